@@ -0,0 +1,61 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// interfaceAddrs lists the addresses of the network interfaces of the device.
+var interfaceAddrs = net.InterfaceAddrs
+
+// parseBindAddress parses the bindAddress setting, the local IP address that the UDP sockets of the
+// tunnel are bound to, for multi-homed devices. The address must belong to an interface of the
+// device, since binding fails otherwise.
+func parseBindAddress(value string) (netip.Addr, *platerrors.PlatformError) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil || addr.Zone() != "" {
+		return netip.Addr{}, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("bindAddress must be an IP address, found %q", value),
+			Details: platerrors.InvalidConfigDetails{Field: "bindAddress", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+	addr = addr.Unmap()
+	addrs, err := interfaceAddrs()
+	if err != nil {
+		return netip.Addr{}, &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to list the network interfaces",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	for _, interfaceAddr := range addrs {
+		if ipNet, ok := interfaceAddr.(*net.IPNet); ok {
+			if local, ok := netip.AddrFromSlice(ipNet.IP); ok && local.Unmap() == addr {
+				return addr, nil
+			}
+		}
+	}
+	return netip.Addr{}, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("bindAddress %s is not an address of this device", addr),
+		Details: platerrors.InvalidConfigDetails{Field: "bindAddress", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+	}
+}
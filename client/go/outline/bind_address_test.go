@@ -0,0 +1,60 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient_BindAddress(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	transportConfig := "{$type: tcpudp, tcp: &ss {$type: shadowsocks, endpoint: '" + server.LocalAddr().String() +
+		"', cipher: chacha20-ietf-poly1305, secret: SECRET}, udp: *ss}"
+	result := doParseTunnelConfig("bindAddress: 127.0.0.1\ntransport: " + transportConfig)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+
+	bindAddress := netip.MustParseAddr("127.0.0.1")
+	clientResult := newClient(context.Background(), transportConfig, clientOptions{bindAddress: bindAddress})
+	require.Nil(t, clientResult.Error)
+	conn, err := clientResult.Client.ListenPacket(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.WriteTo([]byte("ping"), &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 53})
+	require.NoError(t, err)
+
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, from, err := server.ReadFrom(make([]byte, 1500))
+	require.NoError(t, err)
+	require.Equal(t, bindAddress, from.(*net.UDPAddr).AddrPort().Addr().Unmap())
+}
+
+func Test_doParseTunnelConfig_InvalidBindAddress(t *testing.T) {
+	for _, value := range []string{"eth0", "192.0.2.1", "fe80::1%eth0"} {
+		result := doParseTunnelConfig("bindAddress: " + value + versionTestTransport)
+		require.NotNil(t, result.Error, value)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Equal(t, platerrors.ErrorDetails{"field": "bindAddress", "reason": platerrors.ReasonInvalidValue}, result.Error.Details)
+	}
+}
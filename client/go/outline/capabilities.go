@@ -0,0 +1,57 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+// TransportCapabilities tells which features a transport supports, for the UI to enable the
+// matching settings. New capabilities are added as fields. It must match the
+// TransportCapabilitiesJson definition in config.ts.
+type TransportCapabilities struct {
+	// UDP is true if the transport relays UDP.
+	UDP bool `json:"udp"`
+	// Fragmentation is true if the transport fragments the stream, like with split or tlsfrag.
+	Fragmentation bool `json:"fragmentation"`
+	// Obfuscation is true if the transport disguises its traffic as another protocol, with a
+	// connection prefix or a TLS or WebSocket layer.
+	Obfuscation bool `json:"obfuscation"`
+	// Multihop is true if the connections go through more than one proxy, like with a chain.
+	Multihop bool `json:"multihop"`
+}
+
+// obfuscationLayerTypes are the layers that make the traffic look like another protocol.
+var obfuscationLayerTypes = map[string]bool{"tls": true, "websocket": true}
+
+// proxyLayerTypes are the layers that relay the connections through a server.
+var proxyLayerTypes = map[string]bool{"http-connect": true, "shadowsocks": true, "socks5": true}
+
+// transportCapabilities derives the capabilities of the parsed config from its layers, as set in
+// the response by [parseTunnelConfig].
+func transportCapabilities(response *TunnelConfig) TransportCapabilities {
+	capabilities := TransportCapabilities{
+		UDP:           response.UDPSupported,
+		Fragmentation: response.Fragmented,
+		Obfuscation:   response.Prefixed,
+	}
+	proxies := 0
+	for _, layer := range response.Layers {
+		if obfuscationLayerTypes[layer.Type] {
+			capabilities.Obfuscation = true
+		}
+		if proxyLayerTypes[layer.Type] {
+			proxies++
+		}
+	}
+	capabilities.Multihop = proxies > 1
+	return capabilities
+}
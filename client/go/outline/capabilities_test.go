@@ -0,0 +1,60 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_Capabilities(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected TransportCapabilities
+	}{
+		{"plain shadowsocks", "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+			TransportCapabilities{UDP: true}},
+		{"prefixed shadowsocks", "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?prefix=POST%20",
+			TransportCapabilities{UDP: true, Obfuscation: true}},
+		{"layered", `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: split
+    bytes: 3
+    dialer:
+      $type: chain
+      dialers:
+        - $type: socks5
+          endpoint: jump.example.com:1080
+        - $type: shadowsocks
+          endpoint: {$type: websocket, url: "wss://exit.example.com/tcp"}
+          cipher: chacha20-ietf-poly1305
+          secret: SECRET
+  udp:
+    $type: disabled`, TransportCapabilities{Fragmentation: true, Obfuscation: true, Multihop: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := doParseTunnelConfig(tc.input)
+			require.Nil(t, result.Error, "Got %v", result.Error)
+			var response TunnelConfig
+			require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+			require.Equal(t, tc.expected, response.Capabilities)
+		})
+	}
+}
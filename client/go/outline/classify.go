@@ -0,0 +1,75 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// ClassifyConfig returns the format of the tunnel config input, with the detection of
+// [ParseTunnelConfig], but without validating the config or creating a client, so it's cheap
+// enough to branch UI behavior on. Remote and file configs are not fetched: they're reported as
+// [ConfigFormatHTTPS] and [ConfigFormatFile].
+//
+// The format is [ConfigFormatUnknown] if the input is not recognized, with an error explaining why
+// if the input is invalid, like a YAML syntax error or an unsupported link.
+func ClassifyConfig(input string) (ConfigFormat, error) {
+	input = strings.TrimPrefix(input, "\ufeff")
+	input = strings.ReplaceAll(input, "\r\n", "\n")
+	input = strings.TrimSpace(input)
+	if perr := checkTunnelConfigSize(input); perr != nil {
+		return ConfigFormatUnknown, perr
+	}
+	if perr := checkEmptyConfig(input); perr != nil {
+		return ConfigFormatUnknown, perr
+	}
+	if strings.HasPrefix(input, "ss://") {
+		return ConfigFormatShadowsocksURL, nil
+	}
+	if decoded, ok := decodeBase64Config(input); ok {
+		input = decoded
+	}
+	switch {
+	case strings.HasPrefix(input, "https://"):
+		return ConfigFormatHTTPS, nil
+	case strings.HasPrefix(input, "file://"):
+		return ConfigFormatFile, nil
+	}
+	if perr := checkUnsupportedURLScheme(input); perr != nil {
+		return ConfigFormatUnknown, perr
+	}
+	if isJSONObject(input) {
+		input = stripJSONComments(input)
+	}
+	var yamlValue map[string]any
+	if err := yaml.Unmarshal([]byte(input), &yamlValue); err != nil {
+		if perr := checkTopLevelShape(input); perr != nil {
+			return ConfigFormatUnknown, perr
+		}
+		return ConfigFormatUnknown, newYAMLParseError(err)
+	}
+	if yamlValue == nil {
+		return ConfigFormatUnknown, nil
+	}
+	if hasKey(yamlValue, "transport") || hasKey(yamlValue, "error") {
+		if yamlValue["error"] != nil {
+			return ConfigFormatProviderError, nil
+		}
+		return ConfigFormatAdvancedYAML, nil
+	}
+	return ConfigFormatLegacyJSON, nil
+}
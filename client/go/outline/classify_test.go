@@ -0,0 +1,69 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		format ConfigFormat
+	}{
+		{"ss link", "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/#Tag", ConfigFormatShadowsocksURL},
+		{"legacy JSON", `{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "SECRET"}`, ConfigFormatLegacyJSON},
+		{"legacy JSON with comments", "{\"server\": \"example.com\" // the host\n}", ConfigFormatLegacyJSON},
+		{"advanced YAML", "transport:\n  $type: tcpudp", ConfigFormatAdvancedYAML},
+		{"advanced YAML with BOM and CRLF", "\ufeffname: Test\r\ntransport: ss://example.com:4321\r\n", ConfigFormatAdvancedYAML},
+		{"provider error", "error:\n  message: Unavailable", ConfigFormatProviderError},
+		{"base64", base64.StdEncoding.EncodeToString([]byte("transport: ss://example.com:4321")), ConfigFormatAdvancedYAML},
+		{"https URL", "https://example.com/config", ConfigFormatHTTPS},
+		{"file URI", "file:///etc/outline/config.yaml", ConfigFormatFile},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := ClassifyConfig(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.format, format)
+		})
+	}
+}
+
+func TestClassifyConfig_Unknown(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		reason platerrors.InvalidConfigReason
+	}{
+		{"empty", " \n", platerrors.ReasonMissing},
+		{"syntax error", "transport: [", platerrors.ReasonSyntax},
+		{"list", "- ss://example.com:4321", platerrors.ReasonWrongShape},
+		{"unsupported link", "vmess://abc", platerrors.ReasonUnsupported},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := ClassifyConfig(tc.input)
+			require.Equal(t, ConfigFormatUnknown, format)
+			perr := platerrors.ToPlatformError(err)
+			require.NotNil(t, perr)
+			require.Equal(t, platerrors.InvalidConfig, perr.Code)
+			require.Equal(t, tc.reason, perr.Details["reason"])
+		})
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"errors"
 	"net"
+	"net/netip"
+	"time"
 
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
@@ -31,6 +33,10 @@ import (
 type Client struct {
 	sd *config.Dialer[transport.StreamConn]
 	pl *config.PacketListener
+	// transportConfig and opts are the config the client was created with, to resolve the first hops
+	// again in [Client.ResolveFirstHops].
+	transportConfig string
+	opts            clientOptions
 }
 
 func (c *Client) DialStream(ctx context.Context, address string) (transport.StreamConn, error) {
@@ -41,6 +47,20 @@ func (c *Client) ListenPacket(ctx context.Context) (net.PacketConn, error) {
 	return c.pl.ListenPacket(ctx)
 }
 
+// StreamProviderInfo returns the info of the stream connections of the client, like their first hop.
+func (c *Client) StreamProviderInfo() config.ConnectionProviderInfo {
+	return c.sd.ConnectionProviderInfo
+}
+
+// PacketProviderInfo returns the info of the packet connections of the client, like their first hop.
+// It's the zero value if the client has no packet listener, as when created for streams only.
+func (c *Client) PacketProviderInfo() config.ConnectionProviderInfo {
+	if c.pl == nil {
+		return config.ConnectionProviderInfo{}
+	}
+	return c.pl.ConnectionProviderInfo
+}
+
 // NewClientResult represents the result of [NewClientAndReturnError].
 //
 // We use a struct instead of a tuple to preserve a strongly typed error that gobind recognizes.
@@ -49,42 +69,121 @@ type NewClientResult struct {
 	Error  *platerrors.PlatformError
 }
 
+// clientOptions are the settings of a tunnel config, outside of the transport, that change how
+// the client is created.
+type clientOptions struct {
+	// addressFamily restricts the addresses used for the first hop. Empty means auto.
+	addressFamily config.AddressFamily
+	// resolver resolves the first hop, instead of the system resolver. The zero value means none.
+	resolver config.ResolverConfig
+	// streamOnly skips the creation of the packet listener, which is left nil.
+	streamOnly bool
+	// noResolve skips the resolution of the first hop, so the creation doesn't use the network.
+	noResolve bool
+	// probeOrder is the path that the connectivity probes try first, probeOrderTCP or probeOrderUDP.
+	// If empty, both paths are probed at once.
+	probeOrder string
+	// tunnelDNS is the resolver that the DNS queries over UDP are sent to, inside the tunnel. The zero
+	// value keeps the destination of the queries.
+	tunnelDNS netip.AddrPort
+	// healthCheckURL is the URL that [Client.CheckHealth] fetches. Empty means none.
+	healthCheckURL string
+	// bindAddress is the local address of the UDP sockets. The zero value lets the system choose.
+	bindAddress netip.Addr
+	// fallbackFirstHop is the host:port used instead of a first hop that fails to resolve. Empty
+	// means none.
+	fallbackFirstHop string
+	// forceFallbackFirstHop dials the fallbackFirstHop instead of the first hops, even if they resolve.
+	forceFallbackFirstHop bool
+	// probeFallbackFirstHop is the fallbackFirstHop of the config, which the connectivity probes try
+	// if the first hop doesn't connect. The first hops are resolved while creating the client then,
+	// so that a parse can try the fallback if they don't resolve. Empty means none.
+	probeFallbackFirstHop string
+	// hostIPOverride maps first hop hosts to the IP to use instead of resolving them, in the form
+	// returned by [parseHostIPOverride], so that the options stay comparable. Empty means none.
+	hostIPOverride string
+	// keepalive is the interval of the TCP keepalive probes of the stream sockets. Zero keeps the
+	// default of [net.Dialer], which currently sends probes every 15 seconds.
+	keepalive time.Duration
+}
+
 // NewClient creates a new Outline client from a configuration string.
 func NewClient(transportConfig string) *NewClientResult {
-	tcpDialer := transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	if perr := checkInsecureCipher(transportConfig); perr != nil {
+		return &NewClientResult{Error: perr}
+	}
+	if perr := checkSIP022Cipher(transportConfig); perr != nil {
+		return &NewClientResult{Error: perr}
+	}
+	return newClient(context.Background(), transportConfig, clientOptions{})
+}
+
+func newClient(ctx context.Context, transportConfig string, opts clientOptions) *NewClientResult {
+	tcpDialer := newBaseTCPDialer(opts)
 	udpDialer := transport.UDPDialer{}
-	client, err := NewClientWithBaseDialers(transportConfig, &tcpDialer, &udpDialer)
+	if opts.bindAddress.IsValid() {
+		udpDialer.Dialer.LocalAddr = net.UDPAddrFromAddrPort(netip.AddrPortFrom(opts.bindAddress, 0))
+	}
+	client, err := newClientWithBaseDialers(ctx, transportConfig, tcpDialer, &udpDialer, opts)
 	if err != nil {
 		return &NewClientResult{Error: platerrors.ToPlatformError(err)}
 	}
 	return &NewClientResult{Client: client}
 }
 
+// newBaseTCPDialer returns the dialer of the stream sockets to the first hop.
+func newBaseTCPDialer(opts clientOptions) *transport.TCPDialer {
+	return &transport.TCPDialer{Dialer: net.Dialer{KeepAlive: opts.keepalive}}
+}
+
 func NewClientWithBaseDialers(transportConfig string, tcpDialer transport.StreamDialer, udpDialer transport.PacketDialer) (*Client, error) {
+	return newClientWithBaseDialers(context.Background(), transportConfig, tcpDialer, udpDialer, clientOptions{})
+}
+
+func newClientWithBaseDialers(ctx context.Context, transportConfig string, tcpDialer transport.StreamDialer, udpDialer transport.PacketDialer, opts clientOptions) (*Client, error) {
 	transportYAML, err := config.ParseConfigYAML(transportConfig)
 	if err != nil {
 		return nil, &platerrors.PlatformError{
 			Code:    platerrors.InvalidConfig,
 			Message: "config is not valid YAML",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonSyntax}.ToErrorDetails(),
 			Cause:   platerrors.ToPlatformError(err),
 		}
 	}
 
-	transportPair, err := config.NewDefaultTransportProvider(tcpDialer, udpDialer).Parse(context.Background(), transportYAML)
+	var providerOptions []config.ProviderOption
+	if opts.addressFamily != "" {
+		providerOptions = append(providerOptions, config.WithAddressFamily(opts.addressFamily))
+	}
+	if opts.resolver != (config.ResolverConfig{}) {
+		providerOptions = append(providerOptions, config.WithResolver(opts.resolver))
+	}
+	if opts.streamOnly {
+		providerOptions = append(providerOptions, config.WithStreamOnly())
+	}
+	if opts.noResolve {
+		providerOptions = append(providerOptions, config.WithoutResolution())
+	}
+	if opts.fallbackFirstHop != "" {
+		providerOptions = append(providerOptions, config.WithFallbackFirstHop(opts.fallbackFirstHop))
+		if opts.forceFallbackFirstHop {
+			providerOptions = append(providerOptions, config.WithForcedFallbackFirstHop())
+		}
+	}
+	if opts.probeFallbackFirstHop != "" {
+		providerOptions = append(providerOptions, config.WithFirstHopResolution())
+	}
+	if opts.hostIPOverride != "" {
+		providerOptions = append(providerOptions, config.WithHostIPOverrides(hostIPOverrideMap(opts.hostIPOverride)))
+	}
+	transportPair, err := config.NewDefaultTransportProvider(tcpDialer, udpDialer, providerOptions...).Parse(ctx, transportYAML)
 	if err != nil {
-		if errors.Is(err, errors.ErrUnsupported) {
-			return nil, &platerrors.PlatformError{
-				Code:    platerrors.InvalidConfig,
-				Message: "unsupported config",
-				Cause:   platerrors.ToPlatformError(err),
-			}
-		} else {
-			return nil, &platerrors.PlatformError{
-				Code:    platerrors.InvalidConfig,
-				Message: "failed to create transport",
-				Cause:   platerrors.ToPlatformError(err),
-			}
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx.Err())
 		}
+		perr := newTransportError(err)
+		addFailedLayerDetails(perr, err, transportYAML)
+		return nil, perr
 	}
 
 	// Make sure the transport is not proxyless for now.
@@ -92,14 +191,100 @@ func NewClientWithBaseDialers(transportConfig string, tcpDialer transport.Stream
 		return nil, &platerrors.PlatformError{
 			Code:    platerrors.InvalidConfig,
 			Message: "transport must tunnel TCP traffic",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonInvalidTransport}.ToErrorDetails(),
 		}
 	}
-	if transportPair.PacketListener.ConnType == config.ConnTypeDirect {
+	if transportPair.PacketListener != nil && transportPair.PacketListener.ConnType == config.ConnTypeDirect {
 		return nil, &platerrors.PlatformError{
 			Code:    platerrors.InvalidConfig,
 			Message: "transport must tunnel UDP traffic",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonInvalidTransport}.ToErrorDetails(),
+		}
+	}
+
+	logger := loggerFromContext(ctx)
+	if transportPair.PacketListener != nil {
+		logger.DebugContext(ctx, "created transport", "streamFirstHops", transportPair.StreamDialer.AllFirstHops(),
+			"packetFirstHops", transportPair.PacketListener.AllFirstHops(), "noResolve", opts.noResolve)
+	} else {
+		logger.DebugContext(ctx, "created transport", "streamFirstHops", transportPair.StreamDialer.AllFirstHops(),
+			"noResolve", opts.noResolve)
+	}
+
+	if transportPair.PacketListener != nil && opts.tunnelDNS.IsValid() {
+		transportPair.PacketListener.PacketListener = newTunnelDNSPacketListener(transportPair.PacketListener.PacketListener, opts.tunnelDNS)
+	}
+
+	return &Client{sd: transportPair.StreamDialer, pl: transportPair.PacketListener, transportConfig: transportConfig, opts: opts}, nil
+}
+
+// newTransportError classifies an error from creating the transport.
+//
+// Failures to resolve the first hop depend on the network rather than on the config, so they're
+// reported as [platerrors.ResolveIPFailed] to tell callers that a retry is reasonable.
+// Everything else is a [platerrors.InvalidConfig].
+func newTransportError(err error) *platerrors.PlatformError {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return &platerrors.PlatformError{
+			Code:    platerrors.ResolveIPFailed,
+			Message: "failed to resolve the first hop",
+			Details: platerrors.ResolveIPFailedDetails{Host: dnsErr.Name}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	if errors.Is(err, config.ErrAddressFamilyUnavailable) {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "the first hop has no address in the requested address family",
+			Details: platerrors.InvalidConfigDetails{Field: "addressFamily", Reason: platerrors.ReasonInvalidTransport}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
 		}
 	}
+	var typeErr *config.UnsupportedTypeError
+	if errors.As(err, &typeErr) {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "unsupported config",
+			Details: platerrors.InvalidConfigDetails{
+				Field:  "transport",
+				Reason: platerrors.ReasonUnsupported,
+				Extra: platerrors.ErrorDetails{
+					"unknownTransport":    typeErr.Name,
+					"supportedTransports": typeErr.Supported,
+				},
+			}.ToErrorDetails(),
+			Cause: platerrors.ToPlatformError(err),
+		}
+	}
+	if errors.Is(err, errors.ErrUnsupported) {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "unsupported config",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonUnsupported}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "failed to create transport",
+		Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonInvalidTransport}.ToErrorDetails(),
+		Cause:   platerrors.ToPlatformError(err),
+	}
+}
 
-	return &Client{sd: transportPair.StreamDialer, pl: transportPair.PacketListener}, nil
+// newContextError converts the error of a done [context.Context] into a [platerrors.PlatformError].
+func newContextError(err error) *platerrors.PlatformError {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &platerrors.PlatformError{
+			Code:    platerrors.OperationTimedOut,
+			Message: "operation timed out",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.OperationCanceled,
+		Message: "operation canceled",
+		Cause:   platerrors.ToPlatformError(err),
+	}
 }
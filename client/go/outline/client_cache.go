@@ -0,0 +1,131 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+const defaultClientCacheSize = 16
+
+// clientCache is an LRU cache of the [Client] created for each normalized transport config text.
+// Only successfully created clients are cached, so transient failures are retried on the next call.
+type clientCache struct {
+	mu       sync.Mutex
+	capacity int
+	// entries holds *clientCacheEntry values, the most recently used first.
+	entries *list.List
+	index   map[clientCacheKey]*list.Element
+}
+
+type clientCacheEntry struct {
+	key    clientCacheKey
+	client *Client
+}
+
+// clientCacheKey identifies a client by its transport config and the options it was created with.
+type clientCacheKey struct {
+	transportConfig string
+	opts            clientOptions
+}
+
+func newClientCache(capacity int) *clientCache {
+	return &clientCache{
+		capacity: capacity,
+		entries:  list.New(),
+		index:    make(map[clientCacheKey]*list.Element),
+	}
+}
+
+var parsedClients = newClientCache(defaultClientCacheSize)
+
+// SetTunnelConfigCacheSize sets how many parsed tunnel configs are kept to speed up repeated
+// parsing of the same config. A non-positive size disables the cache.
+func SetTunnelConfigCacheSize(size int) {
+	parsedClients.setCapacity(size)
+}
+
+// ClearTunnelConfigCache removes all the parsed tunnel configs from the cache.
+func ClearTunnelConfigCache() {
+	parsedClients.clear()
+}
+
+func (c *clientCache) get(key clientCacheKey) (*Client, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	c.entries.MoveToFront(element)
+	return element.Value.(*clientCacheEntry).client, true
+}
+
+func (c *clientCache) add(key clientCacheKey, client *Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity <= 0 {
+		return
+	}
+	if element, ok := c.index[key]; ok {
+		element.Value.(*clientCacheEntry).client = client
+		c.entries.MoveToFront(element)
+		return
+	}
+	c.index[key] = c.entries.PushFront(&clientCacheEntry{key, client})
+	c.evictLocked()
+}
+
+func (c *clientCache) setCapacity(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = capacity
+	c.evictLocked()
+}
+
+func (c *clientCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Init()
+	clear(c.index)
+}
+
+// evictLocked removes the least recently used entries beyond the capacity. c.mu must be held.
+func (c *clientCache) evictLocked() {
+	for c.entries.Len() > max(c.capacity, 0) {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.index, oldest.Value.(*clientCacheEntry).key)
+	}
+}
+
+// newCachedClient is like [newClient], but reuses the client previously created for the same
+// transport config text and options, if it's still in the cache.
+func newCachedClient(ctx context.Context, transportConfig string, opts clientOptions) *NewClientResult {
+	if ctx.Err() != nil {
+		return &NewClientResult{Error: newContextError(ctx.Err())}
+	}
+	key := clientCacheKey{transportConfig, opts}
+	if client, ok := parsedClients.get(key); ok {
+		return &NewClientResult{Client: client}
+	}
+	result := newClient(ctx, transportConfig, opts)
+	if result.Error == nil {
+		parsedClients.add(key, result.Client)
+	}
+	return result
+}
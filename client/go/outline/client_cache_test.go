@@ -0,0 +1,82 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newClientCache(2)
+	a, b, c := &Client{}, &Client{}, &Client{}
+	cache.add(clientCacheKey{transportConfig: "a"}, a)
+	cache.add(clientCacheKey{transportConfig: "b"}, b)
+	_, ok := cache.get(clientCacheKey{transportConfig: "a"})
+	require.True(t, ok)
+	cache.add(clientCacheKey{transportConfig: "c"}, c)
+
+	_, ok = cache.get(clientCacheKey{transportConfig: "b"})
+	require.False(t, ok)
+	got, ok := cache.get(clientCacheKey{transportConfig: "a"})
+	require.True(t, ok)
+	require.Same(t, a, got)
+	got, ok = cache.get(clientCacheKey{transportConfig: "c"})
+	require.True(t, ok)
+	require.Same(t, c, got)
+}
+
+func TestClientCache_SetCapacityAndClear(t *testing.T) {
+	cache := newClientCache(2)
+	cache.add(clientCacheKey{transportConfig: "a"}, &Client{})
+	cache.add(clientCacheKey{transportConfig: "b"}, &Client{})
+	cache.setCapacity(1)
+	_, ok := cache.get(clientCacheKey{transportConfig: "a"})
+	require.False(t, ok)
+	_, ok = cache.get(clientCacheKey{transportConfig: "b"})
+	require.True(t, ok)
+
+	cache.clear()
+	_, ok = cache.get(clientCacheKey{transportConfig: "b"})
+	require.False(t, ok)
+
+	cache.setCapacity(0)
+	cache.add(clientCacheKey{transportConfig: "a"}, &Client{})
+	_, ok = cache.get(clientCacheKey{transportConfig: "a"})
+	require.False(t, ok)
+}
+
+func TestNewCachedClient(t *testing.T) {
+	defer ClearTunnelConfigCache()
+	const transportConfig = "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"
+
+	first := newCachedClient(context.Background(), transportConfig, clientOptions{})
+	require.Nil(t, first.Error)
+	second := newCachedClient(context.Background(), transportConfig, clientOptions{})
+	require.Nil(t, second.Error)
+	require.Same(t, first.Client, second.Client)
+}
+
+func TestNewCachedClient_ErrorsAreNotCached(t *testing.T) {
+	defer ClearTunnelConfigCache()
+	const transportConfig = "ss://invalid"
+
+	result := newCachedClient(context.Background(), transportConfig, clientOptions{})
+	require.NotNil(t, result.Error)
+	_, ok := parsedClients.get(clientCacheKey{transportConfig: transportConfig})
+	require.False(t, ok)
+}
@@ -15,8 +15,13 @@
 package outline
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
 	"github.com/stretchr/testify/require"
 )
@@ -31,6 +36,35 @@ func Test_NewTransport_SS_URL(t *testing.T) {
 	require.Equal(t, firstHop, result.Client.pl.FirstHop)
 }
 
+func TestClient_ProviderInfo(t *testing.T) {
+	result := NewClient(`
+$type: tcpudp
+tcp:
+    $type: shadowsocks
+    endpoint: tcp.example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+udp:
+    $type: shadowsocks
+    endpoint: udp.example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+
+	streamInfo := result.Client.StreamProviderInfo()
+	require.Equal(t, config.ConnTypeTunneled, streamInfo.ConnType)
+	require.Equal(t, "tcp.example.com:4321", streamInfo.FirstHop)
+	packetInfo := result.Client.PacketProviderInfo()
+	require.Equal(t, config.ConnTypeTunneled, packetInfo.ConnType)
+	require.Equal(t, "udp.example.com:4321", packetInfo.FirstHop)
+	require.Positive(t, packetInfo.MaxPacketSize)
+
+	streamOnly := newClient(context.Background(), "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", clientOptions{streamOnly: true})
+	require.Nil(t, streamOnly.Error, "Got %v", streamOnly.Error)
+	require.Equal(t, "example.com:4321", streamOnly.Client.StreamProviderInfo().FirstHop)
+	require.Equal(t, config.ConnectionProviderInfo{}, streamOnly.Client.PacketProviderInfo())
+}
+
 func Test_NewTransport_Legacy_JSON(t *testing.T) {
 	config := `{
     "server": "example.com",
@@ -127,6 +161,43 @@ secret: EXIT_SECRET`
 	require.Equal(t, firstHop, result.Client.pl.FirstHop)
 }
 
+func Test_NewTransport_Chain(t *testing.T) {
+	config := `
+$type: tcpudp
+tcp:
+    $type: chain
+    dialers:
+      - $type: socks5
+        endpoint: jump.example.com:1080
+      - $type: shadowsocks
+        endpoint: exit.example.com:4321
+        cipher: chacha20-ietf-poly1305
+        secret: SECRET
+udp:
+    $type: disabled`
+
+	result := NewClient(config)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, "jump.example.com:1080", result.Client.sd.FirstHop)
+}
+
+func Test_NewTransport_Chain_PacketOnly(t *testing.T) {
+	config := `
+$type: tcpudp
+tcp:
+    $type: chain
+    dialers:
+      - $type: socks5
+        endpoint: jump.example.com:1080
+      - $type: disabled
+udp:
+    $type: disabled`
+
+	result := NewClient(config)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
 func Test_NewTransport_Explicit_TCPUDP(t *testing.T) {
 	config := `
 $type: tcpudp
@@ -218,6 +289,62 @@ udp:
 	require.Equal(t, firstHop, result.Client.pl.FirstHop)
 }
 
+func Test_NewTransport_Socks5(t *testing.T) {
+	config := `
+$type: tcpudp
+tcp: &base
+    $type: socks5
+    endpoint: localhost:1080
+    username: user
+    password: pass
+udp: *base`
+	firstHop := "localhost:1080"
+
+	result := NewClient(config)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, firstHop, result.Client.sd.FirstHop)
+	require.Equal(t, firstHop, result.Client.pl.FirstHop)
+}
+
+func Test_NewTransport_HTTPConnect(t *testing.T) {
+	config := `
+$type: tcpudp
+tcp:
+    $type: shadowsocks
+    endpoint:
+        $type: dial
+        address: example.com:4321
+        dialer:
+            $type: http-connect
+            endpoint: proxy.example.com:3128
+            username: user
+            password: pass
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+udp:
+    $type: disabled`
+
+	result := NewClient(config)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, "proxy.example.com:3128", result.Client.sd.FirstHop)
+}
+
+func Test_NewTransport_HTTPConnect_InvalidCredentials(t *testing.T) {
+	config := `
+$type: tcpudp
+tcp:
+    $type: http-connect
+    endpoint: proxy.example.com:3128
+    username: "user:name"
+    password: pass
+udp:
+    $type: disabled`
+
+	result := NewClient(config)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
 func Test_NewTransport_DisallowProxyless(t *testing.T) {
 	config := `
 $type: tcpudp
@@ -231,6 +358,20 @@ udp:`
 	require.Equal(t, "transport must tunnel TCP traffic", result.Error.Message)
 }
 
+func Test_NewTransport_InvalidCipherIsConfigError(t *testing.T) {
+	config := `{"server": "example.com", "server_port": 4321, "method": "bad-cipher", "password": "SECRET"}`
+	result := NewClient(config)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
+func Test_newTransportError_UnresolvableHostIsNetworkError(t *testing.T) {
+	err := fmt.Errorf("failed to resolve endpoint address: %w", &net.DNSError{Err: "no such host", Name: "unresolvable.example", IsNotFound: true})
+	perr := newTransportError(err)
+	require.Equal(t, platerrors.ResolveIPFailed, perr.Code)
+	require.Equal(t, "unresolvable.example", perr.Details["host"])
+}
+
 func Test_NewClientFromJSON_Errors(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -291,3 +432,9 @@ func Test_NewClientFromJSON_Errors(t *testing.T) {
 		})
 	}
 }
+
+func Test_newBaseTCPDialer_Keepalive(t *testing.T) {
+	// Zero keeps the default of the dialer, rather than disabling the probes.
+	require.Equal(t, time.Duration(0), newBaseTCPDialer(clientOptions{}).Dialer.KeepAlive)
+	require.Equal(t, 30*time.Second, newBaseTCPDialer(clientOptions{keepalive: 30 * time.Second}).Dialer.KeepAlive)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+)
+
+// ChainConfig is the format for the chain config. It specifies a StreamDialer that connects through
+// a list of hops, like the jump hosts of OpenSSH.
+type ChainConfig struct {
+	// Dialers lists the hops in order. The first one is dialed directly and each other one is dialed
+	// through the previous one.
+	Dialers []ConfigNode
+}
+
+// parseChainStreamDialer creates the dialers of the chain in order. parseSD parses the dialers that
+// connect directly, and newParseSD returns a parser whose direct connections go through the given
+// dialer instead. isPacketOnly tells the $type values that can't create a StreamDialer.
+func parseChainStreamDialer(ctx context.Context, configMap map[string]any, parseSD ParseFunc[*Dialer[transport.StreamConn]], newParseSD func(base transport.StreamDialer) ParseFunc[*Dialer[transport.StreamConn]], isPacketOnly func(name string) bool) (*Dialer[transport.StreamConn], error) {
+	var config ChainConfig
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, fmt.Errorf("invalid config format: %w", err)
+	}
+	if len(config.Dialers) == 0 {
+		return nil, errors.New("empty list of dialers")
+	}
+
+	var sd *Dialer[transport.StreamConn]
+	for i, dialerConfig := range config.Dialers {
+		if typed, ok := dialerConfig.(map[string]any); ok {
+			if name, ok := typed[ConfigTypeKey].(string); ok && isPacketOnly(name) {
+				return nil, fmt.Errorf("chain dialer %d: %q only supports packets and cannot be chained", i, name)
+			}
+		}
+		// Until a hop tunnels the connections, the next one is still reached directly.
+		if sd == nil || sd.ConnType == ConnTypeDirect {
+			next, err := parseSD(ctx, dialerConfig)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse chain dialer %d: %w", i, err)
+			}
+			sd = next
+			continue
+		}
+		next, err := newParseSD(transport.FuncStreamDialer(sd.Dial))(ctx, dialerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain dialer %d: %w", i, err)
+		}
+		// The hop dials through the previous one, so the first hop is still the one of the chain.
+		sd = &Dialer[transport.StreamConn]{tunneledInfo(sd.ConnectionProviderInfo), next.Dial}
+	}
+	return sd, nil
+}
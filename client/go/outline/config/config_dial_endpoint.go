@@ -19,8 +19,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"runtime"
 	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -30,7 +32,48 @@ type DialEndpointConfig struct {
 	Dialer  any
 }
 
-func parseDirectDialerEndpoint[ConnType any](ctx context.Context, config any, newDialer ParseFunc[*Dialer[ConnType]]) (*Endpoint[ConnType], error) {
+// AddressFamily restricts the IP addresses used to reach the first hop.
+type AddressFamily string
+
+const (
+	// AddressFamilyAuto uses any address, preferring IPv4.
+	AddressFamilyAuto AddressFamily = "auto"
+	AddressFamilyIPv4 AddressFamily = "ipv4"
+	AddressFamilyIPv6 AddressFamily = "ipv6"
+)
+
+// ErrAddressFamilyUnavailable is returned when the first hop host has no address in the requested [AddressFamily].
+var ErrAddressFamilyUnavailable = errors.New("host has no address in the requested family")
+
+// firstHopResolution specifies how the first hop host is resolved.
+type firstHopResolution struct {
+	family AddressFamily
+	// lookupIP is the custom resolver, if any. Nil means the system resolver.
+	lookupIP lookupIPFunc
+	// disabled skips the resolution, even if a family or resolver is set.
+	disabled bool
+	// fallback is the host:port address to use instead of an address that fails to resolve, if set.
+	fallback string
+	// forceFallback uses the fallback instead of every address, without trying them first.
+	forceFallback bool
+	// required resolves the addresses on every platform, unless disabled, so that an address that
+	// doesn't resolve fails the parse.
+	required bool
+	// hostIPs maps lowercase host names to the IP to use instead of resolving them.
+	hostIPs map[string]netip.Addr
+}
+
+// overridesHost tells whether the IP of the host of address is set in hostIPs.
+func (r firstHopResolution) overridesHost(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	_, ok := r.hostIPs[strings.ToLower(host)]
+	return ok
+}
+
+func parseDirectDialerEndpoint[ConnType any](ctx context.Context, config any, newDialer ParseFunc[*Dialer[ConnType]], resolution firstHopResolution) (*Endpoint[ConnType], error) {
 	if config == nil {
 		return nil, errors.New("endpoint config cannot be nil")
 	}
@@ -48,13 +91,33 @@ func parseDirectDialerEndpoint[ConnType any](ctx context.Context, config any, ne
 	// We need to resolve to the proxy server address before attempting a connection.
 	// This is because we cannot protect the system DNS resolution connection
 	// with our FW_MARK (Linux) or by binding to an interface (Windows). Therefore, as a workaround on Linux and Windows, we resolve the address first.
+	// If an address family or a resolver is requested, we also need to resolve it to constrain the dialed address.
+	// A fallback needs the resolution too, to tell whether the address resolves.
 	ipPortStr := dialParams.Address
-	if dialer.ConnType == ConnTypeDirect && (runtime.GOOS == "linux" || runtime.GOOS == "windows") && !testing.Testing() {
-		ipPort, err := net.ResolveTCPAddr("tcp", ipPortStr)
+	firstHop := dialParams.Address
+	if dialer.ConnType == ConnTypeDirect && resolution.forceFallback && resolution.fallback != "" {
+		ipPortStr, firstHop = resolution.fallback, resolution.fallback
+	}
+	// An overridden host is always "resolved", since it doesn't use the network, but it's still
+	// reported as written.
+	overridden := resolution.overridesHost(ipPortStr)
+	pinAddress := (resolution.family != "" && resolution.family != AddressFamilyAuto) || resolution.lookupIP != nil
+	hasFallback := resolution.fallback != "" && !resolution.forceFallback
+	if dialer.ConnType == ConnTypeDirect && (overridden || (!resolution.disabled && (pinAddress || hasFallback || resolution.required || ((runtime.GOOS == "linux" || runtime.GOOS == "windows") && !testing.Testing())))) {
+		ipPort, err := resolveTCPAddr(ctx, ipPortStr, resolution)
+		if err != nil && hasFallback && ctx.Err() == nil {
+			if fallbackIPPort, fallbackErr := resolveTCPAddr(ctx, resolution.fallback, resolution); fallbackErr == nil {
+				ipPort, err = fallbackIPPort, nil
+				firstHop = resolution.fallback
+			}
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve endpoint address %s: %w", ipPortStr, err)
 		}
 		ipPortStr = ipPort.String()
+		if pinAddress && !overridden {
+			firstHop = ipPortStr
+		}
 	}
 
 	endpoint := &Endpoint[ConnType]{
@@ -64,7 +127,7 @@ func parseDirectDialerEndpoint[ConnType any](ctx context.Context, config any, ne
 		ConnectionProviderInfo: dialer.ConnectionProviderInfo,
 	}
 	if dialer.ConnType == ConnTypeDirect {
-		endpoint.ConnectionProviderInfo.FirstHop = dialParams.Address
+		endpoint.ConnectionProviderInfo.FirstHop = firstHop
 	}
 	return endpoint, nil
 }
@@ -111,3 +174,62 @@ func toDialEndpointConfig(node ConfigNode) (*DialEndpointConfig, error) {
 		return nil, fmt.Errorf("endpoint config of type %T is not supported", typed)
 	}
 }
+
+// resolveTCPAddr is like [net.ResolveTCPAddr], but aborts when ctx is done, uses the requested
+// resolver, and only returns addresses in the requested family.
+func resolveTCPAddr(ctx context.Context, address string, resolution firstHopResolution) (*net.TCPAddr, error) {
+	family := resolution.family
+	lookupIP := resolution.lookupIP
+	if lookupIP == nil {
+		lookupIP = lookupSystemIP
+	}
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "tcp", portText)
+	if err != nil {
+		return nil, err
+	}
+	var ips []netip.Addr
+	if ip, ok := resolution.hostIPs[strings.ToLower(host)]; ok {
+		ips = []netip.Addr{ip}
+	} else if ip, ok := literalIP(host); ok {
+		ips = []netip.Addr{ip}
+	} else if ips, err = lookupIP(ctx, host); err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for host %v", host)
+	}
+	var ip netip.Addr
+	switch family {
+	case AddressFamilyIPv4:
+		for _, candidate := range ips {
+			if candidate.Unmap().Is4() {
+				ip = candidate.Unmap()
+				break
+			}
+		}
+	case AddressFamilyIPv6:
+		for _, candidate := range ips {
+			if candidate.Is6() && !candidate.Is4In6() {
+				ip = candidate
+				break
+			}
+		}
+	default:
+		// Prefer IPv4, like net.ResolveTCPAddr.
+		ip = ips[0]
+		for _, candidate := range ips {
+			if candidate.Is4() || candidate.Is4In6() {
+				ip = candidate.Unmap()
+				break
+			}
+		}
+	}
+	if !ip.IsValid() {
+		return nil, fmt.Errorf("host %v has no %v address: %w", host, family, ErrAddressFamilyUnavailable)
+	}
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, uint16(port))), nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+)
+
+// disabledPacketListener is the [transport.PacketListener] of transports that don't support UDP.
+type disabledPacketListener struct{}
+
+var _ transport.PacketListener = disabledPacketListener{}
+
+func (disabledPacketListener) ListenPacket(ctx context.Context) (net.PacketConn, error) {
+	return nil, fmt.Errorf("UDP is disabled: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+)
+
+// isEndpointAddress returns whether the transport config is a host:port string, rather than a URL.
+func isEndpointAddress(node ConfigNode) bool {
+	address, ok := node.(string)
+	return ok && !strings.Contains(address, "://")
+}
+
+// parseEndpointTransport creates a [TransportPair] that sends all the connections and packets to
+// the host:port address, as is. It's meant for upstreams that already tunnel the traffic, like an
+// authenticated forwarder.
+func parseEndpointTransport(ctx context.Context, address string, parseSE ParseFunc[*Endpoint[transport.StreamConn]], parsePE ParseFunc[*Endpoint[net.Conn]]) (*TransportPair, error) {
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("endpoint must be host:port: %w", err)
+	}
+	if host == "" {
+		return nil, fmt.Errorf("endpoint %q is missing the host", address)
+	}
+	if port, err := strconv.ParseUint(portText, 10, 16); err != nil || port == 0 {
+		return nil, fmt.Errorf("endpoint %q has an invalid port", address)
+	}
+
+	se, err := parseSE(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create StreamEndpoint: %w", err)
+	}
+	streamDialer := &Dialer[transport.StreamConn]{tunneledInfo(se.ConnectionProviderInfo), func(ctx context.Context, _ string) (transport.StreamConn, error) {
+		return se.Connect(ctx)
+	}}
+	if parsePE == nil {
+		return &TransportPair{StreamDialer: streamDialer}, nil
+	}
+
+	pe, err := parsePE(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PacketEndpoint: %w", err)
+	}
+	return &TransportPair{
+		streamDialer,
+		&PacketListener{tunneledInfo(pe.ConnectionProviderInfo), endpointPacketListener{pe.Connect}},
+	}, nil
+}
+
+// endpointPacketListener creates PacketConns that send all the packets to the connected endpoint.
+type endpointPacketListener struct {
+	connect func(context.Context) (net.Conn, error)
+}
+
+var _ transport.PacketListener = (*endpointPacketListener)(nil)
+
+func (l endpointPacketListener) ListenPacket(ctx context.Context) (net.PacketConn, error) {
+	conn, err := l.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &endpointPacketConn{conn}, nil
+}
+
+// endpointPacketConn is a [net.PacketConn] over a connected [net.Conn]. The destination addresses
+// are ignored, and all the packets come from the endpoint.
+type endpointPacketConn struct {
+	net.Conn
+}
+
+var _ net.PacketConn = (*endpointPacketConn)(nil)
+
+func (c *endpointPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, err := c.Read(p)
+	return n, c.RemoteAddr(), err
+}
+
+func (c *endpointPacketConn) WriteTo(p []byte, _ net.Addr) (int, error) {
+	return c.Write(p)
+}
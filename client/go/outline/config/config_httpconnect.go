@@ -0,0 +1,85 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/x/httpconnect"
+)
+
+// HTTPConnectConfig is the format for the HTTP CONNECT config. It specifies a StreamDialer that
+// connects through an HTTP proxy, optionally with basic authentication.
+type HTTPConnectConfig struct {
+	Endpoint ConfigNode
+	Username string
+	Password string
+}
+
+func parseHTTPConnectStreamDialer(ctx context.Context, configMap map[string]any, parseSE ParseFunc[*Endpoint[transport.StreamConn]]) (*Dialer[transport.StreamConn], error) {
+	var config HTTPConnectConfig
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, fmt.Errorf("invalid config format: %w", err)
+	}
+
+	headers := make(http.Header)
+	if config.Username != "" || config.Password != "" {
+		if err := validateBasicAuth(config.Username, config.Password); err != nil {
+			return nil, fmt.Errorf("invalid basic auth credentials: %w", err)
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Username + ":" + config.Password))
+		headers.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	se, err := parseSE(ctx, config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTTP CONNECT endpoint: %w", err)
+	}
+	if se.FirstHop == "" {
+		return nil, errors.New("HTTP CONNECT endpoint must have an address")
+	}
+
+	// The endpoint already knows where to connect, so the proxy address is only used for errors.
+	endpointDialer := transport.FuncStreamDialer(func(ctx context.Context, _ string) (transport.StreamConn, error) {
+		return se.Connect(ctx)
+	})
+	sd, err := httpconnect.NewConnectClient(endpointDialer, se.FirstHop, httpconnect.WithHeaders(headers))
+	if err != nil {
+		return nil, err
+	}
+	return &Dialer[transport.StreamConn]{tunneledInfo(se.ConnectionProviderInfo), sd.DialStream}, nil
+}
+
+// validateBasicAuth checks the credentials can be encoded for HTTP basic authentication, as specified
+// in RFC 7617.
+func validateBasicAuth(username, password string) error {
+	if username == "" {
+		return errors.New("username must not be empty")
+	}
+	if strings.Contains(username, ":") {
+		return errors.New("username must not contain ':'")
+	}
+	if strings.ContainsFunc(username+password, unicode.IsControl) {
+		return errors.New("credentials must not contain control characters")
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+
+	"github.com/Jigsaw-Code/outline-sdk/dns"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ResolverConfig is the format for the resolver config. It specifies the DNS resolver used to
+// resolve the first hop host, instead of the system resolver.
+type ResolverConfig struct {
+	// URL is the DNS-over-HTTPS URL of the resolver. If empty, the resolver uses plain DNS over UDP.
+	URL string
+	// Address is the host:port of the plain DNS resolver. With a URL, it's the address to connect
+	// to the DNS-over-HTTPS server, which defaults to the host of the URL.
+	Address string
+}
+
+// Validate returns an error if the resolver config is malformed.
+func (c ResolverConfig) Validate() error {
+	if c.URL == "" {
+		if c.Address == "" {
+			return errors.New("resolver must have a url or an address")
+		}
+		return nil
+	}
+	resolverURL, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid resolver url: %w", err)
+	}
+	if resolverURL.Scheme != "https" {
+		return fmt.Errorf("resolver url must use https, found %q", resolverURL.Scheme)
+	}
+	if resolverURL.Hostname() == "" {
+		return errors.New("resolver url must have a host")
+	}
+	return nil
+}
+
+// lookupIPFunc returns the IP addresses of host.
+type lookupIPFunc func(ctx context.Context, host string) ([]netip.Addr, error)
+
+// literalIP returns host as an address if it's an IP literal. The zone of link-local IPv6 addresses,
+// like fe80::1%eth0, is kept, while the resolvers drop it.
+func literalIP(host string) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(host)
+	return ip, err == nil
+}
+
+func lookupSystemIP(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+// newResolverLookup creates a [lookupIPFunc] that queries the resolver in config, connecting to
+// it with the given base dialers.
+func newResolverLookup(config ResolverConfig, sd transport.StreamDialer, pd transport.PacketDialer) (lookupIPFunc, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	var resolver dns.Resolver
+	if config.URL != "" {
+		address := config.Address
+		if address == "" {
+			resolverURL, _ := url.Parse(config.URL)
+			address = resolverURL.Host
+		}
+		resolver = dns.NewHTTPSResolver(sd, address, config.URL)
+	} else {
+		resolver = dns.NewUDPResolver(pd, config.Address)
+	}
+	return func(ctx context.Context, host string) ([]netip.Addr, error) {
+		if ip, err := netip.ParseAddr(host); err == nil {
+			return []netip.Addr{ip}, nil
+		}
+		var ips []netip.Addr
+		var errs []error
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			found, err := queryIPs(ctx, resolver, host, qtype)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			ips = append(ips, found...)
+		}
+		if len(ips) == 0 {
+			if len(errs) > 0 {
+				return nil, &net.DNSError{Err: errors.Join(errs...).Error(), Name: host}
+			}
+			return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		return ips, nil
+	}, nil
+}
+
+func queryIPs(ctx context.Context, resolver dns.Resolver, host string, qtype dnsmessage.Type) ([]netip.Addr, error) {
+	q, err := dns.NewQuestion(host, qtype)
+	if err != nil {
+		return nil, err
+	}
+	response, err := resolver.Query(ctx, *q)
+	if err != nil {
+		return nil, err
+	}
+	if response.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("resolver returned %v", response.RCode)
+	}
+	var ips []netip.Addr
+	for _, answer := range response.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, netip.AddrFrom4(body.A))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, netip.AddrFrom16(body.AAAA))
+		}
+	}
+	return ips, nil
+}
+
+// LookupIP returns the IP addresses of host, using the resolver in config reached with the given
+// base dialers. The zero config uses the system resolver.
+func LookupIP(ctx context.Context, host string, config ResolverConfig, sd transport.StreamDialer, pd transport.PacketDialer) ([]netip.Addr, error) {
+	if ip, ok := literalIP(host); ok {
+		return []netip.Addr{ip}, nil
+	}
+	lookupIP := lookupSystemIP
+	if config != (ResolverConfig{}) {
+		var err error
+		if lookupIP, err = newResolverLookup(config, sd, pd); err != nil {
+			return nil, err
+		}
+	}
+	return lookupIP(ctx, host)
+}
@@ -35,6 +35,9 @@ type ShadowsocksConfig struct {
 	Cipher   string
 	Secret   string
 	Prefix   string
+	// PrefixPreset is the name of one of the presets of [shadowsocksPrefixPresets], used instead of
+	// a raw Prefix.
+	PrefixPreset string `yaml:"prefixPreset"`
 }
 
 // LegacyShadowsocksConfig is the legacy format for the Shadowsocks config.
@@ -44,6 +47,8 @@ type LegacyShadowsocksConfig struct {
 	Method      string
 	Password    string
 	Prefix      string
+	// PrefixPreset is like [ShadowsocksConfig.PrefixPreset].
+	PrefixPreset string `yaml:"prefixPreset"`
 }
 
 func parseShadowsocksTransport(ctx context.Context, config ConfigNode, parseSE ParseFunc[*Endpoint[transport.StreamConn]], parsePE ParseFunc[*Endpoint[net.Conn]]) (*TransportPair, error) {
@@ -63,6 +68,10 @@ func parseShadowsocksTransport(ctx context.Context, config ConfigNode, parseSE P
 	if params.SaltGenerator != nil {
 		sd.SaltGenerator = params.SaltGenerator
 	}
+	streamDialer := &Dialer[transport.StreamConn]{tunneledInfo(se.ConnectionProviderInfo), sd.DialStream}
+	if parsePE == nil {
+		return &TransportPair{StreamDialer: streamDialer}, nil
+	}
 
 	pe, err := parsePE(ctx, params.Endpoint)
 	if err != nil {
@@ -75,8 +84,8 @@ func parseShadowsocksTransport(ctx context.Context, config ConfigNode, parseSE P
 	// For the Shadowsocks transport, the prefix only applies to TCP. To use a prefix with UDP, one needs to
 	// specify it in the PacketListener config explicitly. This is to ensure backwards-compatibility.
 	return &TransportPair{
-		&Dialer[transport.StreamConn]{ConnectionProviderInfo{ConnTypeTunneled, se.FirstHop}, sd.DialStream},
-		&PacketListener{ConnectionProviderInfo{ConnTypeTunneled, pe.FirstHop}, pl},
+		streamDialer,
+		&PacketListener{shadowsocksPacketInfo(pe.ConnectionProviderInfo, params.Key), pl},
 	}, nil
 }
 
@@ -98,7 +107,7 @@ func parseShadowsocksStreamDialer(ctx context.Context, config ConfigNode, parseS
 		sd.SaltGenerator = params.SaltGenerator
 	}
 
-	return &Dialer[transport.StreamConn]{ConnectionProviderInfo{ConnTypeTunneled, se.FirstHop}, sd.DialStream}, nil
+	return &Dialer[transport.StreamConn]{tunneledInfo(se.ConnectionProviderInfo), sd.DialStream}, nil
 }
 
 func parseShadowsocksPacketDialer(ctx context.Context, config ConfigNode, parsePE ParseFunc[*Endpoint[net.Conn]]) (*Dialer[net.Conn], error) {
@@ -107,7 +116,7 @@ func parseShadowsocksPacketDialer(ctx context.Context, config ConfigNode, parseP
 		return nil, err
 	}
 	pd := transport.PacketListenerDialer{Listener: pl}
-	return &Dialer[net.Conn]{ConnectionProviderInfo{ConnTypeTunneled, pl.FirstHop}, pd.DialPacket}, nil
+	return &Dialer[net.Conn]{tunneledInfo(pl.ConnectionProviderInfo), pd.DialPacket}, nil
 }
 
 func parseShadowsocksPacketListener(ctx context.Context, config ConfigNode, parsePE ParseFunc[*Endpoint[net.Conn]]) (*PacketListener, error) {
@@ -126,7 +135,22 @@ func parseShadowsocksPacketListener(ctx context.Context, config ConfigNode, pars
 	if params.SaltGenerator != nil {
 		pl.SetSaltGenerator(params.SaltGenerator)
 	}
-	return &PacketListener{ConnectionProviderInfo{ConnTypeTunneled, pe.FirstHop}, pl}, nil
+	return &PacketListener{shadowsocksPacketInfo(pe.ConnectionProviderInfo, params.Key), pl}, nil
+}
+
+// shadowsocksMaxAddressSize is the size of the largest SOCKS address of the IP destinations that
+// prefixes the payload of Shadowsocks packets: the type, the IPv6 address and the port.
+const shadowsocksMaxAddressSize = 1 + 16 + 2
+
+// shadowsocksPacketInfo returns the info of the Shadowsocks packets sent over the endpoint. Each
+// packet adds a salt, the destination address and the AEAD tag to the payload, which are subtracted
+// from the max packet size of the endpoint.
+func shadowsocksPacketInfo(endpointInfo ConnectionProviderInfo, key *shadowsocks.EncryptionKey) ConnectionProviderInfo {
+	info := tunneledInfo(endpointInfo)
+	if endpointInfo.MaxPacketSize > 0 {
+		info.MaxPacketSize = max(endpointInfo.MaxPacketSize-key.SaltSize()-shadowsocksMaxAddressSize-key.TagSize(), 0)
+	}
+	return info
 }
 
 type shadowsocksParams struct {
@@ -135,6 +159,12 @@ type shadowsocksParams struct {
 	SaltGenerator shadowsocks.SaltGenerator
 }
 
+// ParseShadowsocksConfig parses a Shadowsocks config in any of the supported formats, including
+// ss:// URLs and the legacy JSON format, without creating any dialer.
+func ParseShadowsocksConfig(node ConfigNode) (*ShadowsocksConfig, error) {
+	return parseShadowsocksConfig(node)
+}
+
 func parseShadowsocksConfig(node ConfigNode) (*ShadowsocksConfig, error) {
 	switch typed := node.(type) {
 	case string:
@@ -158,10 +188,11 @@ func parseShadowsocksConfig(node ConfigNode) (*ShadowsocksConfig, error) {
 				return nil, err
 			}
 			return &ShadowsocksConfig{
-				Endpoint: net.JoinHostPort(config.Server, strconv.FormatUint(uint64(config.Server_Port), 10)),
-				Cipher:   config.Method,
-				Secret:   config.Password,
-				Prefix:   config.Prefix,
+				Endpoint:     net.JoinHostPort(config.Server, strconv.FormatUint(uint64(config.Server_Port), 10)),
+				Cipher:       config.Method,
+				Secret:       config.Password,
+				Prefix:       config.Prefix,
+				PrefixPreset: config.PrefixPreset,
 			}, nil
 		} else {
 			return nil, fmt.Errorf("shadowsocks config missing endpoint")
@@ -191,8 +222,19 @@ func parseShadowsocksParams(node ConfigNode) (*shadowsocksParams, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid cipher: %w", err)
 	}
-	if len(config.Prefix) > 0 {
-		prefixBytes, err := parseStringPrefix(config.Prefix)
+	prefix := config.Prefix
+	if len(config.PrefixPreset) > 0 {
+		if len(config.Prefix) > 0 {
+			return nil, errors.New("prefix and prefixPreset are mutually exclusive")
+		}
+		preset, ok := shadowsocksPrefixPresets[config.PrefixPreset]
+		if !ok {
+			return nil, fmt.Errorf("unknown prefix preset %q", config.PrefixPreset)
+		}
+		prefix = preset
+	}
+	if len(prefix) > 0 {
+		prefixBytes, err := parseStringPrefix(prefix)
 		if err != nil {
 			return nil, fmt.Errorf("invalid prefix: %w", err)
 		}
@@ -201,6 +243,20 @@ func parseShadowsocksParams(node ConfigNode) (*shadowsocksParams, error) {
 	return params, nil
 }
 
+// shadowsocksPrefixPresets are the named prefixes that the prefixPreset field selects instead of
+// the raw bytes of the prefix field, to make the connections look like common protocols. Like the
+// raw prefixes, each character is a byte.
+var shadowsocksPrefixPresets = map[string]string{
+	"http-get":             "GET / HTTP/1.1\r\n",
+	"http-post":            "POST ",
+	"http-response":        "HTTP/1.1 ",
+	"dns-over-tcp":         "\x05\u00dc\x5f\u00e0\x01\x20",
+	"tls-client-hello":     "\x16\x03\x01\x00\u00a8\x01\x01",
+	"tls-server-hello":     "\x16\x03\x03\x40\x00\x02",
+	"tls-application-data": "\x13\x03\x03\x3f",
+	"ssh":                  "SSH-2.0\r\n",
+}
+
 func parseStringPrefix(utf8Str string) ([]byte, error) {
 	runes := []rune(utf8Str)
 	rawBytes := make([]byte, len(runes))
@@ -269,10 +325,11 @@ func parseShadowsocksLegacyBase64URL(url url.URL) (*ShadowsocksConfig, error) {
 	}
 
 	return &ShadowsocksConfig{
-		Endpoint: newURL.Host,
-		Cipher:   cipherName,
-		Secret:   secret,
-		Prefix:   newURL.Query().Get("prefix"),
+		Endpoint:     newURL.Host,
+		Cipher:       cipherName,
+		Secret:       secret,
+		Prefix:       newURL.Query().Get("prefix"),
+		PrefixPreset: newURL.Query().Get("prefixPreset"),
 	}, nil
 }
 
@@ -309,9 +366,10 @@ func parseShadowsocksSIP002URL(url url.URL) (*ShadowsocksConfig, error) {
 		}
 	}
 	return &ShadowsocksConfig{
-		Endpoint: url.Host,
-		Cipher:   cipherName,
-		Secret:   secret,
-		Prefix:   url.Query().Get("prefix"),
+		Endpoint:     url.Host,
+		Cipher:       cipherName,
+		Secret:       secret,
+		Prefix:       url.Query().Get("prefix"),
+		PrefixPreset: url.Query().Get("prefixPreset"),
 	}, nil
 }
@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"net"
 	"net/url"
 	"testing"
@@ -187,3 +188,77 @@ func TestParseShadowsocksConfig_YAML(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestParseShadowsocksParams_PrefixPresets(t *testing.T) {
+	for name, prefix := range shadowsocksPrefixPresets {
+		params, err := parseShadowsocksParams(map[string]any{
+			"endpoint":     "example.com:1234",
+			"cipher":       "chacha20-ietf-poly1305",
+			"secret":       "SECRET",
+			"prefixPreset": name,
+		})
+		require.NoError(t, err, name)
+		salt := make([]byte, 32)
+		require.NoError(t, params.SaltGenerator.GetSalt(salt), name)
+		prefixBytes, err := parseStringPrefix(prefix)
+		require.NoError(t, err, name)
+		require.Equal(t, prefixBytes, salt[:len(prefixBytes)], name)
+	}
+}
+
+func TestParseShadowsocksParams_RawPrefixMatchingPreset(t *testing.T) {
+	// The raw prefix is used as is, even if it's the name of a preset.
+	params, err := parseShadowsocksParams("ss://chacha20-ietf-poly1305:SECRET@example.com:1234/?prefix=ssh")
+	require.NoError(t, err)
+	salt := make([]byte, 32)
+	require.NoError(t, params.SaltGenerator.GetSalt(salt))
+	require.Equal(t, []byte("ssh"), salt[:3])
+}
+
+func TestParseShadowsocksParams_InvalidPrefixPreset(t *testing.T) {
+	for _, config := range []map[string]any{
+		{"endpoint": "example.com:1234", "cipher": "chacha20-ietf-poly1305", "secret": "SECRET", "prefixPreset": "unknown"},
+		{"endpoint": "example.com:1234", "cipher": "chacha20-ietf-poly1305", "secret": "SECRET", "prefixPreset": "ssh", "prefix": "SSH"},
+	} {
+		_, err := parseShadowsocksParams(config)
+		require.Error(t, err, config)
+	}
+}
+
+func TestShadowsocksPrefixPreset_Dial(t *testing.T) {
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	for _, config := range []string{
+		fmt.Sprintf(`
+endpoint: %s
+cipher: chacha20-ietf-poly1305
+secret: SECRET
+prefixPreset: http-get`, listener.Addr()),
+		fmt.Sprintf("ss://chacha20-ietf-poly1305:SECRET@%s/?prefixPreset=http-get", listener.Addr()),
+	} {
+		received := make(chan []byte, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			prefix := make([]byte, len("GET / HTTP/1.1\r\n"))
+			io.ReadFull(conn, prefix)
+			received <- prefix
+		}()
+
+		node, err := ParseConfigYAML(config)
+		require.NoError(t, err)
+		transportPair, err := newTestTransportProvider().Parse(context.Background(), node)
+		require.NoError(t, err)
+		conn, err := transportPair.StreamDialer.Dial(context.Background(), "example.com:80")
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\n\r\n"))
+		require.NoError(t, err)
+		require.Equal(t, "GET / HTTP/1.1\r\n", string(<-received))
+		conn.Close()
+	}
+}
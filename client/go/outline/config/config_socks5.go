@@ -0,0 +1,80 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/transport/socks5"
+)
+
+// Socks5Config is the format for the SOCKS5 config. It can specify a StreamDialer or PacketListener.
+type Socks5Config struct {
+	Endpoint ConfigNode
+	Username string
+	Password string
+}
+
+func parseSocks5StreamDialer(ctx context.Context, configMap map[string]any, parseSE ParseFunc[*Endpoint[transport.StreamConn]]) (*Dialer[transport.StreamConn], error) {
+	client, se, err := newSocks5Client(ctx, configMap, parseSE)
+	if err != nil {
+		return nil, err
+	}
+	return &Dialer[transport.StreamConn]{tunneledInfo(se.ConnectionProviderInfo), client.DialStream}, nil
+}
+
+func parseSocks5PacketListener(ctx context.Context, configMap map[string]any, parseSE ParseFunc[*Endpoint[transport.StreamConn]], parsePD ParseFunc[*Dialer[net.Conn]]) (*PacketListener, error) {
+	client, se, err := newSocks5Client(ctx, configMap, parseSE)
+	if err != nil {
+		return nil, err
+	}
+	// The UDP relay address is returned by the server, and we send datagrams to it directly.
+	// That only works if we also reach the server directly.
+	if se.ConnType != ConnTypeDirect {
+		return nil, errors.New("SOCKS5 UDP requires a direct endpoint")
+	}
+	pd, err := parsePD(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PacketDialer: %w", err)
+	}
+	client.EnablePacket(transport.FuncPacketDialer(pd.Dial))
+	return &PacketListener{tunneledInfo(se.ConnectionProviderInfo), client}, nil
+}
+
+func newSocks5Client(ctx context.Context, configMap map[string]any, parseSE ParseFunc[*Endpoint[transport.StreamConn]]) (*socks5.Client, *Endpoint[transport.StreamConn], error) {
+	var config Socks5Config
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, nil, fmt.Errorf("invalid config format: %w", err)
+	}
+
+	se, err := parseSE(ctx, config.Endpoint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create StreamEndpoint: %w", err)
+	}
+	client, err := socks5.NewClient(transport.FuncStreamEndpoint(se.Connect))
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.Username != "" || config.Password != "" {
+		if err := client.SetCredentials([]byte(config.Username), []byte(config.Password)); err != nil {
+			return nil, nil, fmt.Errorf("invalid credentials: %w", err)
+		}
+	}
+	return client, se, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/transport/split"
+)
+
+// SplitConfig is the format for the split config. It specifies a StreamDialer that splits the
+// first bytes written to the stream into separate writes.
+type SplitConfig struct {
+	// Bytes is the length of each split segment.
+	Bytes int64
+	// Count is the number of segments to split. Defaults to 1.
+	Count int
+	// Dialer is the dialer to wrap. Defaults to the direct TCP dialer.
+	Dialer ConfigNode
+}
+
+func parseSplitStreamDialer(ctx context.Context, configMap map[string]any, parseSD ParseFunc[*Dialer[transport.StreamConn]]) (*Dialer[transport.StreamConn], error) {
+	var config SplitConfig
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, fmt.Errorf("invalid config format: %w", err)
+	}
+	if config.Bytes <= 0 {
+		return nil, errors.New("bytes must be positive")
+	}
+	if config.Count < 0 {
+		return nil, errors.New("count must not be negative")
+	}
+	if config.Count == 0 {
+		config.Count = 1
+	}
+
+	sd, err := parseSD(ctx, config.Dialer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dialer: %w", err)
+	}
+	baseDialer := transport.FuncStreamDialer(sd.Dial)
+	dial := func(ctx context.Context, address string) (transport.StreamConn, error) {
+		// The split iterator keeps track of the bytes written, so each connection needs its own.
+		splitDialer, err := split.NewStreamDialer(baseDialer, split.NewRepeatedSplitIterator(split.RepeatedSplit{Count: config.Count, Bytes: config.Bytes}))
+		if err != nil {
+			return nil, err
+		}
+		return splitDialer.DialStream(ctx, address)
+	}
+	return &Dialer[transport.StreamConn]{sd.ConnectionProviderInfo, dial}, nil
+}
@@ -39,6 +39,9 @@ func parseTCPUDPTransportPair(ctx context.Context, configMap map[string]any, par
 		return nil, fmt.Errorf("failed to parse StreamDialer: %w", err)
 	}
 
+	if parsePL == nil {
+		return &TransportPair{StreamDialer: sd}, nil
+	}
 	pl, err := parsePL(ctx, config.UDP)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse PacketListener: %w", err)
@@ -0,0 +1,79 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/transport/tls"
+)
+
+// TLSEndpointConfig is the format for the TLS endpoint config, which wraps the connections of
+// another stream endpoint in TLS.
+type TLSEndpointConfig struct {
+	// SNI is the host name sent in the Server Name Indication.
+	// Defaults to the host of the endpoint address.
+	SNI string
+	// CertificateName is the host name used to validate the server certificate. Defaults to the SNI.
+	CertificateName string
+	Endpoint        ConfigNode
+}
+
+func parseTLSStreamEndpoint(ctx context.Context, configMap map[string]any, parseSE ParseFunc[*Endpoint[transport.StreamConn]]) (*Endpoint[transport.StreamConn], error) {
+	var config TLSEndpointConfig
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, fmt.Errorf("invalid config format: %w", err)
+	}
+
+	serverName := config.SNI
+	if serverName == "" {
+		if address, ok := config.Endpoint.(string); ok {
+			serverName, _, _ = net.SplitHostPort(address)
+		}
+	}
+	if serverName == "" {
+		return nil, errors.New("sni must be specified if the endpoint is not an address")
+	}
+	options := []tls.ClientOption{}
+	if config.CertificateName != "" {
+		options = append(options, tls.WithCertificateName(config.CertificateName))
+	}
+
+	se, err := parseSE(ctx, config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS endpoint: %w", err)
+	}
+
+	return &Endpoint[transport.StreamConn]{
+		// TLS doesn't change where we connect to, so the first hop is the one of the wrapped endpoint.
+		ConnectionProviderInfo: se.ConnectionProviderInfo,
+		Connect: func(ctx context.Context) (transport.StreamConn, error) {
+			conn, err := se.Connect(ctx)
+			if err != nil {
+				return nil, err
+			}
+			tlsConn, err := tls.WrapConn(ctx, conn, serverName, options...)
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return tlsConn, nil
+		},
+	}, nil
+}
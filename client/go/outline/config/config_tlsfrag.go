@@ -0,0 +1,53 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/transport/tlsfrag"
+)
+
+// TLSFragConfig is the format for the tlsfrag config. It specifies a StreamDialer that splits the
+// TLS handshake record into two records.
+type TLSFragConfig struct {
+	// Length is the length of the first record if positive, or of the second record if negative.
+	Length int
+	// Dialer is the dialer to wrap. Defaults to the direct TCP dialer.
+	Dialer ConfigNode
+}
+
+func parseTLSFragStreamDialer(ctx context.Context, configMap map[string]any, parseSD ParseFunc[*Dialer[transport.StreamConn]]) (*Dialer[transport.StreamConn], error) {
+	var config TLSFragConfig
+	if err := mapToAny(configMap, &config); err != nil {
+		return nil, fmt.Errorf("invalid config format: %w", err)
+	}
+	if config.Length == 0 {
+		return nil, errors.New("length must not be zero")
+	}
+
+	sd, err := parseSD(ctx, config.Dialer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dialer: %w", err)
+	}
+	fragDialer, err := tlsfrag.NewFixedLenStreamDialer(transport.FuncStreamDialer(sd.Dial), config.Length)
+	if err != nil {
+		return nil, err
+	}
+	return &Dialer[transport.StreamConn]{sd.ConnectionProviderInfo, fragDialer.DialStream}, nil
+}
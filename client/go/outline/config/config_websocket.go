@@ -27,7 +27,9 @@ import (
 )
 
 type WebsocketEndpointConfig struct {
-	URL      string
+	URL string
+	// Host overrides the Host header, which otherwise is the host of the URL.
+	Host     string
 	Endpoint any
 }
 
@@ -74,6 +76,9 @@ func parseWebsocketEndpoint[ConnType any](ctx context.Context, configMap map[str
 	headers := http.Header(map[string][]string{
 		"User-Agent": {fmt.Sprintf("Outline (%s; %s; %s)", runtime.GOOS, runtime.GOARCH, runtime.Version())},
 	})
+	if config.Host != "" {
+		headers.Set("Host", config.Host)
+	}
 	connect, err := newWE(url.String(), transport.FuncStreamEndpoint(se.Connect), websocket.WithHTTPHeaders(headers))
 	if err != nil {
 		return nil, err
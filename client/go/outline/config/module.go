@@ -17,7 +17,11 @@ package config
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
+	"net/netip"
+	"slices"
+	"strings"
 
 	"github.com/Jigsaw-Code/outline-sdk/transport"
 )
@@ -39,8 +43,194 @@ func (t *TransportPair) ListenPacket(ctx context.Context) (net.PacketConn, error
 	return t.PacketListener.ListenPacket(ctx)
 }
 
+// ProviderOption configures the parsers created by [NewDefaultTransportProvider].
+type ProviderOption func(*providerOptions)
+
+type providerOptions struct {
+	addressFamily AddressFamily
+	resolver      *ResolverConfig
+	streamOnly    bool
+	noResolve     bool
+	// fallbackFirstHop is the first hop to use if resolving a first hop host fails. Empty means none.
+	fallbackFirstHop string
+	// forceFallbackFirstHop uses fallbackFirstHop instead of every first hop.
+	forceFallbackFirstHop bool
+	// resolveFirstHops resolves the first hops while parsing on every platform.
+	resolveFirstHops bool
+	// hostIPOverrides maps lowercase host names to the IP to use instead of resolving them.
+	hostIPOverrides map[string]netip.Addr
+}
+
+// WithAddressFamily restricts the addresses used to reach the first hop to the given family.
+func WithAddressFamily(family AddressFamily) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.addressFamily = family
+	}
+}
+
+// WithResolver resolves the first hop host with the given resolver, instead of the system resolver.
+// The resolver is reached with the base dialers of the provider.
+func WithResolver(config ResolverConfig) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.resolver = &config
+	}
+}
+
+// WithStreamOnly skips the creation of the PacketListener, for callers that only need the
+// StreamDialer. The parsed [TransportPair] has a nil PacketListener.
+func WithStreamOnly() ProviderOption {
+	return func(opts *providerOptions) {
+		opts.streamOnly = true
+	}
+}
+
+// WithoutResolution never resolves the first hop hosts, so that parsing doesn't use the network,
+// for example to validate a config offline. The first hops are reported as written, and the host
+// is resolved when connecting.
+func WithoutResolution() ProviderOption {
+	return func(opts *providerOptions) {
+		opts.noResolve = true
+	}
+}
+
+// WithFallbackFirstHop uses the host:port address instead of a first hop whose host fails to
+// resolve, like a backup entry IP for when DNS is poisoned. The fallback is reported as the first
+// hop. The first hops are resolved while parsing then, unless [WithoutResolution] is set.
+func WithFallbackFirstHop(address string) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.fallbackFirstHop = address
+	}
+}
+
+// WithFirstHopResolution resolves the first hop hosts while parsing on every platform, not only
+// where the resolution can't be protected from the VPN, so that a host that doesn't resolve fails
+// the parse. It has no effect with [WithoutResolution].
+func WithFirstHopResolution() ProviderOption {
+	return func(opts *providerOptions) {
+		opts.resolveFirstHops = true
+	}
+}
+
+// WithForcedFallbackFirstHop uses the address of [WithFallbackFirstHop] instead of every first hop,
+// even if they resolve, for retrying through the fallback when the first hop doesn't connect.
+func WithForcedFallbackFirstHop() ProviderOption {
+	return func(opts *providerOptions) {
+		opts.forceFallbackFirstHop = true
+	}
+}
+
+// WithHostIPOverrides uses the given IP instead of resolving a first hop host, like an entry of
+// /etc/hosts, for IP pinning and reproducible tests. The host names are case-insensitive. The
+// first hops are still reported as written, and the TLS layers still send the host name, as SNI.
+// The overrides apply even with [WithoutResolution], since they don't use the network.
+func WithHostIPOverrides(overrides map[string]netip.Addr) ProviderOption {
+	return func(opts *providerOptions) {
+		opts.hostIPOverrides = make(map[string]netip.Addr, len(overrides))
+		for host, ip := range overrides {
+			opts.hostIPOverrides[strings.ToLower(host)] = ip
+		}
+	}
+}
+
 // NewDefaultTransportProvider provider a [TransportPair].
-func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer transport.PacketDialer) *TypeParser[*TransportPair] {
+func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer transport.PacketDialer, options ...ProviderOption) *TypeParser[*TransportPair] {
+	return newDefaultParsers(tcpDialer, udpDialer, options...).transports
+}
+
+// Kinds of objects created by the parsers of [NewDefaultTransportProvider], as reported in [ConfigType].
+const (
+	KindTransport      = "transport"
+	KindStreamDialer   = "streamDialer"
+	KindPacketDialer   = "packetDialer"
+	KindPacketListener = "packetListener"
+	KindStreamEndpoint = "streamEndpoint"
+	KindPacketEndpoint = "packetEndpoint"
+)
+
+// kindOf returns the kind of the object, which may be a nil pointer, or an empty string if it's
+// not created by the parsers of [NewDefaultTransportProvider].
+func kindOf(object any) string {
+	switch object.(type) {
+	case *TransportPair:
+		return KindTransport
+	case *Dialer[transport.StreamConn]:
+		return KindStreamDialer
+	case *Dialer[net.Conn]:
+		return KindPacketDialer
+	case *PacketListener:
+		return KindPacketListener
+	case *Endpoint[transport.StreamConn]:
+		return KindStreamEndpoint
+	case *Endpoint[net.Conn]:
+		return KindPacketEndpoint
+	default:
+		return ""
+	}
+}
+
+// ConfigType describes a $type value supported by [NewDefaultTransportProvider].
+type ConfigType struct {
+	// Name is the $type value.
+	Name string
+	// Kinds lists the kinds of objects the type can create, like [KindStreamDialer]. It tells where
+	// the type can be nested.
+	Kinds []string
+}
+
+// SupportedConfigTypes returns the $type values supported by [NewDefaultTransportProvider], sorted by
+// name. It's read from the registered sub-parsers, so it's always in sync with the parsers.
+func SupportedConfigTypes() []ConfigType {
+	parsers := newDefaultParsers(nil, nil)
+	kinds := make(map[string][]string)
+	for _, registry := range []struct {
+		kind  string
+		names []string
+	}{
+		{KindTransport, parsers.transports.subParserNames()},
+		{KindStreamDialer, parsers.streamDialers.subParserNames()},
+		{KindPacketDialer, parsers.packetDialers.subParserNames()},
+		{KindPacketListener, parsers.packetListeners.subParserNames()},
+		{KindStreamEndpoint, parsers.streamEndpoints.subParserNames()},
+		{KindPacketEndpoint, parsers.packetEndpoints.subParserNames()},
+	} {
+		for _, name := range registry.names {
+			kinds[name] = append(kinds[name], registry.kind)
+		}
+	}
+	types := make([]ConfigType, 0, len(kinds))
+	for name, typeKinds := range kinds {
+		types = append(types, ConfigType{Name: name, Kinds: typeKinds})
+	}
+	slices.SortFunc(types, func(a, b ConfigType) int { return strings.Compare(a.Name, b.Name) })
+	return types
+}
+
+// defaultParsers holds the parsers of [NewDefaultTransportProvider] for each kind of object.
+type defaultParsers struct {
+	transports      *TypeParser[*TransportPair]
+	streamDialers   *TypeParser[*Dialer[transport.StreamConn]]
+	packetDialers   *TypeParser[*Dialer[net.Conn]]
+	packetListeners *TypeParser[*PacketListener]
+	streamEndpoints *TypeParser[*Endpoint[transport.StreamConn]]
+	packetEndpoints *TypeParser[*Endpoint[net.Conn]]
+}
+
+func newDefaultParsers(tcpDialer transport.StreamDialer, udpDialer transport.PacketDialer, options ...ProviderOption) *defaultParsers {
+	opts := providerOptions{addressFamily: AddressFamilyAuto}
+	for _, option := range options {
+		option(&opts)
+	}
+	resolution := firstHopResolution{family: opts.addressFamily, disabled: opts.noResolve, fallback: opts.fallbackFirstHop, forceFallback: opts.forceFallbackFirstHop, required: opts.resolveFirstHops, hostIPs: opts.hostIPOverrides}
+	if opts.resolver != nil {
+		lookupIP, err := newResolverLookup(*opts.resolver, tcpDialer, udpDialer)
+		if err != nil {
+			lookupIP = func(context.Context, string) ([]netip.Addr, error) {
+				return nil, fmt.Errorf("invalid resolver: %w", err)
+			}
+		}
+		resolution.lookupIP = lookupIP
+	}
+
 	var streamEndpoints *TypeParser[*Endpoint[transport.StreamConn]]
 	var packetEndpoints *TypeParser[*Endpoint[net.Conn]]
 
@@ -48,7 +238,7 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 		switch input.(type) {
 		case nil:
 			// An absent config implicitly means TCP.
-			return &Dialer[transport.StreamConn]{ConnectionProviderInfo{ConnTypeDirect, ""}, tcpDialer.DialStream}, nil
+			return &Dialer[transport.StreamConn]{ConnectionProviderInfo{ConnType: ConnTypeDirect}, tcpDialer.DialStream}, nil
 		case string:
 			// Parse URL-style config.
 			return parseShadowsocksStreamDialer(ctx, input, streamEndpoints.Parse)
@@ -61,7 +251,7 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 		switch input.(type) {
 		case nil:
 			// An absent config implicitly means UDP.
-			return &Dialer[net.Conn]{ConnectionProviderInfo{ConnTypeDirect, ""}, udpDialer.DialPacket}, nil
+			return &Dialer[net.Conn]{ConnectionProviderInfo{ConnType: ConnTypeDirect, MaxPacketSize: maxUDPPayloadSize}, udpDialer.DialPacket}, nil
 		case string:
 			// Parse URL-style config.
 			return parseShadowsocksPacketDialer(ctx, input, packetEndpoints.Parse)
@@ -74,7 +264,7 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 		switch input.(type) {
 		case nil:
 			// An absent config implicitly means UDP.
-			return &PacketListener{ConnectionProviderInfo{ConnTypeDirect, ""}, &transport.UDPListener{}}, nil
+			return &PacketListener{ConnectionProviderInfo{ConnType: ConnTypeDirect, MaxPacketSize: maxUDPPayloadSize}, &transport.UDPListener{}}, nil
 		default:
 			return nil, errors.New("parser not specified")
 		}
@@ -82,22 +272,33 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 
 	streamEndpoints = NewTypeParser(func(ctx context.Context, input ConfigNode) (*Endpoint[transport.StreamConn], error) {
 		// TODO: perhaps only support string here to force the struct to have an explicit parser.
-		return parseDirectDialerEndpoint(ctx, input, streamDialers.Parse)
+		return parseDirectDialerEndpoint(ctx, input, streamDialers.Parse, resolution)
 	})
 	streamEndpoints.RegisterSubParser("dial", func(ctx context.Context, input map[string]any) (*Endpoint[transport.StreamConn], error) {
-		return parseDirectDialerEndpoint(ctx, input, streamDialers.Parse)
+		return parseDirectDialerEndpoint(ctx, input, streamDialers.Parse, resolution)
 	})
 
 	packetEndpoints = NewTypeParser(func(ctx context.Context, input ConfigNode) (*Endpoint[net.Conn], error) {
-		return parseDirectDialerEndpoint(ctx, input, packetDialers.Parse)
+		return parseDirectDialerEndpoint(ctx, input, packetDialers.Parse, resolution)
 	})
 	packetEndpoints.RegisterSubParser("dial", func(ctx context.Context, input map[string]any) (*Endpoint[net.Conn], error) {
-		return parseDirectDialerEndpoint(ctx, input, packetDialers.Parse)
+		return parseDirectDialerEndpoint(ctx, input, packetDialers.Parse, resolution)
 	})
 
+	// A nil packet parser skips the PacketListener.
+	parseTransportPE := packetEndpoints.Parse
+	parseTransportPL := packetListeners.Parse
+	if opts.streamOnly {
+		parseTransportPE, parseTransportPL = nil, nil
+	}
+
 	transports := NewTypeParser(func(ctx context.Context, input ConfigNode) (*TransportPair, error) {
+		// A host:port string is shorthand for an upstream that already tunnels the traffic.
+		if isEndpointAddress(input) {
+			return parseEndpointTransport(ctx, input.(string), streamEndpoints.Parse, parseTransportPE)
+		}
 		// If parser directive is missing, parse as Shadowsocks for backwards-compatibility.
-		return parseShadowsocksTransport(ctx, input, streamEndpoints.Parse, packetEndpoints.Parse)
+		return parseShadowsocksTransport(ctx, input, streamEndpoints.Parse, parseTransportPE)
 	})
 
 	// First-Supported support.
@@ -128,6 +329,45 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 		return parseShadowsocksPacketListener(ctx, input, packetEndpoints.Parse)
 	})
 
+	// SOCKS5 support.
+	streamDialers.RegisterSubParser("socks5", func(ctx context.Context, input map[string]any) (*Dialer[transport.StreamConn], error) {
+		return parseSocks5StreamDialer(ctx, input, streamEndpoints.Parse)
+	})
+	packetListeners.RegisterSubParser("socks5", func(ctx context.Context, input map[string]any) (*PacketListener, error) {
+		return parseSocks5PacketListener(ctx, input, streamEndpoints.Parse, packetDialers.Parse)
+	})
+
+	// HTTP CONNECT support.
+	streamDialers.RegisterSubParser("http-connect", func(ctx context.Context, input map[string]any) (*Dialer[transport.StreamConn], error) {
+		return parseHTTPConnectStreamDialer(ctx, input, streamEndpoints.Parse)
+	})
+
+	// Stream fragmentation support.
+	streamDialers.RegisterSubParser("split", func(ctx context.Context, input map[string]any) (*Dialer[transport.StreamConn], error) {
+		return parseSplitStreamDialer(ctx, input, streamDialers.Parse)
+	})
+	streamDialers.RegisterSubParser("tlsfrag", func(ctx context.Context, input map[string]any) (*Dialer[transport.StreamConn], error) {
+		return parseTLSFragStreamDialer(ctx, input, streamDialers.Parse)
+	})
+
+	// Chained hops support. The hops after the first tunneled one are parsed with parsers that dial
+	// through it. Their hosts are resolved by the previous hop, not locally.
+	streamDialers.RegisterSubParser("chain", func(ctx context.Context, input map[string]any) (*Dialer[transport.StreamConn], error) {
+		newParseSD := func(base transport.StreamDialer) ParseFunc[*Dialer[transport.StreamConn]] {
+			return newDefaultParsers(base, udpDialer, slices.Concat(options, []ProviderOption{WithoutResolution()})...).streamDialers.Parse
+		}
+		isPacketOnly := func(name string) bool {
+			if _, ok := streamDialers.subparsers[name]; ok {
+				return false
+			}
+			_, isPD := packetDialers.subparsers[name]
+			_, isPL := packetListeners.subparsers[name]
+			_, isPE := packetEndpoints.subparsers[name]
+			return isPD || isPL || isPE
+		}
+		return parseChainStreamDialer(ctx, input, streamDialers.Parse, newParseSD, isPacketOnly)
+	})
+
 	streamEndpoints.RegisterSubParser("websocket", func(ctx context.Context, input map[string]any) (*Endpoint[transport.StreamConn], error) {
 		return parseWebsocketStreamEndpoint(ctx, input, streamEndpoints.Parse)
 	})
@@ -135,10 +375,26 @@ func NewDefaultTransportProvider(tcpDialer transport.StreamDialer, udpDialer tra
 		return parseWebsocketPacketEndpoint(ctx, input, streamEndpoints.Parse)
 	})
 
+	streamEndpoints.RegisterSubParser("tls", func(ctx context.Context, input map[string]any) (*Endpoint[transport.StreamConn], error) {
+		return parseTLSStreamEndpoint(ctx, input, streamEndpoints.Parse)
+	})
+
+	// Support transports without UDP.
+	packetListeners.RegisterSubParser("disabled", func(ctx context.Context, input map[string]any) (*PacketListener, error) {
+		return &PacketListener{ConnectionProviderInfo{ConnType: ConnTypeDisabled}, disabledPacketListener{}}, nil
+	})
+
 	// Support distinct TCP and UDP configuration.
 	transports.RegisterSubParser("tcpudp", func(ctx context.Context, config map[string]any) (*TransportPair, error) {
-		return parseTCPUDPTransportPair(ctx, config, streamDialers.Parse, packetListeners.Parse)
+		return parseTCPUDPTransportPair(ctx, config, streamDialers.Parse, parseTransportPL)
 	})
 
-	return transports
+	return &defaultParsers{
+		transports:      transports,
+		streamDialers:   streamDialers,
+		packetDialers:   packetDialers,
+		packetListeners: packetListeners,
+		streamEndpoints: streamEndpoints,
+		packetEndpoints: packetEndpoints,
+	}
 }
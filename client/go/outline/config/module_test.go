@@ -16,11 +16,17 @@ package config
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
+	"net/netip"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/Jigsaw-Code/outline-sdk/transport"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
 )
 
 func newTestTransportProvider() *TypeParser[*TransportPair] {
@@ -86,3 +92,546 @@ func TestRegisterParseURLInQuotes(t *testing.T) {
 	require.Equal(t, "example.com:4321", d.PacketListener.FirstHop)
 	require.Equal(t, ConnTypeTunneled, d.PacketListener.ConnType)
 }
+
+func TestRegisterSocks5(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp: &shared
+  $type: socks5
+  endpoint: localhost:1080
+  username: user
+  password: pass
+udp: *shared`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+
+	require.Equal(t, "localhost:1080", d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+	require.Equal(t, "localhost:1080", d.PacketListener.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.PacketListener.ConnType)
+}
+
+func TestRegisterSocks5_UDPOverTunneledEndpoint(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: socks5
+  endpoint: localhost:1080
+udp:
+  $type: socks5
+  endpoint:
+    $type: dial
+    address: localhost:1080
+    dialer: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@entry.example.com:4321/`)
+	require.NoError(t, err)
+
+	_, err = provider.Parse(context.Background(), node)
+	require.ErrorContains(t, err, "SOCKS5 UDP requires a direct endpoint")
+}
+
+func TestRegisterWebsocketOverTLS(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint:
+    $type: websocket
+    url: ws://relay.example.com/tcp
+    host: relay.example.com
+    endpoint:
+      $type: tls
+      sni: front.example.com
+      endpoint: cdn-edge.example.com:443
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp:
+  $type: shadowsocks
+  endpoint:
+    $type: websocket
+    url: ws://relay.example.com/udp
+    host: relay.example.com
+    endpoint:
+      $type: tls
+      endpoint: cdn-edge.example.com:443
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+
+	require.Equal(t, "cdn-edge.example.com:443", d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+	require.Equal(t, "cdn-edge.example.com:443", d.PacketListener.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.PacketListener.ConnType)
+}
+
+func TestRegisterTLS_MissingSNI(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint:
+    $type: tls
+    endpoint:
+      $type: dial
+      address: cdn-edge.example.com:443
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`)
+	require.NoError(t, err)
+
+	_, err = provider.Parse(context.Background(), node)
+	require.ErrorContains(t, err, "sni must be specified")
+}
+
+func TestRegisterDisabledUDP(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+udp:
+  $type: disabled`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+	require.Equal(t, ConnTypeDisabled, d.PacketListener.ConnType)
+	require.Equal(t, "", d.PacketListener.FirstHop)
+	_, err = d.PacketListener.ListenPacket(context.Background())
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+}
+
+func TestTypeParser_UnsupportedType(t *testing.T) {
+	parser := NewTypeParser(func(ctx context.Context, input ConfigNode) (int, error) {
+		return 0, errors.New("not implemented")
+	})
+	parser.RegisterSubParser("b", func(ctx context.Context, input map[string]any) (int, error) { return 2, nil })
+	parser.RegisterSubParser("a", func(ctx context.Context, input map[string]any) (int, error) { return 1, nil })
+
+	_, err := parser.Parse(context.Background(), map[string]any{"$type": "bogus"})
+	require.ErrorIs(t, err, errors.ErrUnsupported)
+	var typeErr *UnsupportedTypeError
+	require.ErrorAs(t, err, &typeErr)
+	require.Equal(t, "bogus", typeErr.Name)
+	require.Equal(t, []string{"a", "b"}, typeErr.Supported)
+}
+
+func TestRegisterAddressFamily(t *testing.T) {
+	tcpDialer := &transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	udpDialer := &transport.UDPDialer{}
+
+	node, err := ParseConfigYAML("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@127.0.0.1:4321/")
+	require.NoError(t, err)
+
+	d, err := NewDefaultTransportProvider(tcpDialer, udpDialer, WithAddressFamily(AddressFamilyIPv4)).Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:4321", d.StreamDialer.FirstHop)
+	require.Equal(t, "127.0.0.1:4321", d.PacketListener.FirstHop)
+
+	_, err = NewDefaultTransportProvider(tcpDialer, udpDialer, WithAddressFamily(AddressFamilyIPv6)).Parse(context.Background(), node)
+	require.ErrorIs(t, err, ErrAddressFamilyUnavailable)
+
+	node, err = ParseConfigYAML("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@[::1]:4321/")
+	require.NoError(t, err)
+	d, err = NewDefaultTransportProvider(tcpDialer, udpDialer, WithAddressFamily(AddressFamilyIPv6)).Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "[::1]:4321", d.StreamDialer.FirstHop)
+}
+
+func TestRegisterAddressFamily_ZonedIPv6(t *testing.T) {
+	tcpDialer := &transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	udpDialer := &transport.UDPDialer{}
+
+	// The zone of link-local addresses must be kept, or they can't be dialed.
+	node, err := ParseConfigYAML("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@[fe80::1%25eth0]:4321/")
+	require.NoError(t, err)
+	d, err := NewDefaultTransportProvider(tcpDialer, udpDialer, WithAddressFamily(AddressFamilyIPv6)).Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "[fe80::1%eth0]:4321", d.StreamDialer.FirstHop)
+	require.Equal(t, "[fe80::1%eth0]:4321", d.PacketListener.FirstHop)
+
+	// IP literals never go to the resolver.
+	d, err = NewDefaultTransportProvider(tcpDialer, udpDialer, WithResolver(ResolverConfig{Address: "127.0.0.1:1"})).Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "[fe80::1%eth0]:4321", d.StreamDialer.FirstHop)
+}
+
+// startTestDNSServer starts a DNS server on a local UDP port that answers A queries with the given IP.
+func startTestDNSServer(t *testing.T, ip netip.Addr) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if err := request.Unpack(buf[:n]); err != nil || len(request.Questions) != 1 {
+				continue
+			}
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: request.ID, Response: true, RCode: dnsmessage.RCodeSuccess},
+				Questions: request.Questions,
+			}
+			if q := request.Questions[0]; q.Type == dnsmessage.TypeA {
+				response.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class},
+					Body:   &dnsmessage.AResource{A: ip.As4()},
+				}}
+			}
+			responseBytes, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(responseBytes, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestRegisterResolver(t *testing.T) {
+	tcpDialer := &transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	udpDialer := &transport.UDPDialer{}
+	resolverAddress := startTestDNSServer(t, netip.MustParseAddr("192.0.2.10"))
+
+	node, err := ParseConfigYAML("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@proxy.invalid:4321/")
+	require.NoError(t, err)
+
+	d, err := NewDefaultTransportProvider(tcpDialer, udpDialer, WithResolver(ResolverConfig{Address: resolverAddress})).Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "192.0.2.10:4321", d.StreamDialer.FirstHop)
+	require.Equal(t, "192.0.2.10:4321", d.PacketListener.FirstHop)
+
+	_, err = NewDefaultTransportProvider(tcpDialer, udpDialer, WithResolver(ResolverConfig{Address: resolverAddress}), WithAddressFamily(AddressFamilyIPv6)).Parse(context.Background(), node)
+	require.Error(t, err)
+}
+
+func TestResolverConfig_Validate(t *testing.T) {
+	require.NoError(t, ResolverConfig{Address: "8.8.8.8"}.Validate())
+	require.NoError(t, ResolverConfig{URL: "https://dns.google/dns-query"}.Validate())
+	require.NoError(t, ResolverConfig{URL: "https://dns.google/dns-query", Address: "8.8.8.8:443"}.Validate())
+	require.Error(t, ResolverConfig{}.Validate())
+	require.Error(t, ResolverConfig{URL: "http://dns.google/dns-query"}.Validate())
+	require.Error(t, ResolverConfig{URL: "https:///dns-query"}.Validate())
+	require.Error(t, ResolverConfig{URL: "https://dns.google:bad/dns-query"}.Validate())
+}
+
+func TestRegisterStreamOnly(t *testing.T) {
+	tcpDialer := &transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	udpDialer := &transport.UDPDialer{}
+	provider := NewDefaultTransportProvider(tcpDialer, udpDialer, WithStreamOnly())
+
+	for _, config := range []string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+		`
+$type: tcpudp
+tcp: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+udp: {$type: unsupported}`,
+	} {
+		node, err := ParseConfigYAML(config)
+		require.NoError(t, err)
+
+		d, err := provider.Parse(context.Background(), node)
+		require.NoError(t, err)
+		require.Equal(t, "example.com:4321", d.StreamDialer.FirstHop)
+		require.Nil(t, d.PacketListener)
+	}
+}
+
+func TestRegisterEndpointTransport(t *testing.T) {
+	listener, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("upstream"))
+	}()
+	provider := newTestTransportProvider()
+
+	d, err := provider.Parse(context.Background(), listener.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+	require.Equal(t, listener.Addr().String(), d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.PacketListener.ConnType)
+	require.Equal(t, listener.Addr().String(), d.PacketListener.FirstHop)
+
+	// Any destination goes to the endpoint.
+	conn, err := d.StreamDialer.Dial(context.Background(), "example.com:443")
+	require.NoError(t, err)
+	defer conn.Close()
+	response, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	require.Equal(t, "upstream", string(response))
+}
+
+func TestRegisterEndpointTransport_Invalid(t *testing.T) {
+	provider := newTestTransportProvider()
+	for _, address := range []string{"example.com", ":80", "example.com:0", "example.com:http"} {
+		_, err := provider.Parse(context.Background(), address)
+		require.Error(t, err, address)
+	}
+}
+
+func TestRegisterHTTPConnect(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint:
+    $type: dial
+    address: ss.example.com:443
+    dialer:
+      $type: http-connect
+      endpoint: proxy.example.com:3128
+      username: user
+      password: pass
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp:
+  $type: disabled`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "proxy.example.com:3128", d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+}
+
+func TestRegisterHTTPConnect_InvalidCredentials(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	for _, credentials := range []string{
+		"username: 'us:er'\npassword: pass",
+		"password: pass",
+		"username: \"user\\n\"\npassword: pass",
+	} {
+		node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: http-connect
+  endpoint: proxy.example.com:3128
+` + indent(credentials, "  ") + `
+udp:
+  $type: disabled`)
+		require.NoError(t, err)
+
+		_, err = provider.Parse(context.Background(), node)
+		require.ErrorContains(t, err, "invalid basic auth credentials", credentials)
+	}
+}
+
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// recordingStreamDialer fails every dial, after recording the address.
+type recordingStreamDialer struct {
+	addresses []string
+}
+
+func (d *recordingStreamDialer) DialStream(ctx context.Context, address string) (transport.StreamConn, error) {
+	d.addresses = append(d.addresses, address)
+	return nil, errors.New("dial disabled in test")
+}
+
+func TestRegisterChain(t *testing.T) {
+	tcpDialer := &recordingStreamDialer{}
+	provider := NewDefaultTransportProvider(tcpDialer, &transport.UDPDialer{})
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: chain
+  dialers:
+    - $type: socks5
+      endpoint: jump.example.com:1080
+    - $type: shadowsocks
+      endpoint: exit.example.com:4321
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET
+udp:
+  $type: disabled`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "jump.example.com:1080", d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+
+	// The Shadowsocks server is reached through the SOCKS5 hop, which is the only one dialed directly.
+	_, err = d.StreamDialer.Dial(context.Background(), "example.com:443")
+	require.Error(t, err)
+	require.Equal(t, []string{"jump.example.com:1080"}, tcpDialer.addresses)
+}
+
+func TestRegisterChain_Invalid(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	for _, test := range []struct {
+		dialers string
+		err     string
+	}{
+		{"[]", "empty list of dialers"},
+		{"[{$type: socks5, endpoint: jump.example.com:1080}, {$type: disabled}]", `"disabled" only supports packets and cannot be chained`},
+		{"[{$type: socks5, endpoint: jump.example.com:1080}, {$type: tls}]", "not available"},
+	} {
+		node, err := ParseConfigYAML("{$type: tcpudp, tcp: {$type: chain, dialers: " + test.dialers + "}, udp: {$type: disabled}}")
+		require.NoError(t, err)
+
+		_, err = provider.Parse(context.Background(), node)
+		require.ErrorContains(t, err, test.err, test.dialers)
+	}
+}
+
+func TestTypeParser_LayerError(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint: {$type: tls, endpoint: {$type: dial, address: example.com:443}}
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp: {$type: disabled}`)
+	require.NoError(t, err)
+
+	_, err = provider.Parse(context.Background(), node)
+	var layerErr *LayerError
+	require.ErrorAs(t, err, &layerErr)
+	require.Equal(t, []string{"tcpudp", "shadowsocks", "tls"}, layerErr.Path)
+	require.Equal(t, KindStreamEndpoint, layerErr.Kind)
+	require.ErrorContains(t, err, "sni must be specified")
+}
+
+func TestRegisterSplit(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint:
+    $type: dial
+    address: example.com:443
+    dialer:
+      $type: split
+      bytes: 2
+      count: 3
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp:
+  $type: disabled`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "example.com:443", d.StreamDialer.FirstHop)
+	require.Equal(t, ConnTypeTunneled, d.StreamDialer.ConnType)
+}
+
+func TestRegisterSplit_Invalid(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	for _, splitConfig := range []string{"bytes: 0", "bytes: 2, count: -1", "length: 2"} {
+		node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp: {$type: split, ` + splitConfig + `}
+udp: {$type: disabled}`)
+		require.NoError(t, err)
+
+		_, err = provider.Parse(context.Background(), node)
+		require.Error(t, err, splitConfig)
+	}
+}
+
+func TestRegisterTLSFrag(t *testing.T) {
+	provider := newTestTransportProvider()
+
+	node, err := ParseConfigYAML(`
+$type: tcpudp
+tcp:
+  $type: tlsfrag
+  length: -5
+  dialer:
+    $type: shadowsocks
+    endpoint: example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+udp:
+  $type: disabled`)
+	require.NoError(t, err)
+
+	d, err := provider.Parse(context.Background(), node)
+	require.NoError(t, err)
+	require.Equal(t, "example.com:443", d.StreamDialer.FirstHop)
+
+	node, err = ParseConfigYAML(`
+$type: tcpudp
+tcp: {$type: tlsfrag, length: 0}
+udp: {$type: disabled}`)
+	require.NoError(t, err)
+	_, err = provider.Parse(context.Background(), node)
+	require.ErrorContains(t, err, "length must not be zero")
+}
+
+func TestTypeParser_CanceledMidParse(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	parser := NewTypeParser(func(ctx context.Context, input ConfigNode) (any, error) {
+		return nil, errors.New("fallback not expected")
+	})
+	parser.RegisterSubParser("outer", func(ctx context.Context, input map[string]any) (any, error) {
+		// Cancel in the middle of the parse, and return a config that needs further parsing.
+		cancel()
+		return map[string]any{ConfigTypeKey: "inner"}, nil
+	})
+	innerCalled := false
+	parser.RegisterSubParser("inner", func(ctx context.Context, input map[string]any) (any, error) {
+		innerCalled = true
+		return "done", nil
+	})
+
+	_, err := parser.Parse(ctx, map[string]any{ConfigTypeKey: "outer"})
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, innerCalled)
+}
+
+func TestSupportedConfigTypes(t *testing.T) {
+	types := SupportedConfigTypes()
+	require.True(t, slices.IsSortedFunc(types, func(a, b ConfigType) int { return strings.Compare(a.Name, b.Name) }))
+
+	kinds := make(map[string][]string, len(types))
+	for _, configType := range types {
+		kinds[configType.Name] = configType.Kinds
+	}
+	require.Equal(t, []string{KindTransport}, kinds["tcpudp"])
+	require.Equal(t, []string{KindStreamDialer, KindPacketDialer, KindPacketListener}, kinds["shadowsocks"])
+	require.Equal(t, []string{KindStreamEndpoint, KindPacketEndpoint}, kinds["websocket"])
+	require.Equal(t, []string{KindStreamDialer}, kinds["split"])
+	require.Equal(t, []string{KindStreamDialer}, kinds["chain"])
+	require.Equal(t, []string{KindPacketListener}, kinds["disabled"])
+}
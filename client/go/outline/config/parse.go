@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/goccy/go-yaml"
 )
@@ -64,6 +65,44 @@ func mapToAny(in map[string]any, out any) error {
 	return nil
 }
 
+// UnsupportedTypeError is returned when a config specifies a $type that has no registered parser.
+// It matches [errors.ErrUnsupported].
+type UnsupportedTypeError struct {
+	// Name is the unsupported $type.
+	Name string
+	// Type is the Go type the parser creates.
+	Type string
+	// Supported lists the $type values available for the parser, sorted.
+	Supported []string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("parser \"%v\" for type %v is not available: %v", e.Name, e.Type, errors.ErrUnsupported)
+}
+
+func (e *UnsupportedTypeError) Unwrap() error {
+	return errors.ErrUnsupported
+}
+
+// LayerError is returned by [TypeParser.Parse] when the sub-parser of a $type fails, to tell which
+// layer of a nested config is at fault. Its message is the one of Err.
+type LayerError struct {
+	// Path lists the $type values from the outermost config to the one whose sub-parser failed.
+	Path []string
+	// Kind is the kind of object the failed sub-parser creates, like [KindStreamDialer], or empty
+	// for other parsers.
+	Kind string
+	Err  error
+}
+
+func (e *LayerError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LayerError) Unwrap() error {
+	return e.Err
+}
+
 // TypeParser creates objects of the given type T from an input config.
 // You can register type-specific sub-parsers that get called when marked in the config.
 // The default value is not valid. Use [NewTypeParser] instead.
@@ -88,6 +127,9 @@ func (p *TypeParser[T]) Parse(ctx context.Context, config ConfigNode) (T, error)
 
 	// Iterate while the input is a function call.
 	for {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
 		inMap, ok := config.(map[string]any)
 		if !ok {
 			break
@@ -102,7 +144,7 @@ func (p *TypeParser[T]) Parse(ctx context.Context, config ConfigNode) (T, error)
 		}
 		parser, ok := p.subparsers[parserName]
 		if !ok {
-			return zero, fmt.Errorf("parser \"%v\" for type %T is not available: %w", parserName, zero, errors.ErrUnsupported)
+			return zero, &UnsupportedTypeError{Name: parserName, Type: fmt.Sprintf("%T", zero), Supported: p.subParserNames()}
 		}
 
 		// $type is embedded in the value: {$type: ..., ...}.
@@ -118,7 +160,14 @@ func (p *TypeParser[T]) Parse(ctx context.Context, config ConfigNode) (T, error)
 		var err error
 		config, err = parser(ctx, inputCopy)
 		if err != nil {
-			return zero, fmt.Errorf("parser \"%v\" failed: %w", parserName, err)
+			err = fmt.Errorf("parser \"%v\" failed: %w", parserName, err)
+			// The error of a nested config already has a LayerError, which gets the enclosing $type.
+			var layerErr *LayerError
+			if errors.As(err, &layerErr) {
+				layerErr.Path = append([]string{parserName}, layerErr.Path...)
+				return zero, err
+			}
+			return zero, &LayerError{Path: []string{parserName}, Kind: kindOf(zero), Err: err}
 		}
 	}
 
@@ -131,6 +180,16 @@ func (p *TypeParser[T]) Parse(ctx context.Context, config ConfigNode) (T, error)
 	return p.fallbackHandler(ctx, config)
 }
 
+// subParserNames returns the sorted names of the registered sub-parsers.
+func (p *TypeParser[T]) subParserNames() []string {
+	names := make([]string, 0, len(p.subparsers))
+	for name := range p.subparsers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
 // RegisterSubParser registers the given subparser function with the given name for the type T.
 // Note that a subparser always take a map[string]any, not ConfigNode, since we must have a map[string]any in
 // order to set the value for the ConfigParserKey.
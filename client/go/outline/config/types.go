@@ -27,14 +27,45 @@ type ConnType int
 const (
 	ConnTypeDirect ConnType = iota
 	ConnTypeTunneled
+	// ConnTypeDisabled means that no connections are provided, for example when a transport doesn't support UDP.
+	ConnTypeDisabled
 )
 
+// maxUDPPayloadSize is the largest UDP payload that fits in a packet of the usual MTU of 1500
+// bytes, with the 40 bytes of an IPv6 header, the largest, and the 8 bytes of the UDP header.
+const maxUDPPayloadSize = 1500 - 40 - 8
+
 // ConnProviderConfig represents a dialer or endpoint that can create connections.
 type ConnectionProviderInfo struct {
 	// The type of the connections that are provided
 	ConnType ConnType
-	// The address of the first hop.
+	// The address of the first hop. It's empty if there are several, as listed in FirstHops.
 	FirstHop string
+	// The addresses of the first hops of transports that connect to several relays at once.
+	// It's nil for the transports with a single first hop.
+	FirstHops []string
+	// MaxPacketSize is the largest payload of the packets that the connections carry without
+	// fragmentation. It's 0 if unknown, or for stream connections.
+	MaxPacketSize int
+}
+
+// AllFirstHops returns the addresses of the first hops, which is FirstHops if set, or else
+// FirstHop, if not empty.
+func (info ConnectionProviderInfo) AllFirstHops() []string {
+	if len(info.FirstHops) > 0 {
+		return info.FirstHops
+	}
+	if info.FirstHop == "" {
+		return nil
+	}
+	return []string{info.FirstHop}
+}
+
+// tunneledInfo returns the info of the connections tunneled through a relay reached with the
+// given info, which keeps the first hops. The max packet size is unknown, since it depends on the
+// overhead of the relay protocol.
+func tunneledInfo(info ConnectionProviderInfo) ConnectionProviderInfo {
+	return ConnectionProviderInfo{ConnType: ConnTypeTunneled, FirstHop: info.FirstHop, FirstHops: info.FirstHops}
 }
 
 // PacketListener is a [transport.PacketListener] with embedded ConnectionProviderInfo.
@@ -0,0 +1,96 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// newConflictError returns the [platerrors.InvalidConfig] error of a config that sets the keys,
+// which exclude each other. The first key is reported as the field at fault.
+func newConflictError(reason string, keys ...string) *platerrors.PlatformError {
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("%s can't be used together: %s", strings.Join(keys, " and "), reason),
+		Details: platerrors.InvalidConfigDetails{
+			Field:  keys[0],
+			Reason: platerrors.ReasonConflict,
+			Extra:  platerrors.ErrorDetails{"conflictingKeys": keys},
+		}.ToErrorDetails(),
+	}
+}
+
+// checkTransportConflicts rejects the transport config if it combines layers that exclude each
+// other. A ss:// link with a SIP003 plugin already defines the layers under Shadowsocks, which are
+// only translated for standalone links, so it can't be mixed with explicit WebSocket layers.
+func checkTransportConflicts(transportConfigText string) *platerrors.PlatformError {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		// Reported when creating the client.
+		return nil
+	}
+	if hasPluginLink(node) && hasTypeNode(node, map[string]bool{"websocket": true}) {
+		return newConflictError("the plugin replaces the websocket layer", "plugin", "websocket")
+	}
+	return nil
+}
+
+// hasPluginLink returns whether the config has a ss:// link with a SIP003 plugin at any depth.
+func hasPluginLink(node config.ConfigNode) bool {
+	switch typed := node.(type) {
+	case string:
+		if !strings.HasPrefix(typed, "ss://") {
+			return false
+		}
+		linkURL, err := url.Parse(typed)
+		if err != nil {
+			return false
+		}
+		query, err := parseLinkQuery(linkURL.RawQuery)
+		return err == nil && query.Get("plugin") != ""
+	case map[string]any:
+		for _, value := range typed {
+			if hasPluginLink(value) {
+				return true
+			}
+		}
+	case []any:
+		for _, value := range typed {
+			if hasPluginLink(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkClientConflicts rejects the tunnel settings that need a packet path if the client has none.
+func checkClientConflicts(client *Client, opts clientOptions) *platerrors.PlatformError {
+	if client.pl == nil || client.pl.ConnType != config.ConnTypeDisabled {
+		return nil
+	}
+	if opts.tunnelDNS.IsValid() {
+		return newConflictError("the DNS queries are sent over UDP, which the transport doesn't relay", "tunnelDns", "udp")
+	}
+	if opts.probeOrder == probeOrderUDP {
+		return newConflictError("the transport doesn't relay UDP", "probeOrder", "udp")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+const conflictsTestNoUDP = `
+transport:
+  $type: tcpudp
+  tcp: ss://chacha20-ietf-poly1305:SECRET@example.com:4321
+  udp: {$type: disabled}`
+
+func Test_doParseTunnelConfig_Conflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		keys  []string
+	}{
+		{"plugin link with websocket layer", `
+transport:
+  $type: tcpudp
+  tcp: ss://chacha20-ietf-poly1305:SECRET@example.com:443/?plugin=v2ray-plugin%3Btls
+  udp:
+    $type: shadowsocks
+    endpoint: {$type: websocket, url: "wss://example.com/udp"}
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`, []string{"plugin", "websocket"}},
+		{"tunnel DNS without UDP", "tunnelDns: 1.1.1.1" + conflictsTestNoUDP, []string{"tunnelDns", "udp"}},
+		{"UDP probe order without UDP", "probeOrder: udp" + conflictsTestNoUDP, []string{"probeOrder", "udp"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := doParseTunnelConfig(tc.input)
+			require.NotNil(t, result.Error)
+			require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+			require.Contains(t, result.Error.Message, tc.keys[0]+" and "+tc.keys[1]+" can't be used together")
+			require.Equal(t, platerrors.ErrorDetails{
+				"field":           tc.keys[0],
+				"reason":          platerrors.ReasonConflict,
+				"conflictingKeys": tc.keys,
+			}, result.Error.Details)
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_NoConflicts(t *testing.T) {
+	for _, input := range []string{
+		"probeOrder: tcp" + conflictsTestNoUDP,
+		"ss://chacha20-ietf-poly1305:SECRET@example.com:443/?plugin=v2ray-plugin%3Btls",
+		"tunnelDns: 1.1.1.1\ntransport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321",
+	} {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error, "Got %v for %v", result.Error, input)
+	}
+}
@@ -15,10 +15,289 @@
 package outline
 
 import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/connectivity"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
 )
 
+const (
+	probeTCPWebsite    = "http://example.com"
+	probeDNSServerIP   = "1.1.1.1"
+	probeDNSServerPort = 53
+)
+
+// Values of the probeOrder config key.
+const (
+	probeOrderTCP = "tcp"
+	probeOrderUDP = "udp"
+)
+
+// connectivityProbeResult is the JSON result of [Client.TestConnectivity].
+type connectivityProbeResult struct {
+	// FirstProbed is the path probed first, "tcp" or "udp", if the config sets a probe order.
+	FirstProbed string `json:"firstProbed,omitempty"`
+	// FirstHops lists the first hops of the stream dialer the probes went through, and FirstHop is
+	// set if there's only one, like in [TunnelConfig]. The probes reach the probe targets, so they
+	// don't tell which of several first hops relayed the traffic.
+	FirstHop  string   `json:"firstHop,omitempty"`
+	FirstHops []string `json:"firstHops,omitempty"`
+	// FallbackFirstHopUsed is true if the first hop didn't connect, so the probes went through the
+	// fallbackFirstHop of the config, which the first hop fields report then.
+	FallbackFirstHopUsed bool                    `json:"fallbackFirstHopUsed,omitempty"`
+	TCP                  connectivityPathResult  `json:"tcp"`
+	UDP                  *connectivityPathResult `json:"udp,omitempty"`
+}
+
+// connectivityPathResult is the outcome of probing a single path (TCP or UDP).
+type connectivityPathResult struct {
+	OK bool `json:"ok"`
+	// LatencyMs is the latency of the last attempt.
+	LatencyMs int64 `json:"latencyMs"`
+	// Attempts is the number of times the path was probed, more than one if it was retried.
+	Attempts int                       `json:"attempts"`
+	Error    *platerrors.PlatformError `json:"error,omitempty"`
+}
+
+// probeRetry is how often a failed probe is retried, so that a single dropped packet doesn't fail
+// the test.
+type probeRetry struct {
+	// attempts is the maximum number of probes per path. Values below 1 mean a single probe.
+	attempts int
+	// baseDelay is the wait before the first retry, doubled after each one.
+	baseDelay time.Duration
+}
+
+// TestConnectivity probes whether the [Client] can relay traffic, without starting the VPN.
+//
+// It sends an HTTP request through a short-lived stream and, if includeUDP is set, a DNS query
+// over UDP. Both probes run in parallel and are bounded by timeoutMs; a non-positive timeoutMs
+// uses the default timeouts. All sockets are closed before returning.
+//
+// If the config sets a probeOrder, the probes run one after the other instead, starting with the
+// given path, which is reported as "firstProbed".
+//
+// The result is a JSON object with a "tcp" entry and an optional "udp" entry, each reporting
+// "ok", "latencyMs" and, on failure, an "error". The error code distinguishes a blocked TCP path
+// ([platerrors.ProxyServerUnreachable]), rejected credentials ([platerrors.Unauthenticated]) and
+// a blocked UDP path ([platerrors.ProxyServerUDPUnsupported]).
+func (c *Client) TestConnectivity(timeoutMs int, includeUDP bool) *InvokeMethodResult {
+	return c.TestConnectivityWithRetry(timeoutMs, includeUDP, 1, 0)
+}
+
+// TestConnectivityWithRetry is like [Client.TestConnectivity], but probes a failed path up to
+// attempts times, waiting baseDelayMs before the first retry and twice as long before each next
+// one. A rejection of the credentials isn't retried. The retries are bounded by timeoutMs too, and
+// the "attempts" of each path report how many probes were made.
+//
+// The "firstHops" of the result list the first hops of the stream dialer the probes went through,
+// and "firstHop" is set if there's only one. If the TCP path doesn't connect and the config sets a
+// fallbackFirstHop, the probes are run again through the fallback, and "fallbackFirstHopUsed" tells
+// whether they succeeded that way.
+func (c *Client) TestConnectivityWithRetry(timeoutMs int, includeUDP bool, attempts int, baseDelayMs int) *InvokeMethodResult {
+	ctx, cancel := newProbeContext(timeoutMs)
+	defer cancel()
+	retry := probeRetry{attempts: attempts, baseDelay: time.Duration(baseDelayMs) * time.Millisecond}
+	result := c.probeConnectivity(ctx, includeUDP, retry)
+	result.setFirstHops(c)
+	if result.TCP.OK || result.TCP.Error.Code != platerrors.ProxyServerUnreachable || ctx.Err() != nil {
+		return marshalInvokeMethodResult(result)
+	}
+	if fallbackClient := c.newFallbackFirstHopClient(ctx); fallbackClient != nil {
+		fallbackResult := fallbackClient.probeConnectivity(ctx, includeUDP, retry)
+		if fallbackResult.TCP.OK {
+			fallbackResult.setFirstHops(fallbackClient)
+			fallbackResult.FallbackFirstHopUsed = true
+			result = fallbackResult
+		}
+	}
+	return marshalInvokeMethodResult(result)
+}
+
+// setFirstHops sets the first hop fields of the result from the client the probes went through.
+func (r *connectivityProbeResult) setFirstHops(client *Client) {
+	r.FirstHops = client.sd.AllFirstHops()
+	r.FirstHop = singleFirstHop(r.FirstHops)
+}
+
+// newFallbackFirstHopClient returns a client like c that dials the fallbackFirstHop of the config
+// instead of the first hops, or nil if the config has none, c already uses it, or the client
+// fails.
+func (c *Client) newFallbackFirstHopClient(ctx context.Context) *Client {
+	if c.opts.probeFallbackFirstHop == "" || c.opts.fallbackFirstHop != "" {
+		return nil
+	}
+	logger := loggerFromContext(ctx)
+	logger.DebugContext(ctx, "first hop didn't connect, probing the fallback", "fallbackFirstHop", c.opts.probeFallbackFirstHop)
+	opts := c.opts
+	opts.fallbackFirstHop, opts.forceFallbackFirstHop = opts.probeFallbackFirstHop, true
+	result := newCachedClient(ctx, c.transportConfig, opts)
+	if result.Error != nil {
+		logger.DebugContext(ctx, "failed to create the client of the fallback first hop", "err", result.Error)
+		return nil
+	}
+	return result.Client
+}
+
+// Outcomes of [Client.TestUDPConnectivity].
+const (
+	// UDPProbeOK means that both TCP and UDP are relayed.
+	UDPProbeOK = "ok"
+	// UDPProbeUDPBlocked means that TCP is relayed but UDP isn't, as when the network blocks UDP to
+	// the first hop. Tunneling UDP over TCP may help.
+	UDPProbeUDPBlocked = "udp-blocked"
+	// UDPProbeTCPBlocked means that UDP is relayed but TCP isn't.
+	UDPProbeTCPBlocked = "tcp-blocked"
+	// UDPProbeAllBlocked means that neither TCP nor UDP are relayed.
+	UDPProbeAllBlocked = "all-blocked"
+	// UDPProbeUDPDisabled means that the transport doesn't relay UDP, so there's nothing to probe.
+	UDPProbeUDPDisabled = "udp-disabled"
+)
+
+// udpConnectivityResult is the JSON result of [Client.TestUDPConnectivity].
+type udpConnectivityResult struct {
+	Outcome string `json:"outcome"`
+	// RecommendUDPOverTCP is set for [UDPProbeUDPBlocked].
+	RecommendUDPOverTCP bool `json:"recommendUdpOverTcp"`
+	// PacketFirstHop is the first hop of the packet listener, as reported by the transport.
+	PacketFirstHop string                  `json:"packetFirstHop,omitempty"`
+	FirstProbed    string                  `json:"firstProbed,omitempty"`
+	TCP            connectivityPathResult  `json:"tcp"`
+	UDP            *connectivityPathResult `json:"udp,omitempty"`
+}
+
+// TestUDPConnectivity probes the UDP path through the packet listener independently of the TCP
+// path, to tell a network that blocks UDP to the first hop from a proxy that is unreachable.
+//
+// The probes and timeoutMs are the same as in [Client.TestConnectivity]. The result is a JSON object
+// with an "outcome", one of the UDPProbe constants, the "tcp" and "udp" entries of
+// [Client.TestConnectivity], and "recommendUdpOverTcp" if UDP should be tunneled over TCP.
+func (c *Client) TestUDPConnectivity(timeoutMs int) *InvokeMethodResult {
+	ctx, cancel := newProbeContext(timeoutMs)
+	defer cancel()
+
+	udpEnabled := c.pl != nil && c.pl.ConnType != config.ConnTypeDisabled
+	probe := c.probeConnectivity(ctx, udpEnabled, probeRetry{})
+	result := udpConnectivityResult{FirstProbed: probe.FirstProbed, TCP: probe.TCP, UDP: probe.UDP}
+	if c.pl != nil {
+		result.PacketFirstHop = c.pl.FirstHop
+	}
+	switch {
+	case !udpEnabled:
+		result.Outcome = UDPProbeUDPDisabled
+	case probe.TCP.OK && probe.UDP.OK:
+		result.Outcome = UDPProbeOK
+	case probe.TCP.OK:
+		result.Outcome = UDPProbeUDPBlocked
+		result.RecommendUDPOverTCP = true
+	case probe.UDP.OK:
+		result.Outcome = UDPProbeTCPBlocked
+	default:
+		result.Outcome = UDPProbeAllBlocked
+	}
+	return marshalInvokeMethodResult(result)
+}
+
+// newProbeContext returns the context of a connectivity probe bounded by timeoutMs. A non-positive
+// timeoutMs leaves the bounds to the default timeouts of the probes.
+func newProbeContext(timeoutMs int) (context.Context, context.CancelFunc) {
+	if timeoutMs > 0 {
+		return context.WithTimeout(context.Background(), time.Duration(timeoutMs)*time.Millisecond)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// probeConnectivity runs the TCP probe and, if includeUDP is set, the UDP probe, in parallel or in
+// the probe order of the client, retrying each path as set by retry.
+func (c *Client) probeConnectivity(ctx context.Context, includeUDP bool, retry probeRetry) connectivityProbeResult {
+	probeTCP := func() connectivityPathResult {
+		return probePathWithRetry(ctx, retry, func(ctx context.Context) error {
+			return connectivity.CheckTCPConnectivityWithHTTPContext(ctx, c, probeTCPWebsite)
+		})
+	}
+	probeUDP := func() connectivityPathResult {
+		resolverAddr := &net.UDPAddr{IP: net.ParseIP(probeDNSServerIP), Port: probeDNSServerPort}
+		return probePathWithRetry(ctx, retry, func(ctx context.Context) error {
+			return connectivity.CheckUDPConnectivityWithDNSContext(ctx, c, resolverAddr)
+		})
+	}
+
+	if !includeUDP {
+		return connectivityProbeResult{TCP: probeTCP()}
+	}
+	var result connectivityProbeResult
+	var udp connectivityPathResult
+	switch c.opts.probeOrder {
+	case probeOrderTCP:
+		result.TCP = probeTCP()
+		udp = probeUDP()
+	case probeOrderUDP:
+		udp = probeUDP()
+		result.TCP = probeTCP()
+	default:
+		udpResult := make(chan connectivityPathResult, 1)
+		go func() { udpResult <- probeUDP() }()
+		result.TCP = probeTCP()
+		udp = <-udpResult
+	}
+	result.FirstProbed = c.opts.probeOrder
+	result.UDP = &udp
+	return result
+}
+
+// probePath runs check, measures its latency and maps its error to a [platerrors.PlatformError].
+func probePath(check func() error) connectivityPathResult {
+	start := time.Now()
+	err := platerrors.ToPlatformError(check())
+	result := connectivityPathResult{LatencyMs: time.Since(start).Milliseconds(), Attempts: 1}
+	if err == nil {
+		result.OK = true
+		return result
+	}
+	if err.Code == platerrors.ProxyServerReadFailed && err.Details[connectivity.ClosedByServerDetail] == true {
+		// The proxy accepted the stream but closed it without a response, which is how servers
+		// typically react to invalid credentials. Other read failures, like timeouts, are kept, since
+		// a slow or lossy network causes them too.
+		err = &platerrors.PlatformError{
+			Code:    platerrors.Unauthenticated,
+			Message: "proxy server rejected the request, the credentials may be invalid",
+			Cause:   err,
+		}
+	}
+	result.Error = err
+	return result
+}
+
+// probePathWithRetry is like [probePath], but runs check again after a failure, up to the attempts
+// of retry, with an exponential backoff. It stops early if ctx is done or the credentials were
+// rejected, since retrying can't help then. If ctx has a deadline, each attempt gets an equal share
+// of the time left, so that an attempt that times out leaves time for the next ones.
+func probePathWithRetry(ctx context.Context, retry probeRetry, check func(ctx context.Context) error) connectivityPathResult {
+	delay := retry.baseDelay
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && attempt < retry.attempts {
+			share := time.Until(deadline) / time.Duration(retry.attempts-attempt+1)
+			attemptCtx, cancel = context.WithTimeout(ctx, share)
+		}
+		result := probePath(func() error { return check(attemptCtx) })
+		cancel()
+		result.Attempts = attempt
+		if result.OK || attempt >= retry.attempts || result.Error.Code == platerrors.Unauthenticated {
+			return result
+		}
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
 // TCPAndUDPConnectivityResult represents the result of TCP and UDP connectivity checks.
 //
 // We use a struct instead of a tuple to preserve a strongly typed error that gobind recognizes.
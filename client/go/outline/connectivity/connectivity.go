@@ -16,8 +16,11 @@ package connectivity
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
 	"net/http"
+	"syscall"
 	"time"
 
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
@@ -32,6 +35,11 @@ const (
 	bufferLength        = 512
 )
 
+// ClosedByServerDetail is set to true in the Details of the [platerrors.ProxyServerReadFailed]
+// errors of [CheckTCPConnectivityWithHTTPContext] if the server closed or reset the stream after
+// the request was written, rather than the read timing out or failing otherwise.
+const ClosedByServerDetail = "closedByServer"
+
 const (
 	testTCPWebsite    = "http://example.com"
 	testDNSServerIP   = "1.1.1.1"
@@ -61,7 +69,13 @@ func CheckTCPAndUDPConnectivity(
 // the network support UDP traffic by issuing a DNS query though a resolver at `resolverAddr`.
 // Returns nil on success or an error on failure.
 func CheckUDPConnectivityWithDNS(client transport.PacketListener, resolverAddr net.Addr) error {
-	conn, err := client.ListenPacket(context.Background())
+	return CheckUDPConnectivityWithDNSContext(context.Background(), client, resolverAddr)
+}
+
+// CheckUDPConnectivityWithDNSContext is like [CheckUDPConnectivityWithDNS], but gives up when
+// ctx is done, even if there are retry attempts left.
+func CheckUDPConnectivityWithDNSContext(ctx context.Context, client transport.PacketListener, resolverAddr net.Addr) error {
+	conn, err := client.ListenPacket(ctx)
 	if err != nil {
 		return platerrors.PlatformError{
 			Code:    platerrors.ProxyServerUDPUnsupported,
@@ -72,8 +86,12 @@ func CheckUDPConnectivityWithDNS(client transport.PacketListener, resolverAddr n
 	defer conn.Close()
 
 	buf := make([]byte, bufferLength)
-	for attempt := 0; attempt < udpMaxRetryAttempts; attempt++ {
-		conn.SetDeadline(time.Now().Add(udpTimeout))
+	for attempt := 0; attempt < udpMaxRetryAttempts && ctx.Err() == nil; attempt++ {
+		deadline := time.Now().Add(udpTimeout)
+		if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+			deadline = ctxDeadline
+		}
+		conn.SetDeadline(deadline)
 		_, err := conn.WriteTo(getDNSRequest(), resolverAddr)
 		if err != nil {
 			continue
@@ -100,9 +118,21 @@ func CheckUDPConnectivityWithDNS(client transport.PacketListener, resolverAddr n
 //
 // Returns nil on success, error on connectivity failure.
 func CheckTCPConnectivityWithHTTP(dialer transport.StreamDialer, targetURL string) error {
-	deadline := time.Now().Add(tcpTimeout)
-	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	ctx, cancel := context.WithTimeout(context.Background(), tcpTimeout)
 	defer cancel()
+	return CheckTCPConnectivityWithHTTPContext(ctx, dialer, targetURL)
+}
+
+// CheckTCPConnectivityWithHTTPContext is like [CheckTCPConnectivityWithHTTP], but uses the
+// deadline of ctx instead of the default timeout, if it has one.
+func CheckTCPConnectivityWithHTTPContext(ctx context.Context, dialer transport.StreamDialer, targetURL string) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(tcpTimeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
 	req, err := http.NewRequest("HEAD", targetURL, nil)
 	if err != nil {
 		return err
@@ -131,15 +161,28 @@ func CheckTCPConnectivityWithHTTP(dialer transport.StreamDialer, targetURL strin
 	}
 	n, err := conn.Read(make([]byte, bufferLength))
 	if n == 0 && err != nil {
-		return platerrors.PlatformError{
+		perr := platerrors.PlatformError{
 			Code:    platerrors.ProxyServerReadFailed,
 			Message: "failed to read HTTP HEAD response from the server",
 			Cause:   platerrors.ToPlatformError(err),
 		}
+		if isClosedByServer(err) {
+			perr.Details = platerrors.ErrorDetails{ClosedByServerDetail: true}
+		}
+		return perr
 	}
 	return nil
 }
 
+// isClosedByServer tells whether the read error means that the server closed or reset the stream.
+func isClosedByServer(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
 func getDNSRequest() []byte {
 	return []byte{
 		0, 0, // [0-1]   query ID
@@ -17,7 +17,10 @@ package connectivity
 import (
 	"context"
 	"errors"
+	"io"
 	"net"
+	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -144,3 +147,50 @@ func (c *fakeDuplexConn) SetWriteDeadline(t time.Time) error { return nil }
 func (c *fakeDuplexConn) CloseRead() error { return nil }
 
 func (c *fakeDuplexConn) CloseWrite() error { return nil }
+
+func TestCheckUDPConnectivityWithDNSContext_Canceled(t *testing.T) {
+	client := &fakeSSClient{failUDP: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	err := CheckUDPConnectivityWithDNSContext(ctx, client, &net.UDPAddr{})
+	require.Less(t, time.Since(start), udpTimeout)
+	require.Error(t, err)
+}
+
+func TestCheckTCPConnectivityWithHTTP_ReadFailure(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		err            error
+		closedByServer bool
+	}{
+		{"eof", io.EOF, true},
+		{"reset", &net.OpError{Op: "read", Err: syscall.ECONNRESET}, true},
+		{"timeout", os.ErrDeadlineExceeded, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckTCPConnectivityWithHTTP(&readErrorDialer{tc.err}, "http://example.com")
+			perr := platerrors.ToPlatformError(err)
+			require.Equal(t, platerrors.ProxyServerReadFailed, perr.Code)
+			require.Equal(t, tc.closedByServer, perr.Details[ClosedByServerDetail] == true)
+		})
+	}
+}
+
+// readErrorDialer creates streams whose reads fail with err.
+type readErrorDialer struct {
+	err error
+}
+
+func (d *readErrorDialer) DialStream(context.Context, string) (transport.StreamConn, error) {
+	return &readErrorConn{fakeDuplexConn{}, d.err}, nil
+}
+
+type readErrorConn struct {
+	fakeDuplexConn
+	err error
+}
+
+func (c *readErrorConn) Read([]byte) (int, error) {
+	return 0, c.err
+}
@@ -0,0 +1,334 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/connectivity"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPacketListener struct{}
+
+func (failingPacketListener) ListenPacket(context.Context) (net.PacketConn, error) {
+	return nil, errors.New("UDP blocked")
+}
+
+func TestTestConnectivity_Unreachable(t *testing.T) {
+	client := &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			Dial: func(context.Context, string) (transport.StreamConn, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("TCP blocked")}
+			},
+		},
+		pl: &config.PacketListener{PacketListener: failingPacketListener{}},
+	}
+
+	result := client.TestConnectivity(1000, true)
+	require.Nil(t, result.Error)
+
+	var probe connectivityProbeResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	require.False(t, probe.TCP.OK)
+	require.Equal(t, platerrors.ProxyServerUnreachable, probe.TCP.Error.Code)
+	require.NotNil(t, probe.UDP)
+	require.False(t, probe.UDP.OK)
+	require.Equal(t, platerrors.ProxyServerUDPUnsupported, probe.UDP.Error.Code)
+}
+
+func TestTestConnectivity_WithoutUDP(t *testing.T) {
+	client := &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			Dial: func(context.Context, string) (transport.StreamConn, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("TCP blocked")}
+			},
+		},
+	}
+
+	result := client.TestConnectivity(0, false)
+	require.Nil(t, result.Error)
+	require.NotContains(t, result.Value, `"udp"`)
+}
+
+func TestTestConnectivity_MultipleFirstHops(t *testing.T) {
+	sd := newHTTPStubDialer(t)
+	sd.FirstHop = ""
+	sd.FirstHops = []string{"a.example.com:443", "b.example.com:443"}
+	result := (&Client{sd: sd}).TestConnectivity(1000, false)
+	require.Nil(t, result.Error)
+
+	var probe connectivityProbeResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	require.True(t, probe.TCP.OK)
+	require.Equal(t, "", probe.FirstHop)
+	require.Equal(t, []string{"a.example.com:443", "b.example.com:443"}, probe.FirstHops)
+}
+
+func TestProbePath(t *testing.T) {
+	ok := probePath(func() error { return nil })
+	require.True(t, ok.OK)
+	require.Nil(t, ok.Error)
+
+	auth := probePath(func() error {
+		return platerrors.PlatformError{
+			Code:    platerrors.ProxyServerReadFailed,
+			Message: "read failed",
+			Details: platerrors.ErrorDetails{connectivity.ClosedByServerDetail: true},
+		}
+	})
+	require.False(t, auth.OK)
+	require.Equal(t, platerrors.Unauthenticated, auth.Error.Code)
+	require.Equal(t, platerrors.ProxyServerReadFailed, auth.Error.Cause.Code)
+
+	timeout := probePath(func() error {
+		return platerrors.PlatformError{Code: platerrors.ProxyServerReadFailed, Message: "read timed out"}
+	})
+	require.False(t, timeout.OK)
+	require.Equal(t, platerrors.ProxyServerReadFailed, timeout.Error.Code)
+}
+
+// newHTTPStubDialer returns a stream dialer that connects to a local server answering any request.
+func newHTTPStubDialer(t *testing.T) *config.Dialer[transport.StreamConn] {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.Read(make([]byte, 512))
+				conn.Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+			}()
+		}
+	}()
+	return &config.Dialer[transport.StreamConn]{
+		ConnectionProviderInfo: config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHop: listener.Addr().String()},
+		Dial: func(ctx context.Context, _ string) (transport.StreamConn, error) {
+			return (&transport.TCPDialer{}).DialStream(ctx, listener.Addr().String())
+		},
+	}
+}
+
+// echoPacketListener creates packet conns that answer each packet with a packet from its destination.
+type echoPacketListener struct{}
+
+func (echoPacketListener) ListenPacket(context.Context) (net.PacketConn, error) {
+	return &echoPacketConn{packets: make(chan net.Addr, 1)}, nil
+}
+
+type echoPacketConn struct {
+	net.PacketConn
+	packets chan net.Addr
+}
+
+func (c *echoPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.packets <- addr
+	return len(b), nil
+}
+
+func (c *echoPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	addr := <-c.packets
+	return copy(b, "ok"), addr, nil
+}
+
+func (c *echoPacketConn) SetDeadline(time.Time) error { return nil }
+
+func (c *echoPacketConn) Close() error { return nil }
+
+func testUDPConnectivity(t *testing.T, client *Client) udpConnectivityResult {
+	result := client.TestUDPConnectivity(1000)
+	require.Nil(t, result.Error)
+	var probe udpConnectivityResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	return probe
+}
+
+func TestTestUDPConnectivity_OK(t *testing.T) {
+	probe := testUDPConnectivity(t, &Client{
+		sd: newHTTPStubDialer(t),
+		pl: &config.PacketListener{
+			ConnectionProviderInfo: config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHop: "192.0.2.1:4321"},
+			PacketListener:         echoPacketListener{},
+		},
+	})
+	require.Equal(t, UDPProbeOK, probe.Outcome)
+	require.False(t, probe.RecommendUDPOverTCP)
+	require.Equal(t, "192.0.2.1:4321", probe.PacketFirstHop)
+	require.True(t, probe.TCP.OK)
+	require.True(t, probe.UDP.OK)
+}
+
+func TestTestUDPConnectivity_UDPBlocked(t *testing.T) {
+	probe := testUDPConnectivity(t, &Client{
+		sd: newHTTPStubDialer(t),
+		pl: &config.PacketListener{PacketListener: failingPacketListener{}},
+	})
+	require.Equal(t, UDPProbeUDPBlocked, probe.Outcome)
+	require.True(t, probe.RecommendUDPOverTCP)
+	require.True(t, probe.TCP.OK)
+	require.False(t, probe.UDP.OK)
+	require.Equal(t, platerrors.ProxyServerUDPUnsupported, probe.UDP.Error.Code)
+}
+
+func TestTestUDPConnectivity_TCPBlocked(t *testing.T) {
+	probe := testUDPConnectivity(t, &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			Dial: func(context.Context, string) (transport.StreamConn, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("TCP blocked")}
+			},
+		},
+		pl: &config.PacketListener{PacketListener: echoPacketListener{}},
+	})
+	require.Equal(t, UDPProbeTCPBlocked, probe.Outcome)
+	require.False(t, probe.RecommendUDPOverTCP)
+	require.Equal(t, platerrors.ProxyServerUnreachable, probe.TCP.Error.Code)
+	require.True(t, probe.UDP.OK)
+}
+
+func TestTestUDPConnectivity_AllBlocked(t *testing.T) {
+	probe := testUDPConnectivity(t, &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			Dial: func(context.Context, string) (transport.StreamConn, error) {
+				return nil, &net.OpError{Op: "dial", Err: errors.New("TCP blocked")}
+			},
+		},
+		pl: &config.PacketListener{PacketListener: failingPacketListener{}},
+	})
+	require.Equal(t, UDPProbeAllBlocked, probe.Outcome)
+	require.False(t, probe.RecommendUDPOverTCP)
+}
+
+func TestTestUDPConnectivity_UDPDisabled(t *testing.T) {
+	probe := testUDPConnectivity(t, &Client{
+		sd: newHTTPStubDialer(t),
+		pl: &config.PacketListener{ConnectionProviderInfo: config.ConnectionProviderInfo{ConnType: config.ConnTypeDisabled}},
+	})
+	require.Equal(t, UDPProbeUDPDisabled, probe.Outcome)
+	require.Nil(t, probe.UDP)
+	require.True(t, probe.TCP.OK)
+}
+
+// recordingPacketListener records "udp" when a packet conn is created, to check the probe order.
+type recordingPacketListener struct {
+	transport.PacketListener
+	probed chan<- string
+}
+
+func (l recordingPacketListener) ListenPacket(ctx context.Context) (net.PacketConn, error) {
+	l.probed <- "udp"
+	return l.PacketListener.ListenPacket(ctx)
+}
+
+func TestTestConnectivity_ProbeOrder(t *testing.T) {
+	for _, probeOrder := range []string{probeOrderTCP, probeOrderUDP} {
+		t.Run(probeOrder, func(t *testing.T) {
+			probed := make(chan string, 2)
+			sd := newHTTPStubDialer(t)
+			dial := sd.Dial
+			sd.Dial = func(ctx context.Context, address string) (transport.StreamConn, error) {
+				probed <- "tcp"
+				return dial(ctx, address)
+			}
+			client := &Client{
+				sd:   sd,
+				pl:   &config.PacketListener{PacketListener: recordingPacketListener{echoPacketListener{}, probed}},
+				opts: clientOptions{probeOrder: probeOrder},
+			}
+
+			result := client.TestConnectivity(1000, true)
+			require.Nil(t, result.Error)
+			var probe connectivityProbeResult
+			require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+			require.Equal(t, probeOrder, probe.FirstProbed)
+			require.True(t, probe.TCP.OK)
+			require.True(t, probe.UDP.OK)
+			require.Equal(t, probeOrder, <-probed)
+		})
+	}
+}
+
+func TestTestConnectivityWithRetry_Flaky(t *testing.T) {
+	// The stub fails the first two dials, like dropped SYNs, then connects.
+	sd := newHTTPStubDialer(t)
+	dial := sd.Dial
+	dials := 0
+	sd.Dial = func(ctx context.Context, address string) (transport.StreamConn, error) {
+		if dials++; dials <= 2 {
+			return nil, &net.OpError{Op: "dial", Err: errors.New("SYN dropped")}
+		}
+		return dial(ctx, address)
+	}
+	client := &Client{sd: sd}
+
+	result := client.TestConnectivityWithRetry(1000, false, 3, 1)
+	require.Nil(t, result.Error)
+	var probe connectivityProbeResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	require.True(t, probe.TCP.OK)
+	require.Equal(t, 3, probe.TCP.Attempts)
+
+	// Without retries, the first failure is the result.
+	dials = 0
+	result = client.TestConnectivity(1000, false)
+	require.Nil(t, result.Error)
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	require.False(t, probe.TCP.OK)
+	require.Equal(t, 1, probe.TCP.Attempts)
+}
+
+// timeoutStreamConn is a stream whose reads time out, like one to a server that drops the packets.
+type timeoutStreamConn struct {
+	transport.StreamConn
+}
+
+func (timeoutStreamConn) Write(b []byte) (int, error) { return len(b), nil }
+func (timeoutStreamConn) Read([]byte) (int, error)    { return 0, os.ErrDeadlineExceeded }
+func (timeoutStreamConn) SetDeadline(time.Time) error { return nil }
+func (timeoutStreamConn) Close() error                { return nil }
+
+func TestTestConnectivityWithRetry_Timeouts(t *testing.T) {
+	// The stub times out the first two probes, then answers.
+	sd := newHTTPStubDialer(t)
+	dial := sd.Dial
+	dials := 0
+	sd.Dial = func(ctx context.Context, address string) (transport.StreamConn, error) {
+		if dials++; dials <= 2 {
+			return timeoutStreamConn{}, nil
+		}
+		return dial(ctx, address)
+	}
+	client := &Client{sd: sd}
+
+	result := client.TestConnectivityWithRetry(3000, false, 3, 1)
+	require.Nil(t, result.Error)
+	var probe connectivityProbeResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &probe))
+	require.True(t, probe.TCP.OK, "Got %v", probe.TCP.Error)
+	require.Equal(t, 3, probe.TCP.Attempts)
+}
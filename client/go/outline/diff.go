@@ -0,0 +1,216 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// Values of tunnelConfigDiffJson.Secrets.
+const (
+	SecretChanged   = "changed"
+	SecretUnchanged = "unchanged"
+)
+
+// tunnelConfigDiffJson is the result of [DiffTunnelConfigs].
+type tunnelConfigDiffJson struct {
+	// Changed is true if the transports differ.
+	Changed bool `json:"changed"`
+	// Changes lists the fields that differ, in path order.
+	Changes []tunnelConfigChangeJson `json:"changes"`
+	// Secrets maps the path of every credential to SecretChanged or SecretUnchanged.
+	Secrets map[string]string `json:"secrets,omitempty"`
+}
+
+// tunnelConfigChangeJson is a field that differs between two transports.
+type tunnelConfigChangeJson struct {
+	// Path is the location of the field, as in "transport.tcp.endpoint" or "transport[1].secret".
+	Path string `json:"path"`
+	// Old and New are the values, redacted. Old is absent for added fields, and New for removed ones.
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+	// Secret is true for credentials, whose values are never included.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// DiffTunnelConfigs compares the transports of two tunnel configs, like the versions of a config
+// before and after a provider update. The result Value is a JSON object with the fields that
+// changed. Credentials are only reported as changed or unchanged, never with their values.
+//
+// Both configs are parsed without network access, and the transports are compared after
+// normalization, so a ss:// link and the equivalent YAML have no differences.
+func DiffTunnelConfigs(oldInput string, newInput string) *InvokeMethodResult {
+	oldNode, perr := parseTransportForDiff(oldInput, "old")
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	newNode, perr := parseTransportForDiff(newInput, "new")
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	diff := tunnelConfigDiffJson{Changes: []tunnelConfigChangeJson{}, Secrets: map[string]string{}}
+	diffConfigNodes(&diff, "transport", oldNode, newNode)
+	diff.Changed = len(diff.Changes) > 0
+	return marshalInvokeMethodResult(diff)
+}
+
+// parseTransportForDiff parses the tunnel config and returns its normalized transport, with the
+// Shadowsocks configs in any format expanded to mappings. which names the config in errors.
+func parseTransportForDiff(input string, which string) (config.ConfigNode, *platerrors.PlatformError) {
+	tunnelConfig, perr := parseTunnelConfig(context.Background(), input, ParseOptions{NoResolve: true})
+	if perr != nil {
+		return nil, &platerrors.PlatformError{
+			Code:    perr.Code,
+			Message: fmt.Sprintf("invalid %s config: %s", which, perr.Message),
+			Details: perr.Details,
+			Cause:   perr,
+		}
+	}
+	node, err := config.ParseConfigYAML(tunnelConfig.Transport)
+	if err != nil {
+		return nil, newYAMLParseError(err)
+	}
+	return expandShadowsocksConfigs(node), nil
+}
+
+// expandShadowsocksConfigs replaces the ss:// links and the Shadowsocks configs without $type,
+// including the legacy JSON format, with the equivalent Shadowsocks mapping.
+func expandShadowsocksConfigs(node config.ConfigNode) config.ConfigNode {
+	switch typed := node.(type) {
+	case string:
+		if !strings.HasPrefix(typed, "ss://") {
+			return typed
+		}
+		if ssConfig, err := config.ParseShadowsocksConfig(typed); err == nil {
+			return shadowsocksConfigNode(ssConfig)
+		}
+		return typed
+	case map[string]any:
+		if _, hasType := typed["$type"]; !hasType {
+			if ssConfig, err := config.ParseShadowsocksConfig(typed); err == nil {
+				return shadowsocksConfigNode(ssConfig)
+			}
+		}
+		expanded := make(map[string]any, len(typed))
+		for key, value := range typed {
+			expanded[key] = expandShadowsocksConfigs(value)
+		}
+		return expanded
+	case []any:
+		expanded := make([]any, 0, len(typed))
+		for _, value := range typed {
+			expanded = append(expanded, expandShadowsocksConfigs(value))
+		}
+		return expanded
+	default:
+		return node
+	}
+}
+
+func shadowsocksConfigNode(ssConfig *config.ShadowsocksConfig) map[string]any {
+	node := map[string]any{
+		"$type":    "shadowsocks",
+		"endpoint": expandShadowsocksConfigs(ssConfig.Endpoint),
+		"cipher":   ssConfig.Cipher,
+		"secret":   ssConfig.Secret,
+	}
+	if ssConfig.Prefix != "" {
+		node["prefix"] = ssConfig.Prefix
+	}
+	if ssConfig.PrefixPreset != "" {
+		node["prefixPreset"] = ssConfig.PrefixPreset
+	}
+	return node
+}
+
+// diffConfigNodes appends the differences between oldNode and newNode at path to diff.
+func diffConfigNodes(diff *tunnelConfigDiffJson, path string, oldNode config.ConfigNode, newNode config.ConfigNode) {
+	if isSecretPath(path) {
+		if reflect.DeepEqual(oldNode, newNode) {
+			diff.Secrets[path] = SecretUnchanged
+			return
+		}
+		diff.Secrets[path] = SecretChanged
+		diff.Changes = append(diff.Changes, tunnelConfigChangeJson{Path: path, Secret: true})
+		return
+	}
+	oldMap, oldIsMap := oldNode.(map[string]any)
+	newMap, newIsMap := newNode.(map[string]any)
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0, len(oldMap)+len(newMap))
+		for key := range oldMap {
+			keys = append(keys, key)
+		}
+		for key := range newMap {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range slices.Compact(keys) {
+			diffConfigNodes(diff, path+"."+key, oldMap[key], newMap[key])
+		}
+		return
+	}
+	oldList, oldIsList := oldNode.([]any)
+	newList, newIsList := newNode.([]any)
+	if oldIsList && newIsList {
+		for i := 0; i < max(len(oldList), len(newList)); i++ {
+			var oldValue, newValue any
+			if i < len(oldList) {
+				oldValue = oldList[i]
+			}
+			if i < len(newList) {
+				newValue = newList[i]
+			}
+			diffConfigNodes(diff, path+"["+strconv.Itoa(i)+"]", oldValue, newValue)
+		}
+		return
+	}
+	if reflect.DeepEqual(oldNode, newNode) {
+		return
+	}
+	change := tunnelConfigChangeJson{Path: path}
+	// Added and removed subtrees may contain secrets, so they're redacted like in [RedactConfig].
+	if oldNode != nil {
+		change.Old = redactChangeValue(oldNode)
+	}
+	if newNode != nil {
+		change.New = redactChangeValue(newNode)
+	}
+	diff.Changes = append(diff.Changes, change)
+}
+
+// redactChangeValue returns the node redacted with [redactNode]. If it can't be redacted, like a
+// malformed ss:// link, it may hold a secret in a form we don't know, so it's replaced as a whole.
+func redactChangeValue(node config.ConfigNode) config.ConfigNode {
+	redacted, perr := redactNode(node)
+	if perr != nil {
+		return redactedValue
+	}
+	return redacted
+}
+
+// isSecretPath returns whether the last key of the path is a credential.
+func isSecretPath(path string) bool {
+	key := path[strings.LastIndexAny(path, ".]")+1:]
+	return secretConfigKeys[strings.ToLower(key)]
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func diffTunnelConfigs(t *testing.T, oldInput string, newInput string) tunnelConfigDiffJson {
+	result := DiffTunnelConfigs(oldInput, newInput)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.NotContains(t, result.Value, "SECRET")
+	var diff tunnelConfigDiffJson
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &diff))
+	return diff
+}
+
+func TestDiffTunnelConfigs_HostChange(t *testing.T) {
+	diff := diffTunnelConfigs(t,
+		"ss://chacha20-ietf-poly1305:SECRET@old.example.com:4321",
+		"ss://chacha20-ietf-poly1305:SECRET@new.example.com:4321")
+	require.Equal(t, tunnelConfigDiffJson{
+		Changed: true,
+		Changes: []tunnelConfigChangeJson{
+			{Path: "transport.endpoint", Old: "old.example.com:4321", New: "new.example.com:4321"},
+		},
+		Secrets: map[string]string{"transport.secret": SecretUnchanged},
+	}, diff)
+}
+
+func TestDiffTunnelConfigs_PasswordChange(t *testing.T) {
+	diff := diffTunnelConfigs(t, `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET1
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET1`, `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET2
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET1`)
+	require.Equal(t, tunnelConfigDiffJson{
+		Changed: true,
+		Changes: []tunnelConfigChangeJson{{Path: "transport.tcp.secret", Secret: true}},
+		Secrets: map[string]string{"transport.tcp.secret": SecretChanged, "transport.udp.secret": SecretUnchanged},
+	}, diff)
+}
+
+func TestDiffTunnelConfigs_EquivalentFormats(t *testing.T) {
+	diff := diffTunnelConfigs(t, "ss://chacha20-ietf-poly1305:SECRET@example.com:4321", `{
+    "server": "example.com",
+    "server_port": 4321,
+    "method": "chacha20-ietf-poly1305",
+    "password": "SECRET"
+}`)
+	require.False(t, diff.Changed)
+	require.Empty(t, diff.Changes)
+}
+
+func TestDiffTunnelConfigs_LayerAdded(t *testing.T) {
+	diff := diffTunnelConfigs(t, `
+transport:
+  $type: tcpudp
+  tcp: ss://chacha20-ietf-poly1305:SECRET@example.com:4321
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`, `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: wss://cdn.example.com/tcp
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`)
+	require.Equal(t, []tunnelConfigChangeJson{{
+		Path: "transport.tcp.endpoint",
+		Old:  "example.com:4321",
+		New:  map[string]any{"$type": "websocket", "url": "wss://cdn.example.com/tcp"},
+	}}, diff.Changes)
+}
+
+func TestDiffTunnelConfigs_InvalidConfig(t *testing.T) {
+	result := DiffTunnelConfigs("ss://chacha20-ietf-poly1305:SECRET@example.com:4321", "transport:")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "invalid new config: transport is required and must not be empty", result.Error.Message)
+}
+
+func Test_diffConfigNodes_UnredactableValue(t *testing.T) {
+	diff := tunnelConfigDiffJson{Secrets: map[string]string{}}
+	// A ss:// link that doesn't parse can't have just its password redacted.
+	diffConfigNodes(&diff, "transport.tcp.endpoint", "example.com:4321", map[string]any{"url": "ss://SECRET@"})
+	require.Equal(t, []tunnelConfigChangeJson{{
+		Path: "transport.tcp.endpoint",
+		Old:  "example.com:4321",
+		New:  redactedValue,
+	}}, diff.Changes)
+}
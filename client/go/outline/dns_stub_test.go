@@ -0,0 +1,59 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startStubDNSServer starts a DNS server that answers A queries with the address returned by
+// answer, and returns its address. Other queries, and every query if answer is nil or returns an
+// invalid address, get no records, like a resolver that blocks the host.
+func startStubDNSServer(t *testing.T, answer func() netip.Addr) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var request dnsmessage.Message
+			if request.Unpack(buf[:n]) != nil || len(request.Questions) != 1 {
+				continue
+			}
+			response := dnsmessage.Message{Header: dnsmessage.Header{ID: request.ID, Response: true}, Questions: request.Questions}
+			if q := request.Questions[0]; q.Type == dnsmessage.TypeA && answer != nil {
+				if ip := answer(); ip.Is4() {
+					response.Answers = []dnsmessage.Resource{{
+						Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: q.Class},
+						Body:   &dnsmessage.AResource{A: ip.As4()},
+					}}
+				}
+			}
+			if responseBytes, err := response.Pack(); err == nil {
+				conn.WriteTo(responseBytes, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// envVarPattern matches ${VAR} tokens, and their escaped $${VAR} form.
+var envVarPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces the ${VAR} tokens in text with values from the process environment.
+// An escaped $${VAR} is replaced with the literal ${VAR}.
+// Undefined variables expand to an empty string, unless errorOnUndefined is set.
+func expandEnv(text string, errorOnUndefined bool) (string, *platerrors.PlatformError) {
+	var undefined []string
+	expanded := envVarPattern.ReplaceAllStringFunc(text, func(token string) string {
+		if strings.HasPrefix(token, "$$") {
+			return token[1:]
+		}
+		name := envVarPattern.FindStringSubmatch(token)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	if errorOnUndefined && len(undefined) > 0 {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "config references undefined environment variables: " + strings.Join(undefined, ", "),
+			Details: platerrors.InvalidConfigDetails{
+				Reason: platerrors.ReasonMissing,
+				Extra:  platerrors.ErrorDetails{"undefinedVariables": undefined},
+			}.ToErrorDetails(),
+		}
+	}
+	return expanded, nil
+}
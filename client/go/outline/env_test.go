@@ -0,0 +1,73 @@
+// Copyright 2024 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_expandEnv(t *testing.T) {
+	t.Setenv("OUTLINE_TEST_HOST", "example.com")
+
+	expanded, perr := expandEnv("endpoint: ${OUTLINE_TEST_HOST}:443", false)
+	require.Nil(t, perr)
+	require.Equal(t, "endpoint: example.com:443", expanded)
+}
+
+func Test_expandEnv_Undefined(t *testing.T) {
+	expanded, perr := expandEnv("secret: ${OUTLINE_TEST_UNDEFINED}", false)
+	require.Nil(t, perr)
+	require.Equal(t, "secret: ", expanded)
+
+	_, perr = expandEnv("secret: ${OUTLINE_TEST_UNDEFINED}", true)
+	require.NotNil(t, perr)
+	require.Equal(t, platerrors.InvalidConfig, perr.Code)
+	require.Equal(t, []string{"OUTLINE_TEST_UNDEFINED"}, perr.Details["undefinedVariables"])
+}
+
+func Test_expandEnv_Escaped(t *testing.T) {
+	t.Setenv("OUTLINE_TEST_SECRET", "SECRET")
+
+	expanded, perr := expandEnv("secret: $${OUTLINE_TEST_SECRET}", true)
+	require.Nil(t, perr)
+	require.Equal(t, "secret: ${OUTLINE_TEST_SECRET}", expanded)
+}
+
+func Test_ParseTunnelConfigWithOptions_ExpandEnv(t *testing.T) {
+	t.Setenv("OUTLINE_TEST_HOST", "example.com")
+	t.Setenv("OUTLINE_TEST_SECRET", "SECRET")
+	input := `
+transport:
+  endpoint: ${OUTLINE_TEST_HOST}:4321
+  cipher: chacha20-ietf-poly1305
+  secret: ${OUTLINE_TEST_SECRET}`
+
+	result := ParseTunnelConfigWithOptions(input, &ParseOptions{ExpandEnv: true})
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Contains(t, response.Transport, "secret: SECRET")
+
+	// Substitution is opt-in.
+	result = doParseTunnelConfig(input)
+	require.Nil(t, result.Error)
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Contains(t, response.Transport, "secret: ${OUTLINE_TEST_SECRET}")
+}
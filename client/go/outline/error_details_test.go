@@ -0,0 +1,99 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_ErrorDetails_DocumentedKeys checks that each error path sets the Details keys documented for
+// its error code in the platerrors package.
+func Test_ErrorDetails_DocumentedKeys(t *testing.T) {
+	const ssLink = "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"
+	for _, tc := range []struct {
+		name    string
+		input   string
+		code    platerrors.ErrorCode
+		details platerrors.ErrorDetails
+	}{
+		{"empty", "  ", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonMissing}},
+		{"syntax", "transport: [", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonSyntax}},
+		{"duplicate key", "transport: " + ssLink + "\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonDuplicateKey}},
+		{"wrong shape", "- " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonWrongShape}},
+		{"missing transport", "transport:", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonMissing}},
+		{"empty transport list", "transport: []", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonMissing}},
+		{"transport wrong shape", "transport: 12", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonWrongShape}},
+		{"negative timeout", "connectTimeoutMs: -1\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "connectTimeoutMs", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid address family", "addressFamily: ipv5\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "addressFamily", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid resolver", "resolver: {}\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "resolver", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid probe order", "probeOrder: sctp\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "probeOrder", "reason": platerrors.ReasonInvalidValue}},
+		{"conflicting keys", "tunnelDns: 1.1.1.1\ntransport: {$type: tcpudp, tcp: '" + ssLink + "', udp: {$type: disabled}}", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "tunnelDns", "reason": platerrors.ReasonConflict, "conflictingKeys": []string{"tunnelDns", "udp"}}},
+		{"invalid tunnel DNS", "tunnelDns: dns.example.com\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "tunnelDns", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid health check URL", "healthCheckUrl: ftp://example.com\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "healthCheckUrl", "reason": platerrors.ReasonInvalidValue}},
+		{"dangling transport ref", "transport: {$ref: missing}", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "$ref", "reason": platerrors.ReasonMissing, "ref": "missing"}},
+		{"invalid bind address", "bindAddress: 192.0.2.1\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "bindAddress", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid fallback first hop", "fallbackFirstHop: 192.0.2.30\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "fallbackFirstHop", "reason": platerrors.ReasonInvalidValue}},
+		{"invalid host IP override", "hostIpOverride: {example.com: not-an-ip}\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "hostIpOverride", "reason": platerrors.ReasonInvalidValue}},
+		{"future version", "version: 99\ntransport: " + ssLink, platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "version", "reason": platerrors.ReasonUnsupported}},
+		{"unsupported transport", "transport:\n  $type: bogus", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonUnsupported, "unknownTransport": "bogus"}},
+		{"invalid transport", "transport:\n  $type: tcpudp\n  tcp: " + ssLink + "\n  udp: bogus", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonInvalidTransport}},
+		{"all transports failed", "transport:\n  - ss://invalid\n  - ss://invalid", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonInvalidTransport}},
+		{"unsupported scheme", "vmess://abc", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonUnsupported, "scheme": "vmess"}},
+		{"unsupported plugin", "ss://chacha20-ietf-poly1305:SECRET@example.com:4321/?plugin=obfs-local", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"field": "plugin", "reason": platerrors.ReasonUnsupported}},
+		{"invalid ss link", "ss://chacha20-ietf-poly1305@example.com:4321/", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonInvalidValue}},
+		{"insecure URL", "http://example.com/config", platerrors.InvalidConfig,
+			platerrors.ErrorDetails{"reason": platerrors.ReasonNotAllowed}},
+		{"provider error", "error:\n  message: Unavailable\n  details: Try later", platerrors.ProviderError,
+			platerrors.ErrorDetails{"details": "Try later"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := doParseTunnelConfig(tc.input)
+			require.NotNil(t, result.Error)
+			require.Equal(t, tc.code, result.Error.Code, "Got %v", result.Error)
+			for key, value := range tc.details {
+				require.Contains(t, result.Error.Details, key)
+				require.Equal(t, value, result.Error.Details[key], key)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"errors"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// structuralConfigTypes are the $type values that combine other configs, rather than being layers.
+var structuralConfigTypes = map[string]bool{"chain": true, "dial": true, "tcpudp": true}
+
+// addFailedLayerDetails adds the layer of the transport config whose creation failed with err to
+// the Details of perr, if known: "failedLayer" is its $type and "layerIndex" its position in the
+// layers reported by [listTransportLayers], from the first hop inward. The index is left out if the
+// failure isn't on the stream path of those layers, like in the packet path of a tcpudp config.
+func addFailedLayerDetails(perr *platerrors.PlatformError, err error, transportNode config.ConfigNode) {
+	var layerErr *config.LayerError
+	if !errors.As(err, &layerErr) {
+		return
+	}
+	var path []string
+	for _, typeName := range layerErr.Path {
+		if !structuralConfigTypes[typeName] {
+			path = append(path, typeName)
+		}
+	}
+	if len(path) == 0 {
+		return
+	}
+	if perr.Details == nil {
+		perr.Details = platerrors.ErrorDetails{}
+	}
+	perr.Details["failedLayer"] = path[len(path)-1]
+
+	// The layers are the ones of the stream path, listed from the outermost config, like the path.
+	if layerErr.Kind != config.KindStreamDialer && layerErr.Kind != config.KindStreamEndpoint {
+		return
+	}
+	layers := transportLayers(transportNode)
+	if len(path) > len(layers) {
+		return
+	}
+	for i, typeName := range path {
+		if layers[i].Type != typeName {
+			return
+		}
+	}
+	perr.Details["layerIndex"] = len(layers) - len(path)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_FailedLayer(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		cipher     string
+		tls        string
+		layer      string
+		layerIndex int
+	}{
+		{"invalid cipher", "not-a-cipher", "{$type: tls, endpoint: example.com:443}", "shadowsocks", 2},
+		{"tls without sni", "chacha20-ietf-poly1305", "{$type: tls, endpoint: {$type: dial, address: example.com:443}}", "tls", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: ws://example.com/tcp
+      endpoint: ` + tc.tls + `
+    cipher: ` + tc.cipher + `
+    secret: SECRET
+  udp:
+    $type: disabled`)
+			require.NotNil(t, result.Error)
+			require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+			require.Equal(t, tc.layer, result.Error.Details["failedLayer"])
+			require.EqualValues(t, tc.layerIndex, result.Error.Details["layerIndex"])
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_FailedLayerWithoutIndex(t *testing.T) {
+	// The failure is on the packet path, which isn't in the layers.
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp: {$type: shadowsocks, endpoint: example.com:4321, cipher: chacha20-ietf-poly1305, secret: SECRET}
+  udp: {$type: shadowsocks, endpoint: example.com:4321, cipher: not-a-cipher, secret: SECRET}`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, "shadowsocks", result.Error.Details["failedLayer"])
+	require.NotContains(t, result.Error.Details, "layerIndex")
+}
@@ -0,0 +1,54 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// validateFallbackFirstHop checks that the fallbackFirstHop setting is a host:port address, like the
+// backup entry IP that providers publish in case the first hop host is blocked by DNS poisoning.
+func validateFallbackFirstHop(value string) *platerrors.PlatformError {
+	host, portText, err := net.SplitHostPort(value)
+	if err == nil && host != "" {
+		if port, err := strconv.ParseUint(portText, 10, 16); err == nil && port != 0 {
+			return nil
+		}
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("fallbackFirstHop must be a host:port address, found %q", value),
+		Details: platerrors.InvalidConfigDetails{Field: "fallbackFirstHop", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+	}
+}
+
+// isResolveFailure tells whether creating the client failed because a first hop didn't resolve,
+// for a single transport or for any entry of a transport list.
+func isResolveFailure(perr *platerrors.PlatformError) bool {
+	if perr.Code == platerrors.ResolveIPFailed {
+		return true
+	}
+	failures, _ := perr.Details["failures"].([]any)
+	for _, failure := range failures {
+		if details, ok := failure.(platerrors.ErrorDetails); ok && details["code"] == platerrors.ResolveIPFailed {
+			return true
+		}
+	}
+	return false
+}
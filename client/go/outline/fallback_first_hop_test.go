@@ -0,0 +1,134 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport/shadowsocks"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_FallbackFirstHop(t *testing.T) {
+	resolver := startStubDNSServer(t, nil)
+	parse := func(fallbackFirstHop string) *InvokeMethodResult {
+		return doParseTunnelConfig(`
+resolver: {address: "` + resolver + `"}
+fallbackFirstHop: "` + fallbackFirstHop + `"
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@proxy.invalid:4321/`)
+	}
+
+	result := parse("192.0.2.30:443")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "192.0.2.30:443", response.FirstHop)
+	require.True(t, response.FallbackFirstHopUsed)
+
+	// The fallback doesn't resolve either, so the error is about the primary first hop.
+	result = parse("backup.invalid:443")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.ResolveIPFailed, result.Error.Code)
+	require.Equal(t, "proxy.invalid", result.Error.Details["host"])
+}
+
+func Test_doParseTunnelConfig_FallbackFirstHopUnused(t *testing.T) {
+	// A fallback makes the parse resolve the first hop, so it's an IP to stay offline.
+	result := doParseTunnelConfig("fallbackFirstHop: 192.0.2.30:443\ntransport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@192.0.2.1:4321/")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "192.0.2.1:4321", response.FirstHop)
+	require.False(t, response.FallbackFirstHopUsed)
+}
+
+func Test_doParseTunnelConfig_InvalidFallbackFirstHop(t *testing.T) {
+	for _, value := range []string{"192.0.2.30", ":443", "example.com:0", "example.com:http"} {
+		result := doParseTunnelConfig("fallbackFirstHop: '" + value + "'" + versionTestTransport)
+		require.NotNil(t, result.Error, value)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Equal(t, platerrors.ErrorDetails{"field": "fallbackFirstHop", "reason": platerrors.ReasonInvalidValue}, result.Error.Details)
+	}
+}
+
+func Test_doParseTunnelConfig_FallbackFirstHopForcesResolution(t *testing.T) {
+	// Without a resolver or an address family, the first hop isn't resolved in tests, unless there's
+	// a fallback. The host isn't a valid domain name, so it fails to resolve without a query.
+	result := doParseTunnelConfig(`
+fallbackFirstHop: 192.0.2.30:443
+transport:
+  $type: tcpudp
+  tcp: {$type: shadowsocks, endpoint: "bad..invalid:4321", cipher: chacha20-ietf-poly1305, secret: SECRET}
+  udp: {$type: shadowsocks, endpoint: "bad..invalid:4321", cipher: chacha20-ietf-poly1305, secret: SECRET}`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "192.0.2.30:443", response.FirstHop)
+	require.True(t, response.FallbackFirstHopUsed)
+}
+
+// startShadowsocksHTTPServer starts a Shadowsocks server with the key that answers any request with
+// an HTTP response, and returns its address.
+func startShadowsocksHTTPServer(t *testing.T, key *shadowsocks.EncryptionKey) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// The first chunk has the destination address and, usually, the request.
+				if _, err := shadowsocks.NewReader(conn, key).Read(make([]byte, 1024)); err != nil {
+					return
+				}
+				shadowsocks.NewWriter(conn, key).Write([]byte("HTTP/1.1 200 OK\r\n\r\n"))
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClient_TestConnectivity_FallbackFirstHop(t *testing.T) {
+	key, err := shadowsocks.NewEncryptionKey("chacha20-ietf-poly1305", "SECRET")
+	require.NoError(t, err)
+	fallbackFirstHop := startShadowsocksHTTPServer(t, key)
+	// Nothing listens on the first hop, so it doesn't connect.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	firstHop := listener.Addr().String()
+	listener.Close()
+
+	transportConfig := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@" + firstHop + "/"
+	result := doParseTunnelConfig("fallbackFirstHop: " + fallbackFirstHop + "\ntransport: " + transportConfig)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	// The first hop resolves, so the parse doesn't use the fallback, but the client knows it.
+	client, ok := parsedClients.get(clientCacheKey{transportConfig, clientOptions{probeFallbackFirstHop: fallbackFirstHop}})
+	require.True(t, ok)
+
+	probeResult := client.TestConnectivity(5000, false)
+	require.Nil(t, probeResult.Error)
+	var probe connectivityProbeResult
+	require.NoError(t, json.Unmarshal([]byte(probeResult.Value), &probe))
+	require.True(t, probe.TCP.OK, "Got %v", probe.TCP.Error)
+	require.True(t, probe.FallbackFirstHopUsed)
+	require.Equal(t, fallbackFirstHop, probe.FirstHop)
+}
@@ -15,6 +15,8 @@
 package outline
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -24,6 +26,12 @@ import (
 
 const fetchTimeout = 10 * time.Second
 
+// maxFetchedTunnelConfigSize bounds the size of a tunnel config fetched by [fetchTunnelConfig].
+const maxFetchedTunnelConfigSize = 64 * 1024
+
+// tunnelConfigHTTPClient is the client used to fetch tunnel configs from https:// URLs.
+var tunnelConfigHTTPClient = &http.Client{Timeout: fetchTimeout}
+
 // fetchResource fetches a resource from the given URL.
 //
 // The function makes an HTTP GET request to the specified URL and returns the response body as a
@@ -37,7 +45,7 @@ func fetchResource(url string) (string, error) {
 		return "", platerrors.PlatformError{
 			Code:    platerrors.FetchConfigFailed,
 			Message: "failed to fetch the URL",
-			Details: platerrors.ErrorDetails{"url": url},
+			Details: platerrors.FetchConfigFailedDetails{URL: url}.ToErrorDetails(),
 			Cause:   platerrors.ToPlatformError(err),
 		}
 	}
@@ -47,19 +55,70 @@ func fetchResource(url string) (string, error) {
 		return "", platerrors.PlatformError{
 			Code:    platerrors.FetchConfigFailed,
 			Message: "non-successful HTTP status",
-			Details: platerrors.ErrorDetails{
-				"status": resp.Status,
-				"body":   string(body),
-			},
+			Details: platerrors.FetchConfigFailedDetails{URL: url, Status: resp.Status, Body: string(body)}.ToErrorDetails(),
 		}
 	}
 	if err != nil {
 		return "", platerrors.PlatformError{
 			Code:    platerrors.FetchConfigFailed,
 			Message: "failed to read the body",
-			Details: platerrors.ErrorDetails{"url": url},
+			Details: platerrors.FetchConfigFailedDetails{URL: url}.ToErrorDetails(),
 			Cause:   platerrors.ToPlatformError(err),
 		}
 	}
 	return string(body), nil
 }
+
+// fetchTunnelConfig fetches a tunnel config from the given URL for the parser.
+//
+// Unlike [fetchResource], a non-2xx status is reported as a [platerrors.ProviderError], since it's
+// the provisioning endpoint that rejected the request, and the body is capped at
+// maxFetchedTunnelConfigSize bytes.
+func fetchTunnelConfig(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid config URL",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.FetchConfigFailed,
+			Message: "failed to fetch the URL",
+			Details: platerrors.FetchConfigFailedDetails{URL: url}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.ProviderError,
+			Message: "non-successful HTTP status",
+			Details: platerrors.ProviderErrorDetails{URL: url, Status: resp.Status, StatusCode: resp.StatusCode}.ToErrorDetails(),
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchedTunnelConfigSize+1))
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.FetchConfigFailed,
+			Message: "failed to read the body",
+			Details: platerrors.FetchConfigFailedDetails{URL: url}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	if len(body) > maxFetchedTunnelConfigSize {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("fetched config exceeds %d bytes", maxFetchedTunnelConfigSize),
+			Details: platerrors.InvalidConfigDetails{
+				Reason: platerrors.ReasonTooLarge,
+				Extra:  platerrors.ErrorDetails{"url": url},
+			}.ToErrorDetails(),
+		}
+	}
+	return string(body), nil
+}
@@ -15,6 +15,7 @@
 package outline
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -127,3 +128,41 @@ func TestFetchResource_Timeout(t *testing.T) {
 	require.Error(t, err, "fetchResource should return a non-nil timeout error")
 	require.Empty(t, content)
 }
+
+func TestFetchTunnelConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	}))
+	defer server.Close()
+
+	content, err := fetchTunnelConfig(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	require.Equal(t, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\n", content)
+}
+
+func TestFetchTunnelConfig_HTTPStatusError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var perr platerrors.PlatformError
+	content, err := fetchTunnelConfig(context.Background(), server.Client(), server.URL)
+	require.Empty(t, content)
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, platerrors.ProviderError, perr.Code)
+	require.Equal(t, http.StatusTooManyRequests, perr.Details["statusCode"])
+}
+
+func TestFetchTunnelConfig_TooLarge(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(make([]byte, maxFetchedTunnelConfigSize+1))
+	}))
+	defer server.Close()
+
+	var perr platerrors.PlatformError
+	content, err := fetchTunnelConfig(context.Background(), server.Client(), server.URL)
+	require.Empty(t, content)
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, platerrors.InvalidConfig, perr.Code)
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// maxTunnelConfigFileSize bounds the size of a tunnel config read by [readTunnelConfigFile].
+const maxTunnelConfigFileSize = 64 * 1024
+
+var tunnelConfigBaseDir struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// SetTunnelConfigBaseDir sets the directory that holds the files referenced by file:// tunnel
+// configs. Files outside of it can't be read. An empty dir, the default, disables file references.
+func SetTunnelConfigBaseDir(dir string) {
+	tunnelConfigBaseDir.mu.Lock()
+	defer tunnelConfigBaseDir.mu.Unlock()
+	tunnelConfigBaseDir.dir = dir
+}
+
+// readTunnelConfigFile reads the tunnel config at the given file:// URI, which must be in the
+// directory set with [SetTunnelConfigBaseDir]. The path is checked as written first, so that paths
+// outside of the directory fail the same way whether they exist or not, and again once symbolic
+// links are resolved, so they can't point outside of it.
+func readTunnelConfigFile(uri string) (string, error) {
+	tunnelConfigBaseDir.mu.Lock()
+	baseDir := tunnelConfigBaseDir.dir
+	tunnelConfigBaseDir.mu.Unlock()
+	if baseDir == "" {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "file:// configs are not enabled",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonNotAllowed}.ToErrorDetails(),
+		}
+	}
+
+	fileURL, err := url.Parse(uri)
+	if err != nil || (fileURL.Host != "" && fileURL.Host != "localhost") || fileURL.Path == "" {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid config file URI",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+	path := fileURL.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir/config.yaml has the path /C:/dir/config.yaml.
+		path = strings.TrimPrefix(path, "/")
+	}
+	path = filepath.Clean(filepath.FromSlash(path))
+
+	realBaseDir, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "invalid config base directory",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	outsideErr := platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "config file is outside the allowed directory",
+		Details: platerrors.InvalidConfigDetails{
+			Reason: platerrors.ReasonNotAllowed,
+			Extra:  platerrors.ErrorDetails{"path": path},
+		}.ToErrorDetails(),
+	}
+	// The base directory may be given through a symbolic link, like /var on macOS.
+	if !isInDir(filepath.Clean(baseDir), path) && !isInDir(realBaseDir, path) {
+		return "", outsideErr
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.FetchConfigFailed,
+			Message: "failed to read the config file",
+			Details: platerrors.FetchConfigFailedDetails{Path: path}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	if !isInDir(realBaseDir, realPath) {
+		return "", outsideErr
+	}
+
+	file, err := os.Open(realPath)
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.FetchConfigFailed,
+			Message: "failed to read the config file",
+			Details: platerrors.FetchConfigFailedDetails{Path: path}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	defer file.Close()
+	content, err := io.ReadAll(io.LimitReader(file, maxTunnelConfigFileSize+1))
+	if err != nil {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.FetchConfigFailed,
+			Message: "failed to read the config file",
+			Details: platerrors.FetchConfigFailedDetails{Path: path}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	if len(content) > maxTunnelConfigFileSize {
+		return "", platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("config file exceeds %d bytes", maxTunnelConfigFileSize),
+			Details: platerrors.InvalidConfigDetails{
+				Reason: platerrors.ReasonTooLarge,
+				Extra:  platerrors.ErrorDetails{"path": path},
+			}.ToErrorDetails(),
+		}
+	}
+	return string(content), nil
+}
+
+// isInDir tells whether the clean path is dir or is under it, without touching the file system.
+func isInDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
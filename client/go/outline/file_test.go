@@ -0,0 +1,106 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+// setTestBaseDir creates a base directory for file:// configs, and a directory outside of it.
+func setTestBaseDir(t *testing.T) (string, string) {
+	root := t.TempDir()
+	baseDir := filepath.Join(root, "configs")
+	outsideDir := filepath.Join(root, "private")
+	require.NoError(t, os.Mkdir(baseDir, 0o700))
+	require.NoError(t, os.Mkdir(outsideDir, 0o700))
+	SetTunnelConfigBaseDir(baseDir)
+	t.Cleanup(func() { SetTunnelConfigBaseDir("") })
+	return baseDir, outsideDir
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func Test_doParseTunnelConfig_File(t *testing.T) {
+	baseDir, _ := setTestBaseDir(t)
+	path := filepath.Join(baseDir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\n"), 0o600))
+
+	result := doParseTunnelConfig(fileURI(path))
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+}
+
+func Test_doParseTunnelConfig_FilePathTraversal(t *testing.T) {
+	baseDir, outsideDir := setTestBaseDir(t)
+	secretPath := filepath.Join(outsideDir, "secret.yaml")
+	require.NoError(t, os.WriteFile(secretPath, []byte("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"), 0o600))
+	linkPath := filepath.Join(baseDir, "link.yaml")
+	require.NoError(t, os.Symlink(secretPath, linkPath))
+
+	for _, uri := range []string{
+		"file://" + filepath.ToSlash(baseDir) + "/../private/secret.yaml",
+		fileURI(secretPath),
+		fileURI(linkPath),
+	} {
+		result := doParseTunnelConfig(uri)
+		require.NotNil(t, result.Error, uri)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code, uri)
+		require.Equal(t, "config file is outside the allowed directory", result.Error.Message, uri)
+	}
+}
+
+func Test_doParseTunnelConfig_FileOutsideExistence(t *testing.T) {
+	_, outsideDir := setTestBaseDir(t)
+	existingPath := filepath.Join(outsideDir, "secret.yaml")
+	require.NoError(t, os.WriteFile(existingPath, []byte("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"), 0o600))
+
+	// The errors don't tell whether a file outside of the base directory exists.
+	existing := doParseTunnelConfig(fileURI(existingPath))
+	missing := doParseTunnelConfig(fileURI(filepath.Join(outsideDir, "missing.yaml")))
+	require.NotNil(t, existing.Error)
+	require.NotNil(t, missing.Error)
+	require.Equal(t, existing.Error.Code, missing.Error.Code)
+	require.Equal(t, existing.Error.Message, missing.Error.Message)
+	require.Equal(t, platerrors.ReasonNotAllowed, missing.Error.Details["reason"])
+}
+
+func Test_doParseTunnelConfig_FileErrors(t *testing.T) {
+	result := doParseTunnelConfig(fileURI(filepath.Join(t.TempDir(), "config.yaml")))
+	require.NotNil(t, result.Error)
+	require.Equal(t, "file:// configs are not enabled", result.Error.Message)
+
+	baseDir, _ := setTestBaseDir(t)
+	result = doParseTunnelConfig(fileURI(filepath.Join(baseDir, "missing.yaml")))
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.FetchConfigFailed, result.Error.Code)
+
+	largePath := filepath.Join(baseDir, "large.yaml")
+	require.NoError(t, os.WriteFile(largePath, []byte(strings.Repeat("#", maxTunnelConfigFileSize+1)), 0o600))
+	result = doParseTunnelConfig(fileURI(largePath))
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
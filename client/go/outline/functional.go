@@ -0,0 +1,118 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// metadataConfigKeys are the top-level keys of the advanced format that are only for display, in
+// lowercase.
+var metadataConfigKeys = map[string]bool{"name": true, "tags": true, "region": true, "provider": true}
+
+// FunctionalTransportConfig returns the canonical form of the parts of the tunnel config that
+// affect the connection, so that configs that only differ in their labels compare equal, like to
+// deduplicate stored configs. It drops the comments, the display keys of the advanced format, like
+// name and tags, and the "#tag" fragment of the ss:// links, which are canonicalized as in
+// [CanonicalizeSSURL]. Other configs are returned as compact JSON with sorted keys.
+//
+// It accepts ss:// links, legacy JSON configs and the advanced YAML format, and doesn't validate
+// the transport, so it doesn't use the network.
+func FunctionalTransportConfig(input string) (string, error) {
+	input = strings.TrimSpace(strings.TrimPrefix(input, "\ufeff"))
+	if strings.HasPrefix(input, "ss://") {
+		return functionalShadowsocksURL(input)
+	}
+
+	node, err := config.ParseConfigYAML(input)
+	if err != nil {
+		return "", newYAMLParseError(err)
+	}
+	if typed, ok := node.(map[string]any); ok && typed["transport"] != nil {
+		functional := make(map[string]any, len(typed))
+		for key, value := range typed {
+			if !metadataConfigKeys[strings.ToLower(key)] {
+				functional[key] = value
+			}
+		}
+		node = functional
+	}
+	functionalNode, perr := functionalNode(node)
+	if perr != nil {
+		return "", perr
+	}
+	// Marshal sorts the keys of the maps.
+	out, err := json.Marshal(functionalNode)
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to serialize the functional config",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return string(out), nil
+}
+
+// functionalNode returns a copy of the node with the ss:// links in functional form.
+func functionalNode(node config.ConfigNode) (config.ConfigNode, *platerrors.PlatformError) {
+	switch typed := node.(type) {
+	case map[string]any:
+		functional := make(map[string]any, len(typed))
+		for key, value := range typed {
+			child, perr := functionalNode(value)
+			if perr != nil {
+				return nil, perr
+			}
+			functional[key] = child
+		}
+		return functional, nil
+	case []any:
+		functional := make([]any, 0, len(typed))
+		for _, value := range typed {
+			child, perr := functionalNode(value)
+			if perr != nil {
+				return nil, perr
+			}
+			functional = append(functional, child)
+		}
+		return functional, nil
+	case string:
+		if strings.HasPrefix(typed, "ss://") {
+			link, err := functionalShadowsocksURL(typed)
+			if err != nil {
+				return nil, platerrors.ToPlatformError(err)
+			}
+			return link, nil
+		}
+		return typed, nil
+	default:
+		return node, nil
+	}
+}
+
+// functionalShadowsocksURL returns the canonical form of the ss:// link, without the "#tag"
+// fragment, which is the server name.
+func functionalShadowsocksURL(link string) (string, error) {
+	canonical, err := CanonicalizeSSURL(link)
+	if err != nil {
+		return "", err
+	}
+	canonical, _, _ = strings.Cut(canonical, "#")
+	return canonical, nil
+}
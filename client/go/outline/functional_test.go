@@ -0,0 +1,71 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionalTransportConfig(t *testing.T) {
+	first, err := FunctionalTransportConfig(`
+# Primary server.
+name: Home
+tags: [fast]
+addressFamily: ipv4
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`)
+	require.NoError(t, err)
+	second, err := FunctionalTransportConfig(`{"name": "Office", "transport": {"$type": "tcpudp",
+  "udp": {"$type": "shadowsocks", "endpoint": "example.com:4321", "cipher": "chacha20-ietf-poly1305", "secret": "SECRET"},
+  "tcp": {"$type": "shadowsocks", "endpoint": "example.com:4321", "cipher": "chacha20-ietf-poly1305", "secret": "SECRET"}},
+  "addressFamily": "ipv4"}`)
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.NotContains(t, first, "name")
+
+	// Settings that affect the connection are kept.
+	third, err := FunctionalTransportConfig(`
+name: Home
+addressFamily: ipv6
+transport: {$type: shadowsocks, endpoint: example.com:4321, cipher: chacha20-ietf-poly1305, secret: SECRET}`)
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+}
+
+func TestFunctionalTransportConfig_SSURL(t *testing.T) {
+	link := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"
+	for _, input := range []string{link + "#Home", link + "#Office", "ss://chacha20-ietf-poly1305:SECRET@EXAMPLE.com:4321"} {
+		functional, err := FunctionalTransportConfig(input)
+		require.NoError(t, err, input)
+		require.Equal(t, link, functional, input)
+	}
+
+	functional, err := FunctionalTransportConfig("transport: " + link + "#Home")
+	require.NoError(t, err)
+	require.Equal(t, `{"transport":"`+link+`"}`, functional)
+
+	_, err = FunctionalTransportConfig("ss://invalid")
+	require.Error(t, err)
+	require.Equal(t, platerrors.InvalidConfig, platerrors.ToPlatformError(err).Code)
+}
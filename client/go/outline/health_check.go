@@ -0,0 +1,136 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// maxHealthCheckBodySize bounds the bytes of the health check response that are read. The rest of
+// the body is ignored, since only the status matters.
+const maxHealthCheckBodySize = 64 * 1024
+
+// healthCheckResult is the JSON result of [Client.CheckHealth].
+type healthCheckResult struct {
+	StatusCode int   `json:"statusCode"`
+	LatencyMs  int64 `json:"latencyMs"`
+	// Bytes is the size of the body that was read, up to maxHealthCheckBodySize.
+	Bytes int64 `json:"bytes"`
+}
+
+// validateHealthCheckURL checks that the healthCheckUrl setting is an absolute http or https URL.
+func validateHealthCheckURL(value string) *platerrors.PlatformError {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("healthCheckUrl must be an http or https URL, found %q", value),
+			Details: platerrors.InvalidConfigDetails{Field: "healthCheckUrl", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+	return nil
+}
+
+// CheckHealth fetches the healthCheckUrl of the config through the tunnel, to check that the service
+// behind the tunnel works and not just the proxy. The result Value is a JSON object with the
+// "statusCode", the "latencyMs" until the response headers and the "bytes" of the body read.
+//
+// The check is bounded by timeoutMs, or by the default connect timeout if non-positive, and fails
+// with [platerrors.OperationTimedOut] when it expires. A non-2xx status fails with
+// [platerrors.HealthCheckFailed].
+func (c *Client) CheckHealth(timeoutMs int) *InvokeMethodResult {
+	if c.opts.healthCheckURL == "" {
+		return &InvokeMethodResult{Error: &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "the config has no healthCheckUrl",
+			Details: platerrors.InvalidConfigDetails{Field: "healthCheckUrl", Reason: platerrors.ReasonMissing}.ToErrorDetails(),
+		}}
+	}
+	timeout := defaultConnectTimeout
+	if timeoutMs > 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	result, perr := c.checkHealth(ctx, c.opts.healthCheckURL)
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	return marshalInvokeMethodResult(result)
+}
+
+func (c *Client) checkHealth(ctx context.Context, healthCheckURL string) (*healthCheckResult, *platerrors.PlatformError) {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return c.DialStream(ctx, addr)
+			},
+		},
+		// Redirects are reported as they are, since the health check is about the URL of the config.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	defer httpClient.CloseIdleConnections()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthCheckURL, nil)
+	if err != nil {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid healthCheckUrl",
+			Details: platerrors.InvalidConfigDetails{Field: "healthCheckUrl", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx.Err())
+		}
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.ProxyServerUnreachable,
+			Message: "failed to fetch the health check URL through the tunnel",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	defer resp.Body.Close()
+	result := &healthCheckResult{StatusCode: resp.StatusCode, LatencyMs: time.Since(start).Milliseconds()}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.HealthCheckFailed,
+			Message: "health check URL returned a non-successful HTTP status",
+			Details: platerrors.HealthCheckFailedDetails{URL: healthCheckURL, Status: resp.Status, StatusCode: resp.StatusCode}.ToErrorDetails(),
+		}
+	}
+	result.Bytes, err = io.Copy(io.Discard, io.LimitReader(resp.Body, maxHealthCheckBodySize))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx.Err())
+		}
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.ProxyServerReadFailed,
+			Message: "failed to read the health check response",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/stretchr/testify/require"
+)
+
+// newHealthCheckClient returns a client whose tunnel connects every stream to server, whatever the
+// destination, like a proxy would.
+func newHealthCheckClient(server *httptest.Server) *Client {
+	serverAddr := server.Listener.Addr().String()
+	return &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			ConnectionProviderInfo: config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHop: serverAddr},
+			Dial: func(ctx context.Context, _ string) (transport.StreamConn, error) {
+				return (&transport.TCPDialer{}).DialStream(ctx, serverAddr)
+			},
+		},
+		opts: clientOptions{healthCheckURL: "http://health.example/check"},
+	}
+}
+
+func TestClient_CheckHealth(t *testing.T) {
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.Write([]byte(strings.Repeat("x", maxHealthCheckBodySize+100)))
+	}))
+	defer server.Close()
+
+	result := newHealthCheckClient(server).CheckHealth(5000)
+	require.Nil(t, result.Error)
+	var health healthCheckResult
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &health))
+	require.Equal(t, http.StatusOK, health.StatusCode)
+	require.GreaterOrEqual(t, health.LatencyMs, int64(0))
+	require.Equal(t, int64(maxHealthCheckBodySize), health.Bytes)
+	require.Equal(t, "health.example", host)
+}
+
+func TestClient_CheckHealth_NonSuccessfulStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	result := newHealthCheckClient(server).CheckHealth(5000)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.HealthCheckFailed, result.Error.Code)
+	require.Equal(t, platerrors.ErrorDetails{
+		"url": "http://health.example/check", "status": "503 Service Unavailable", "statusCode": 503,
+	}, result.Error.Details)
+}
+
+func TestClient_CheckHealth_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	result := newHealthCheckClient(server).CheckHealth(50)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.OperationTimedOut, result.Error.Code)
+}
+
+func TestClient_CheckHealth_Unreachable(t *testing.T) {
+	client := &Client{
+		sd: &config.Dialer[transport.StreamConn]{
+			Dial: func(context.Context, string) (transport.StreamConn, error) {
+				return nil, &net.OpError{Op: "dial", Err: net.UnknownNetworkError("refused")}
+			},
+		},
+		opts: clientOptions{healthCheckURL: "http://health.example/check"},
+	}
+	result := client.CheckHealth(5000)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.ProxyServerUnreachable, result.Error.Code)
+}
+
+func TestClient_CheckHealth_NotConfigured(t *testing.T) {
+	result := (&Client{}).CheckHealth(5000)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "healthCheckUrl", result.Error.Details["field"])
+}
+
+func Test_doParseTunnelConfig_HealthCheckURL(t *testing.T) {
+	result := doParseTunnelConfig("healthCheckUrl: https://example.com/health" + versionTestTransport)
+	require.Nil(t, result.Error)
+	var tunnelConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &tunnelConfig))
+	require.Equal(t, "https://example.com/health", tunnelConfig.HealthCheckURL)
+
+	for _, value := range []string{"ftp://example.com/health", "/health", "https://"} {
+		result := doParseTunnelConfig("healthCheckUrl: " + value + versionTestTransport)
+		require.NotNil(t, result.Error, value)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Equal(t, "healthCheckUrl", result.Error.Details["field"])
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// parseHostIPOverride checks the hostIpOverride setting, which maps first hop host names to the IP
+// to use instead of resolving them, and returns it in the canonical form of
+// [clientOptions.hostIPOverride]: lowercase "host=ip" pairs, sorted and joined with commas.
+func parseHostIPOverride(overrides map[string]string) (string, *platerrors.PlatformError) {
+	ips := make(map[string]string, len(overrides))
+	for host, ipText := range overrides {
+		if host == "" || strings.ContainsAny(host, "=,:") {
+			return "", newHostIPOverrideError(fmt.Sprintf("hostIpOverride has an invalid host name %q", host))
+		}
+		ip, err := netip.ParseAddr(ipText)
+		if err != nil || ip.Zone() != "" {
+			return "", newHostIPOverrideError(fmt.Sprintf("hostIpOverride of %q must be an IP address, found %q", host, ipText))
+		}
+		host = strings.ToLower(host)
+		if _, ok := ips[host]; ok {
+			return "", newHostIPOverrideError(fmt.Sprintf("hostIpOverride has host %q more than once", host))
+		}
+		ips[host] = ip.Unmap().String()
+	}
+	pairs := make([]string, 0, len(ips))
+	for host, ip := range ips {
+		pairs = append(pairs, host+"="+ip)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ","), nil
+}
+
+func newHostIPOverrideError(message string) *platerrors.PlatformError {
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: message,
+		Details: platerrors.InvalidConfigDetails{Field: "hostIpOverride", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+	}
+}
+
+// hostIPOverrideMap returns the overrides of the canonical form, as validated by
+// [parseHostIPOverride].
+func hostIPOverrideMap(hostIPOverride string) map[string]netip.Addr {
+	overrides := make(map[string]netip.Addr)
+	for _, pair := range strings.Split(hostIPOverride, ",") {
+		host, ipText, _ := strings.Cut(pair, "=")
+		if ip, err := netip.ParseAddr(ipText); err == nil {
+			overrides[host] = ip
+		}
+	}
+	return overrides
+}
+
+// overriddenFirstHopIP returns the override of the host of the first hop, if any.
+func overriddenFirstHopIP(hostIPOverride string, firstHop string) (netip.Addr, bool) {
+	if hostIPOverride == "" {
+		return netip.Addr{}, false
+	}
+	host, _, err := net.SplitHostPort(firstHop)
+	if err != nil {
+		host = firstHop
+	}
+	ip, ok := hostIPOverrideMap(hostIPOverride)[strings.ToLower(host)]
+	return ip, ok
+}
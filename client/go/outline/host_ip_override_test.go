@@ -0,0 +1,68 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_HostIPOverride(t *testing.T) {
+	// The override points the unresolvable host to a local server, which must get the connection.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	accepted := make(chan struct{})
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conn.Close()
+			close(accepted)
+		}
+	}()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	transportConfig := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@Proxy.invalid:" + port + "/"
+
+	result := doParseTunnelConfig(`
+hostIpOverride: {proxy.INVALID: 127.0.0.1}
+transport: ` + transportConfig)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "Proxy.invalid:"+port, response.FirstHop)
+	require.Equal(t, []string{"127.0.0.1"}, response.FirstHopAddresses)
+
+	clientResult := newClient(context.Background(), transportConfig, clientOptions{hostIPOverride: "proxy.invalid=127.0.0.1"})
+	require.Nil(t, clientResult.Error, "Got %v", clientResult.Error)
+	conn, err := clientResult.Client.DialStream(context.Background(), "example.com:80")
+	require.NoError(t, err)
+	conn.Close()
+	<-accepted
+}
+
+func Test_parseHostIPOverride(t *testing.T) {
+	hostIPOverride, perr := parseHostIPOverride(map[string]string{"B.example.com": "::ffff:192.0.2.2", "a.example.com": "2001:db8::1"})
+	require.Nil(t, perr)
+	require.Equal(t, "a.example.com=2001:db8::1,b.example.com=192.0.2.2", hostIPOverride)
+
+	for _, overrides := range []map[string]string{{"example.com": "example.org"}, {"": "192.0.2.1"}, {"example.com:443": "192.0.2.1"}, {"example.com": "192.0.2.1", "Example.com": "192.0.2.2"}} {
+		_, perr := parseHostIPOverride(overrides)
+		require.NotNil(t, perr, overrides)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// InsecureShadowsocksCiphers lists the Shadowsocks ciphers that are known to be broken. Old
+// servers may still offer them. The Outline SDK only implements the AEAD ciphers, so configs that
+// use them are rejected, by the parse too, with an [platerrors.InvalidConfig] error that says why.
+var InsecureShadowsocksCiphers = []string{"rc4-md5", "table"}
+
+// The "severity" of the cipher issues. The insecure ciphers don't protect the traffic, while the
+// deprecated names are just aliases of AEAD ciphers.
+const (
+	insecureCipherSeverity   = "high"
+	deprecatedCipherSeverity = "low"
+)
+
+// CipherWarning is a non-fatal issue with the Shadowsocks cipher of a config, like a deprecated
+// alias of an AEAD cipher.
+type CipherWarning struct {
+	// Cipher is the cipher as written in the config.
+	Cipher string `json:"cipher"`
+	// Severity is "low" for the deprecated aliases.
+	Severity string `json:"severity"`
+	// Message describes the issue. It's also in [TunnelConfig.Warnings].
+	Message string `json:"message"`
+}
+
+// cipherWarning returns a warning if the Shadowsocks transport of the config, or the TCP transport
+// of a tcpudp config, uses a deprecated cipher name.
+func cipherWarning(transportConfigText string) *CipherWarning {
+	ssConfig := transportShadowsocksConfig(transportConfigText)
+	if ssConfig == nil {
+		return nil
+	}
+	name, deprecated := deprecatedCipherNames[strings.ToUpper(ssConfig.Cipher)]
+	if !deprecated {
+		return nil
+	}
+	return &CipherWarning{
+		Cipher:   ssConfig.Cipher,
+		Severity: deprecatedCipherSeverity,
+		Message:  fmt.Sprintf("cipher %q is deprecated, use %q instead", ssConfig.Cipher, name),
+	}
+}
+
+// checkInsecureCipher fails if the config uses one of the [InsecureShadowsocksCiphers], with the
+// cipher and its "high" severity in the Details. Without it, the error would just say that the
+// cipher is unsupported, which suggests that updating the app would help.
+func checkInsecureCipher(transportConfigText string) *platerrors.PlatformError {
+	ssConfig := transportShadowsocksConfig(transportConfigText)
+	if ssConfig == nil {
+		return nil
+	}
+	cipher := strings.ToLower(ssConfig.Cipher)
+	if !slices.Contains(InsecureShadowsocksCiphers, cipher) {
+		return nil
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("cipher %q is insecure and not supported, ask the provider for an AEAD cipher like \"chacha20-ietf-poly1305\"", cipher),
+		Details: platerrors.InvalidConfigDetails{
+			Field:  "cipher",
+			Reason: platerrors.ReasonInsecureCipher,
+			Extra:  platerrors.ErrorDetails{"cipher": cipher, "severity": insecureCipherSeverity},
+		}.ToErrorDetails(),
+	}
+}
+
+// transportShadowsocksConfig is [shadowsocksNodeConfig] for a transport config text.
+func transportShadowsocksConfig(transportConfigText string) *config.ShadowsocksConfig {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return nil
+	}
+	return shadowsocksNodeConfig(node)
+}
+
+// shadowsocksNodeConfig returns the Shadowsocks config of the node, or of the TCP transport of a
+// tcpudp node, as written, even if the cipher isn't supported. It returns nil for other transports.
+func shadowsocksNodeConfig(node config.ConfigNode) *config.ShadowsocksConfig {
+	if typed, ok := node.(map[string]any); ok {
+		switch typed[config.ConfigTypeKey] {
+		case nil, "shadowsocks":
+		case "tcpudp":
+			return shadowsocksNodeConfig(typed["tcp"])
+		default:
+			return nil
+		}
+	}
+	ssConfig, err := config.ParseShadowsocksConfig(node)
+	if err != nil {
+		return nil
+	}
+	return ssConfig
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_InsecureCipher(t *testing.T) {
+	userInfo := base64.RawURLEncoding.EncodeToString([]byte("rc4-md5:SECRET"))
+	for _, input := range []string{
+		"ss://" + userInfo + "@example.com:4321/",
+		`{"server": "example.com", "server_port": 4321, "method": "RC4-MD5", "password": "SECRET"}`,
+		`
+transport:
+  $type: tcpudp
+  tcp: {$type: shadowsocks, endpoint: example.com:4321, cipher: table, secret: SECRET}
+  udp: {$type: shadowsocks, endpoint: example.com:4321, cipher: table, secret: SECRET}`,
+	} {
+		// The SDK can't create the insecure ciphers, so the parse fails with the cipher and severity.
+		result := doParseTunnelConfig(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code, input)
+		require.Equal(t, "cipher", result.Error.Details["field"], input)
+		require.Equal(t, platerrors.ReasonInsecureCipher, result.Error.Details["reason"], input)
+		require.Equal(t, "high", result.Error.Details["severity"], input)
+		require.Contains(t, InsecureShadowsocksCiphers, result.Error.Details["cipher"], input)
+	}
+}
+
+func TestNewClient_InsecureCipher(t *testing.T) {
+	userInfo := base64.RawURLEncoding.EncodeToString([]byte("rc4-md5:SECRET"))
+	for _, transportConfig := range []string{
+		"ss://" + userInfo + "@example.com:4321/",
+		`{$type: tcpudp, tcp: {$type: shadowsocks, endpoint: example.com:4321, cipher: table, secret: SECRET}, udp: {$type: shadowsocks, endpoint: example.com:4321, cipher: table, secret: SECRET}}`,
+	} {
+		result := NewClient(transportConfig)
+		require.NotNil(t, result.Error, transportConfig)
+		require.Equal(t, platerrors.ReasonInsecureCipher, result.Error.Details["reason"], transportConfig)
+		require.Equal(t, "high", result.Error.Details["severity"], transportConfig)
+	}
+}
+
+func Test_doParseTunnelConfig_DeprecatedCipher(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp: {$type: shadowsocks, endpoint: example.com:4321, cipher: AEAD_AES_128_GCM, secret: SECRET}
+  udp: {$type: shadowsocks, endpoint: example.com:4321, cipher: AEAD_AES_128_GCM, secret: SECRET}`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "aes-128-gcm", response.Cipher)
+	require.Equal(t, &CipherWarning{
+		Cipher:   "AEAD_AES_128_GCM",
+		Severity: "low",
+		Message:  `cipher "AEAD_AES_128_GCM" is deprecated, use "aes-128-gcm" instead`,
+	}, response.CipherWarning)
+	require.Equal(t, []string{response.CipherWarning.Message}, response.Warnings)
+}
+
+func Test_doParseTunnelConfig_AEADCipher(t *testing.T) {
+	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "chacha20-ietf-poly1305", response.Cipher)
+	require.Empty(t, response.Warnings)
+	require.Nil(t, response.CipherWarning)
+}
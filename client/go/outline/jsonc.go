@@ -0,0 +1,72 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import "strings"
+
+// isJSONObject returns whether the config input is a JSON object, like the legacy Shadowsocks
+// JSON, rather than YAML.
+func isJSONObject(input string) bool {
+	return strings.HasPrefix(input, "{")
+}
+
+// stripJSONComments removes the // and /* */ comments of "JSON with comments", as emitted by some
+// third-party tools, which the YAML parser rejects. Comment markers inside strings, like the slashes
+// of URLs, are kept. Each comment is replaced with a space, or the newlines it spans, so that the
+// positions in parse errors still match the lines of the input.
+func stripJSONComments(input string) string {
+	var out strings.Builder
+	out.Grow(len(input))
+	// quote is the delimiter of the string we're in, or 0 outside strings.
+	var quote byte
+	for i := 0; i < len(input); i++ {
+		c := input[i]
+		switch {
+		case quote != 0:
+			out.WriteByte(c)
+			if c == '\\' && quote == '"' && i+1 < len(input) {
+				i++
+				out.WriteByte(input[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			out.WriteByte(c)
+		case strings.HasPrefix(input[i:], "//"):
+			out.WriteByte(' ')
+			end := strings.IndexByte(input[i:], '\n')
+			if end == -1 {
+				return out.String()
+			}
+			// Keep the newline, which ends the comment.
+			i += end - 1
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end == -1 {
+				// Unterminated comment. Let the parser report the error on what's left.
+				out.WriteString(input[i:])
+				return out.String()
+			}
+			comment := input[i : i+2+end+2]
+			out.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			out.WriteByte(' ')
+			i += len(comment) - 1
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stripJSONComments(t *testing.T) {
+	for _, tc := range []struct {
+		input, expected string
+	}{
+		{`{"a": 1} // trailing`, `{"a": 1}  `},
+		{"{\n  // line\n  \"a\": 1\n}", "{\n   \n  \"a\": 1\n}"},
+		{`{/* block */"a": 1}`, `{ "a": 1}`},
+		{"{/* two\nlines */\"a\": 1}", "{\n \"a\": 1}"},
+		{`{"url": "https://example.com/*path*/"}`, `{"url": "https://example.com/*path*/"}`},
+		{`{"a": "quote \" // still a string"}`, `{"a": "quote \" // still a string"}`},
+		{`{'a': 'it''s // a string'}`, `{'a': 'it''s // a string'}`},
+		{`{"a": 1 /* unterminated`, `{"a": 1 /* unterminated`},
+	} {
+		require.Equal(t, tc.expected, stripJSONComments(tc.input), tc.input)
+	}
+}
+
+func Test_doParseTunnelConfig_LegacyJSONWithComments(t *testing.T) {
+	result := doParseTunnelConfig(`{
+  // Exported by a third-party tool.
+  "server": "example.com", /* the relay */
+  "server_port": 4321,
+  "method": "chacha20-ietf-poly1305", // AEAD
+  /*
+   * The password.
+   */
+  "password": "SECRET"
+}`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Equal(t, "shadowsocks", response.TransportType)
+	require.NotContains(t, response.Transport, "//")
+	require.Equal(t, ConfigFormatLegacyJSON, detectConfigFormat(`{"password": "SECRET" // comment
+}`))
+}
+
+func Test_doParseTunnelConfig_JSONCommentMarkersInStrings(t *testing.T) {
+	// The slashes of the URL in the prefix must not be taken as a comment.
+	result := doParseTunnelConfig(`{
+  "server": "example.com", // comment
+  "server_port": 4321,
+  "method": "chacha20-ietf-poly1305",
+  "password": "SECRET//not-a-comment",
+  "prefix": "https://"
+}`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Contains(t, response.Transport, `"SECRET//not-a-comment"`)
+	require.Contains(t, response.Transport, `"https://"`)
+	require.True(t, response.Prefixed)
+}
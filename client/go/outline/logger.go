@@ -0,0 +1,42 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+type loggerContextKey struct{}
+
+// discardLogger is used when the context has no logger. It's disabled for the debug level, so the
+// log calls are cheap.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// ContextWithLogger returns a copy of ctx that carries logger, for the debug logs of the parsing of
+// tunnel configs and the creation of clients. The logs never include the config or its secrets.
+func ContextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// loggerFromContext returns the logger set with [ContextWithLogger], or a logger that discards
+// everything if there's none.
+func loggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return discardLogger
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseTunnelConfig_LoggerOmitsSecrets(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	for _, input := range []string{
+		"ss://chacha20-ietf-poly1305:LOGGED_PASSWORD_1@example.com:4321/",
+		`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "LOGGED_PASSWORD_2"}`,
+		`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: LOGGED_PASSWORD_3
+  udp: *shared`,
+	} {
+		result := ParseTunnelConfigWithOptions(input, &ParseOptions{Logger: logger})
+		require.Nil(t, result.Error, input)
+	}
+
+	require.Contains(t, logs.String(), "format=ss-url")
+	require.Contains(t, logs.String(), "format=legacy-json")
+	require.Contains(t, logs.String(), "format=advanced-yaml")
+	require.Contains(t, logs.String(), "created transport")
+	require.Contains(t, logs.String(), "example.com:4321")
+	require.NotContains(t, logs.String(), "LOGGED_PASSWORD")
+}
+
+func Test_ParseTunnelConfig_ContextLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := ContextWithLogger(context.Background(), logger)
+
+	result := ParseTunnelConfigContext(ctx, "ss://chacha20-ietf-poly1305:SECRET@127.0.0.1:4321/", nil)
+	require.Nil(t, result.Error)
+	require.Contains(t, logs.String(), "parsed tunnel config")
+	require.Contains(t, logs.String(), "127.0.0.1:4321")
+}
+
+func Test_loggerFromContext_NoLogger(t *testing.T) {
+	require.Same(t, discardLogger, loggerFromContext(context.Background()))
+	require.Same(t, discardLogger, loggerFromContext(ContextWithLogger(context.Background(), nil)))
+}
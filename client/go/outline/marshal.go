@@ -0,0 +1,157 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/goccy/go-yaml"
+)
+
+type shadowsocksYAML struct {
+	Type     string     `yaml:"$type"`
+	Endpoint any        `yaml:"endpoint"`
+	Cipher   string     `yaml:"cipher"`
+	Secret   yamlString `yaml:"secret"`
+	Prefix   yamlString `yaml:"prefix,omitempty"`
+	// PrefixPreset names a preset instead of the raw Prefix.
+	PrefixPreset string `yaml:"prefixPreset,omitempty"`
+}
+
+// yamlString is a string that is escaped if it has non-printable characters, since the YAML
+// encoder writes them as is, and they're not allowed in YAML documents.
+type yamlString string
+
+func (s yamlString) MarshalYAML() ([]byte, error) {
+	if strings.IndexFunc(string(s), func(r rune) bool { return !unicode.IsPrint(r) }) == -1 {
+		return yaml.Marshal(string(s))
+	}
+	// Go escape sequences are valid in YAML double-quoted scalars.
+	return []byte(strconv.Quote(string(s))), nil
+}
+
+// sharedTCPUDPYAML uses the same transport for TCP and UDP, so it's written only once.
+type sharedTCPUDPYAML struct {
+	Type string           `yaml:"$type"`
+	TCP  *shadowsocksYAML `yaml:"tcp,anchor=shared"`
+	UDP  *shadowsocksYAML `yaml:"udp,alias=shared"`
+}
+
+type tcpUDPYAML struct {
+	Type string           `yaml:"$type"`
+	TCP  *shadowsocksYAML `yaml:"tcp"`
+	UDP  *shadowsocksYAML `yaml:"udp"`
+}
+
+// MarshalTunnelConfig converts a ss:// link or a legacy JSON config to the equivalent tunnel
+// config in the advanced YAML format. It doesn't create a client, so the first hop is not resolved.
+func MarshalTunnelConfig(input string) *InvokeMethodResult {
+	text, perr := marshalTunnelConfig(input)
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	return &InvokeMethodResult{Value: text}
+}
+
+func marshalTunnelConfig(input string) (string, *platerrors.PlatformError) {
+	input = strings.TrimSpace(input)
+	if isJSONObject(input) {
+		input = stripJSONComments(input)
+	}
+	node, err := config.ParseConfigYAML(input)
+	if err != nil {
+		return "", newYAMLParseError(err)
+	}
+	if configMap, ok := node.(map[string]any); ok && (hasKey(configMap, "transport") || hasKey(configMap, "$type")) {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "only ss:// links and legacy JSON configs can be converted",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonUnsupported}.ToErrorDetails(),
+		}
+	}
+	transport, perr := marshalTransport(node)
+	if perr != nil {
+		return "", perr
+	}
+	tunnelConfig := map[string]any{"transport": transport}
+	if link, ok := node.(string); ok {
+		if name := shadowsocksLinkName(link); name != "" {
+			tunnelConfig["name"] = name
+		}
+	}
+	out, err := yaml.Marshal(tunnelConfig)
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to serialize the tunnel config",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return string(out), nil
+}
+
+// marshalTransport returns the transport of a ss:// link or legacy JSON config node, to be
+// serialized as YAML. Links with a SIP003 plugin get the same transport as when they're parsed.
+func marshalTransport(node config.ConfigNode) (any, *platerrors.PlatformError) {
+	if link, ok := node.(string); ok {
+		transport, perr := shadowsocksPluginTransport(link)
+		if perr != nil {
+			return nil, perr
+		}
+		if transport != nil {
+			return transport, nil
+		}
+	}
+	ssConfig, err := config.ParseShadowsocksConfig(node)
+	if err != nil {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid Shadowsocks config",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	endpoint, ok := ssConfig.Endpoint.(string)
+	if !ok {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "Shadowsocks endpoint must be an address",
+			Details: platerrors.InvalidConfigDetails{Field: "endpoint", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+
+	tcp := &shadowsocksYAML{
+		Type:         "shadowsocks",
+		Endpoint:     endpoint,
+		Cipher:       ssConfig.Cipher,
+		Secret:       yamlString(ssConfig.Secret),
+		Prefix:       yamlString(ssConfig.Prefix),
+		PrefixPreset: ssConfig.PrefixPreset,
+	}
+	var transport any
+	if ssConfig.Prefix == "" && ssConfig.PrefixPreset == "" {
+		transport = sharedTCPUDPYAML{Type: "tcpudp", TCP: tcp, UDP: tcp}
+	} else {
+		// The prefix of ss:// links and legacy configs only applies to TCP.
+		udp := *tcp
+		udp.Prefix, udp.PrefixPreset = "", ""
+		transport = tcpUDPYAML{Type: "tcpudp", TCP: tcp, UDP: &udp}
+	}
+	return transport, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalTunnelConfig_SSURL(t *testing.T) {
+	result := MarshalTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error)
+	require.Equal(t, `transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared
+`, result.Value)
+}
+
+func TestMarshalTunnelConfig_SSURLTag(t *testing.T) {
+	result := MarshalTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/#My%20Server")
+	require.Nil(t, result.Error)
+	require.Equal(t, `name: My Server
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared
+`, result.Value)
+}
+
+func TestMarshalTunnelConfig_SSURLPlugin(t *testing.T) {
+	link := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:443/?plugin=v2ray-plugin%3Btls%3Bhost%3Dcdn.example.com%3Bpath%3D%2Fws#Home"
+	result := MarshalTunnelConfig(link)
+	require.Nil(t, result.Error)
+	require.Equal(t, `name: Home
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: wss://cdn.example.com/ws
+      endpoint: example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled
+`, result.Value)
+
+	original, perr := ParseTunnelConfig(link)
+	require.Nil(t, perr)
+	converted, perr := ParseTunnelConfig(result.Value)
+	require.Nil(t, perr)
+	require.Equal(t, original.Transport, converted.Transport)
+
+	unsupported := MarshalTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:443/?plugin=obfs-local%3Bobfs%3Dhttp")
+	require.NotNil(t, unsupported.Error)
+	require.Equal(t, platerrors.ReasonUnsupported, unsupported.Error.Details["reason"])
+}
+
+func TestMarshalTunnelConfig_RoundTrip(t *testing.T) {
+	for _, input := range []string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+		"ss://chacha20-ietf-poly1305:%3A%23%20%22'@example.com:4321/?prefix=%16%03%01",
+		"ss://chacha20-ietf-poly1305:SECRET@example.com:4321/?prefixPreset=http-get",
+		`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "a: #b"}`,
+		"{\"server\": \"example.com\", // comment\n\"server_port\": 4321, \"method\": \"chacha20-ietf-poly1305\", /* */ \"password\": \"//b\"}",
+	} {
+		t.Run(input, func(t *testing.T) {
+			original := doParseTunnelConfig(input)
+			require.Nil(t, original.Error)
+			marshaled := MarshalTunnelConfig(input)
+			require.Nil(t, marshaled.Error)
+			converted := doParseTunnelConfig(marshaled.Value)
+			require.Nil(t, converted.Error)
+
+			var originalConfig, convertedConfig TunnelConfig
+			require.NoError(t, json.Unmarshal([]byte(original.Value), &originalConfig))
+			require.NoError(t, json.Unmarshal([]byte(converted.Value), &convertedConfig))
+			require.Equal(t, originalConfig.FirstHop, convertedConfig.FirstHop)
+		})
+	}
+}
+
+func TestMarshalTunnelConfig_PreservesCredentials(t *testing.T) {
+	result := MarshalTunnelConfig("ss://chacha20-ietf-poly1305:%3A%23%20%22'@example.com:4321/?prefix=%16%03%01")
+	require.Nil(t, result.Error)
+	require.Contains(t, result.Value, `secret: ":# \"'"`)
+	require.Contains(t, result.Value, `prefix: "\x16\x03\x01"`)
+}
+
+func TestMarshalTunnelConfig_PrefixPreset(t *testing.T) {
+	result := MarshalTunnelConfig("ss://chacha20-ietf-poly1305:SECRET@example.com:4321/?prefixPreset=http-get")
+	require.Nil(t, result.Error)
+	require.Equal(t, `transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+    prefixPreset: http-get
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+`, result.Value)
+}
+
+func TestMarshalTunnelConfig_AdvancedConfigRejected(t *testing.T) {
+	result := MarshalTunnelConfig("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/goccy/go-yaml"
+)
+
+// MergeTunnelConfig merges the overrides, a YAML or JSON mapping, into the base tunnel config in
+// the advanced YAML format, and returns the merged config as YAML, to be parsed as usual. It lets
+// providers ship a shared config and the credentials or hosts of each user separately.
+//
+// Mappings are merged key by key, recursively. Scalars and sequences in the overrides replace the
+// values of the base. Anchors are expanded, so an override of an aliased value only changes the
+// value at that path.
+func MergeTunnelConfig(base string, overrides string) (string, error) {
+	baseMap, perr := parseMergeMapping(base, "base")
+	if perr != nil {
+		return "", perr
+	}
+	overridesMap, perr := parseMergeMapping(overrides, "overrides")
+	if perr != nil {
+		return "", perr
+	}
+	out, err := yaml.Marshal(mergeConfigMaps(baseMap, overridesMap))
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to serialize the merged config",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return string(out), nil
+}
+
+// parseMergeMapping parses a YAML or JSON mapping, named by what for error messages.
+func parseMergeMapping(text string, what string) (map[string]any, *platerrors.PlatformError) {
+	text = strings.TrimSpace(text)
+	if isJSONObject(text) {
+		text = stripJSONComments(text)
+	}
+	node, err := config.ParseConfigYAML(text)
+	if err != nil {
+		return nil, newYAMLParseError(err)
+	}
+	mapping, ok := node.(map[string]any)
+	if !ok {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "the " + what + " config must be a mapping",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonWrongShape}.ToErrorDetails(),
+		}
+	}
+	return mapping, nil
+}
+
+// mergeConfigMaps returns a copy of base with overrides merged into it. The inputs are not modified.
+func mergeConfigMaps(base map[string]any, overrides map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(overrides))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		baseChild, baseIsMap := merged[key].(map[string]any)
+		overrideChild, overrideIsMap := value.(map[string]any)
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeConfigMaps(baseChild, overrideChild)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
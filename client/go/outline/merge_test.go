@@ -0,0 +1,120 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+const mergeTestBase = `
+name: Base
+tags: [base]
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+`
+
+func requireMergedConfig(t *testing.T, expected string, merged string) {
+	expectedNode, err := config.ParseConfigYAML(expected)
+	require.NoError(t, err)
+	mergedNode, err := config.ParseConfigYAML(merged)
+	require.NoError(t, err)
+	require.Equal(t, expectedNode, mergedNode)
+}
+
+func TestMergeTunnelConfig_Credentials(t *testing.T) {
+	merged, err := MergeTunnelConfig(mergeTestBase, `
+transport:
+  tcp: {secret: SECRET}
+  udp: {secret: SECRET}`)
+	require.NoError(t, err)
+	requireMergedConfig(t, `
+name: Base
+tags: [base]
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+`, merged)
+
+	result := doParseTunnelConfig(merged)
+	require.Nil(t, result.Error)
+}
+
+func TestMergeTunnelConfig_HostOverride(t *testing.T) {
+	merged, err := MergeTunnelConfig(mergeTestBase, `{
+  "name": "User",
+  "tags": ["user"],
+  "transport": {"tcp": {"endpoint": "user.example.com:443"}}
+}`)
+	require.NoError(t, err)
+	requireMergedConfig(t, `
+name: User
+tags: [user]
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: user.example.com:443
+    cipher: chacha20-ietf-poly1305
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+`, merged)
+}
+
+func TestMergeTunnelConfig_ReplacesSequencesAndTypes(t *testing.T) {
+	merged, err := MergeTunnelConfig(`
+tags: [a, b]
+transport:
+  - ss://one
+  - ss://two`, `
+tags: [c]
+transport: ss://three`)
+	require.NoError(t, err)
+	requireMergedConfig(t, "tags: [c]\ntransport: ss://three", merged)
+}
+
+func TestMergeTunnelConfig_NotAMapping(t *testing.T) {
+	_, err := MergeTunnelConfig(mergeTestBase, "- secret: SECRET")
+	var perr *platerrors.PlatformError
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, platerrors.InvalidConfig, perr.Code)
+	require.Equal(t, "the overrides config must be a mapping", perr.Message)
+
+	_, err = MergeTunnelConfig("ss://SECRET@example.com:4321", "name: User")
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, "the base config must be a mapping", perr.Message)
+}
@@ -40,11 +40,32 @@ const (
 	//  - Output: the content in raw string of the fetched resource
 	MethodFetchResource = "FetchResource"
 
+	// GetTransportTypes lists the transport types of the advanced YAML config format, with their fields.
+	//  - Input: null
+	//  - Output: a JSON array of TransportTypeDescriptor
+	MethodGetTransportTypes = "GetTransportTypes"
+
+	// GetTunnelConfigSchema returns the JSON Schema of the advanced YAML config format.
+	//  - Input: null
+	//  - Output: the JSON Schema document
+	MethodGetTunnelConfigSchema = "GetTunnelConfigSchema"
+
+	// MarshalTunnelConfig converts a ss:// link or legacy JSON config to the advanced YAML format.
+	//  - Input: the ss:// link or legacy JSON config text
+	//  - Output: the equivalent tunnel config in YAML
+	MethodMarshalTunnelConfig = "MarshalTunnelConfig"
+
 	// Parses the TunnelConfig and extracts the first hop or provider error as needed.
-	//  - Input: the transport config text
+	//  - Input: the transport config text, an https:// URL to fetch it from, or a file:// URI to read it from
 	//  - Output: the TunnelConfigJson that Typescript needs
 	MethodParseTunnelConfig = "ParseTunnelConfig"
 
+	// ResolveFirstHop extracts the first hop of the TunnelConfig stream dialer, without creating the
+	// packet listener.
+	//  - Input: the transport config text
+	//  - Output: the host:port of the first hop
+	MethodResolveFirstHop = "ResolveFirstHop"
+
 	// SetVPNStateChangeListener sets a callback to be invoked when the VPN state changes.
 	//
 	// We recommend the caller to set this listener at app startup to catch all VPN state changes.
@@ -54,6 +75,11 @@ const (
 	//  - Input: A callback token string.
 	//  - Output: null
 	MethodSetVPNStateChangeListener = "SetVPNStateChangeListener"
+
+	// ValidateTunnelConfig checks whether the TunnelConfig is valid without connecting to it.
+	//  - Input: the transport config text
+	//  - Output: a JSON object with the resolved first hops
+	MethodValidateTunnelConfig = "ValidateTunnelConfig"
 )
 
 // InvokeMethodResult represents the result of an InvokeMethod call.
@@ -87,15 +113,30 @@ func InvokeMethod(method string, input string) *InvokeMethodResult {
 			Error: platerrors.ToPlatformError(err),
 		}
 
+	case MethodGetTransportTypes:
+		return marshalInvokeMethodResult(TransportTypes())
+
+	case MethodGetTunnelConfigSchema:
+		return &InvokeMethodResult{Value: TunnelConfigSchema()}
+
+	case MethodMarshalTunnelConfig:
+		return MarshalTunnelConfig(input)
+
 	case MethodParseTunnelConfig:
 		return doParseTunnelConfig(input)
 
+	case MethodResolveFirstHop:
+		return ResolveFirstHop(input)
+
 	case MethodSetVPNStateChangeListener:
 		err := setVPNStateChangeListener(input)
 		return &InvokeMethodResult{
 			Error: platerrors.ToPlatformError(err),
 		}
 
+	case MethodValidateTunnelConfig:
+		return ValidateTunnelConfig(input)
+
 	default:
 		return &InvokeMethodResult{Error: &platerrors.PlatformError{
 			Code:    platerrors.InternalError,
@@ -0,0 +1,83 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// ConfigFormat identifies the format of a tunnel config input, as returned by [ClassifyConfig]
+// and reported in [ParseEvent].
+type ConfigFormat = string
+
+// Formats of the tunnel config input.
+const (
+	ConfigFormatShadowsocksURL ConfigFormat = "ss-url"
+	ConfigFormatLegacyJSON     ConfigFormat = "legacy-json"
+	ConfigFormatAdvancedYAML   ConfigFormat = "advanced-yaml"
+	ConfigFormatHTTPS          ConfigFormat = "https-url"
+	ConfigFormatFile           ConfigFormat = "file-uri"
+	// ConfigFormatProviderError is an advanced YAML config with the error of a provider instead
+	// of a transport. It's only returned by [ClassifyConfig]: parses report it as ConfigFormatAdvancedYAML.
+	ConfigFormatProviderError ConfigFormat = "provider-error"
+	// ConfigFormatUnknown means the input is not recognized.
+	ConfigFormatUnknown ConfigFormat = ""
+)
+
+// ParseEvent describes a parse of a tunnel config, for telemetry. It never includes the config, so
+// it's free of secrets.
+type ParseEvent struct {
+	// Format is one of the ConfigFormat constants, or empty if the input is not recognized.
+	Format string
+	// TransportType is the kind of the outermost transport, e.g. "shadowsocks". It's empty on failure.
+	TransportType string
+	// Duration is the time the parse took, including the creation of the client.
+	Duration time.Duration
+	// ErrorCode is the code of the error, or empty on success.
+	ErrorCode platerrors.ErrorCode
+}
+
+var parseObserver struct {
+	mu       sync.Mutex
+	observer func(ParseEvent)
+}
+
+// SetParseObserver registers a function that is called after each parse of a tunnel config, with
+// its [ParseEvent]. A nil observer, the default, disables the events. The observer is called from
+// the goroutine of the parse, so it must be safe for concurrent use and return quickly.
+func SetParseObserver(observer func(ParseEvent)) {
+	parseObserver.mu.Lock()
+	defer parseObserver.mu.Unlock()
+	parseObserver.observer = observer
+}
+
+func getParseObserver() func(ParseEvent) {
+	parseObserver.mu.Lock()
+	defer parseObserver.mu.Unlock()
+	return parseObserver.observer
+}
+
+// detectConfigFormat returns the ConfigFormat constant of the tunnel config input, or an empty
+// string if it's not recognized.
+func detectConfigFormat(input string) string {
+	format, _ := ClassifyConfig(input)
+	if format == ConfigFormatProviderError {
+		return ConfigFormatAdvancedYAML
+	}
+	return format
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SetParseObserver(t *testing.T) {
+	var mu sync.Mutex
+	var events []ParseEvent
+	SetParseObserver(func(event ParseEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	t.Cleanup(func() { SetParseObserver(nil) })
+
+	doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	doParseTunnelConfig(`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "SECRET"}`)
+	doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`)
+	doParseTunnelConfig("transport: ss://invalid")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, events, 4)
+	for _, event := range events {
+		require.Positive(t, event.Duration)
+		require.NotContains(t, fmt.Sprintf("%+v", event), "SECRET")
+	}
+	require.Equal(t, ConfigFormatShadowsocksURL, events[0].Format)
+	require.Equal(t, "shadowsocks", events[0].TransportType)
+	require.Empty(t, events[0].ErrorCode)
+	require.Equal(t, ConfigFormatLegacyJSON, events[1].Format)
+	require.Equal(t, "shadowsocks", events[1].TransportType)
+	require.Equal(t, ConfigFormatAdvancedYAML, events[2].Format)
+	require.Equal(t, "tcpudp", events[2].TransportType)
+	require.Equal(t, ConfigFormatAdvancedYAML, events[3].Format)
+	require.Empty(t, events[3].TransportType)
+	require.Equal(t, platerrors.InvalidConfig, events[3].ErrorCode)
+}
+
+func Test_SetParseObserver_Concurrent(t *testing.T) {
+	t.Cleanup(func() { SetParseObserver(nil) })
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetParseObserver(func(ParseEvent) {})
+			SetParseObserver(nil)
+		}()
+		go func() {
+			defer wg.Done()
+			result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+			require.Nil(t, result.Error)
+		}()
+	}
+	wg.Wait()
+}
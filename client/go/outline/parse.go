@@ -15,27 +15,335 @@
 package outline
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+	"unsafe"
 
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/Jigsaw-Code/outline-sdk/transport/shadowsocks"
 	"github.com/goccy/go-yaml"
 	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
 )
 
 type parseTunnelConfigRequest struct {
-	Transport ast.Node
-	Error     *struct {
+	// Version is the version of the format, 1 if absent. Older versions are migrated before parsing.
+	Version int
+	Name    string
+	Tags    []string
+	// Region and Provider are display labels, like the datacenter of the server and its operator.
+	Region           string
+	Provider         string
+	ConnectTimeoutMs int                  `yaml:"connectTimeoutMs"`
+	AddressFamily    config.AddressFamily `yaml:"addressFamily"`
+	// Enabled is false if the provider disabled the config. Defaults to true.
+	Enabled *bool
+	// Resolver overrides the system resolver to resolve the first hop.
+	Resolver *config.ResolverConfig
+	// ProbeOrder is the path that the connectivity probes try first, "tcp" or "udp".
+	ProbeOrder string `yaml:"probeOrder"`
+	// TunnelDNS is the resolver of the DNS queries that go over the tunnel.
+	TunnelDNS string `yaml:"tunnelDns"`
+	// HealthCheckURL is a URL that [Client.CheckHealth] fetches through the tunnel.
+	HealthCheckURL string `yaml:"healthCheckUrl"`
+	// BindAddress is the local address of the UDP sockets, for devices with several interfaces.
+	BindAddress string `yaml:"bindAddress"`
+	// FallbackFirstHop is a backup host:port for when the first hop host fails to resolve, or the
+	// connectivity probes fail to connect to it.
+	FallbackFirstHop string `yaml:"fallbackFirstHop"`
+	// HostIPOverride maps first hop host names to the IP to connect to instead of resolving them.
+	HostIPOverride map[string]string `yaml:"hostIpOverride"`
+	// KeepaliveSeconds is the interval of the TCP keepalive probes, for NATs that drop idle
+	// connections. Zero or absent keeps the default of the Go dialer, currently 15 seconds.
+	KeepaliveSeconds int `yaml:"keepaliveSeconds"`
+	// Transports are named transports that the transport can refer to with {$ref: name}.
+	Transports map[string]any
+	Transport  ast.Node
+	Error      *struct {
 		Message string
 		Details string
+		// Code identifies the error for the app, like "unauthorized". See providerErrorCodes.
+		Code string
+		// RetryAfter is the number of seconds the client should wait before fetching the config again.
+		RetryAfter *float64 `yaml:"retryAfter"`
 	}
 }
 
-// tunnelConfigJson must match the definition in config.ts.
-type tunnelConfigJson struct {
-	FirstHop  string `json:"firstHop"`
-	Transport string `json:"transport"`
+// firstHopsJson is the result of [ValidateTunnelConfig].
+type firstHopsJson struct {
+	FirstHop       string `json:"firstHop"`
+	StreamFirstHop string `json:"streamFirstHop"`
+	PacketFirstHop string `json:"packetFirstHop"`
+}
+
+// TunnelConfig is the result of parsing a tunnel config. It's returned as is by [ParseTunnelConfig]
+// and as JSON by [MethodParseTunnelConfig], so it must match the TunnelConfigJson definition in config.ts.
+type TunnelConfig struct {
+	// FirstHop is the first hop shared by the stream and packet paths. It's empty if they differ.
+	// It's the displayFirstHop of the transport instead, if set, since that's the hop users know.
+	FirstHop string `json:"firstHop"`
+	// StreamFirstHop and PacketFirstHop are the first hops of each path, which may differ on split transports.
+	// They're empty if the path has several first hops.
+	StreamFirstHop string `json:"streamFirstHop"`
+	PacketFirstHop string `json:"packetFirstHop"`
+	// StreamFirstHops and PacketFirstHops list all the first hops of each path, for transports that
+	// connect to several relays at once.
+	StreamFirstHops []string `json:"streamFirstHops,omitempty"`
+	PacketFirstHops []string `json:"packetFirstHops,omitempty"`
+	// Hopless is true if the transport reports no first hop on either path, like a test transport
+	// that only connects to loopback. The first hop fields are all empty then, so there's no server
+	// to show.
+	Hopless bool `json:"hopless,omitempty"`
+	// UDPSupported is false if the transport doesn't relay UDP, in which case PacketFirstHop is empty.
+	UDPSupported bool `json:"udpSupported"`
+	// UDPOverTCP is true if the packets are tunneled over a stream, like a WebSocket. The device then
+	// only sends TCP traffic, and FirstHop is the stream hop even if the packet hop differs.
+	UDPOverTCP bool `json:"udpOverTcp,omitempty"`
+	// MaxPacketSize is the largest UDP payload that goes through the tunnel without fragmentation,
+	// on a network with the usual MTU of 1500 bytes. It's 0 if unknown.
+	MaxPacketSize int `json:"maxPacketSize,omitempty"`
+	// ProbeOrder is the path that the connectivity probes try first, "tcp" or "udp", if set in the config.
+	ProbeOrder string `json:"probeOrder,omitempty"`
+	// HealthCheckURL is the URL that [Client.CheckHealth] fetches through the tunnel, if set in the config.
+	HealthCheckURL string `json:"healthCheckUrl,omitempty"`
+	Transport      string `json:"transport"`
+	// ConfigID is the hex SHA-256 digest of the normalized transport text. It's stable for the
+	// same transport and doesn't expose the credentials.
+	ConfigID string `json:"configId"`
+	// TransportType is the kind of the outermost transport, e.g. "shadowsocks" or "tcpudp".
+	TransportType string `json:"transportType,omitempty"`
+	// Cipher and KeyBytes describe the encryption of Shadowsocks transports. They never include the secret.
+	Cipher   string `json:"cipher,omitempty"`
+	KeyBytes int    `json:"keyBytes,omitempty"`
+	// Prefixed is true if the Shadowsocks connections start with a prefix, to look like another protocol.
+	Prefixed bool `json:"prefixed,omitempty"`
+	// FirstHopIsHostname is true if a first hop has a hostname rather than an IP literal, so the
+	// tunnel depends on DNS to connect.
+	FirstHopIsHostname bool `json:"firstHopIsHostname,omitempty"`
+	// FirstHopAddresses lists the IP addresses of the first hops, if requested with
+	// [ParseOptions.ResolveFirstHopAddresses]. Otherwise, it lists the IPs of the first hops set in
+	// the hostIpOverride of the config, if any.
+	FirstHopAddresses []string `json:"firstHopAddresses,omitempty"`
+	// Summary describes the layers of the transport and the first hop, as in
+	// "Shadowsocks over WebSocket/TLS (example.com:443)". It never includes secrets.
+	Summary string `json:"summary,omitempty"`
+	// Layers lists the layers of the stream path, from the one that connects to the first hop
+	// inward, with their endpoints. It never includes secrets.
+	Layers []TransportLayer `json:"layers,omitempty"`
+	// Fragmented is true if the transport has a layer that fragments the stream, like split or tlsfrag.
+	Fragmented bool `json:"fragmented,omitempty"`
+	// Capabilities tells which features the transport supports, derived from its layers.
+	Capabilities TransportCapabilities `json:"capabilities"`
+	// SelectedTransport is the index of the transport picked from a transport list.
+	SelectedTransport *int `json:"selectedTransport,omitempty"`
+	// Candidates lists every entry of a transport list, if requested with [ParseOptions.ListCandidates].
+	Candidates []TransportCandidate `json:"candidates,omitempty"`
+	// Name and Tags are the optional label and tags of the tunnel config, for display only.
+	Name string   `json:"name,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+	// Region and Provider are the optional region and provider labels of the tunnel config, for
+	// display only.
+	Region   string `json:"region,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	// Warnings lists non-fatal issues with the config, like deprecated fields.
+	Warnings []string `json:"warnings,omitempty"`
+	// CipherWarning is set if the Shadowsocks cipher is a deprecated name. The insecure ciphers
+	// fail the parse instead.
+	CipherWarning *CipherWarning `json:"cipherWarning,omitempty"`
+	// FallbackFirstHopUsed is true if the first hop failed to resolve, so the fallbackFirstHop of
+	// the config was used instead. The first hop fields report the fallback then.
+	FallbackFirstHopUsed bool `json:"fallbackFirstHopUsed,omitempty"`
+	// Format is the format the config was parsed as: [ConfigFormatShadowsocksURL],
+	// [ConfigFormatLegacyJSON] or [ConfigFormatAdvancedYAML]. Remote and file configs report the
+	// format of their content.
+	Format ConfigFormat `json:"format"`
+}
+
+// defaultConnectTimeout bounds the creation of the client, including the first hop resolution,
+// if the config doesn't set connectTimeoutMs.
+const defaultConnectTimeout = 10 * time.Second
+
+// defaultMaxTunnelConfigSize is the default of [SetMaxTunnelConfigSize].
+const defaultMaxTunnelConfigSize = 64 * 1024
+
+// defaultMaxTunnelConfigDepth is the default of [SetMaxTunnelConfigDepth]. Real configs nest a
+// few levels per transport layer, so it leaves plenty of room.
+const defaultMaxTunnelConfigDepth = 64
+
+var maxTunnelConfigSize atomic.Int64
+var maxTunnelConfigDepth atomic.Int64
+
+func init() {
+	maxTunnelConfigSize.Store(defaultMaxTunnelConfigSize)
+	maxTunnelConfigDepth.Store(defaultMaxTunnelConfigDepth)
+}
+
+// SetMaxTunnelConfigSize sets the maximum size in bytes of the tunnel configs to parse, to bound the
+// memory used by the parser on untrusted input. It applies to the input, and to the config it
+// expands to, like a fetched config. A non-positive size removes the limit. The default is 64 KiB.
+func SetMaxTunnelConfigSize(size int) {
+	maxTunnelConfigSize.Store(int64(size))
+}
+
+// checkTunnelConfigSize returns an error if the config is larger than [SetMaxTunnelConfigSize].
+func checkTunnelConfigSize(input string) *platerrors.PlatformError {
+	return checkTunnelConfigLength(len(input))
+}
+
+// checkTunnelConfigLength is like [checkTunnelConfigSize], given the size of the config.
+func checkTunnelConfigLength(size int) *platerrors.PlatformError {
+	maxSize := maxTunnelConfigSize.Load()
+	if maxSize <= 0 || int64(size) <= maxSize {
+		return nil
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("config exceeds the maximum size of %d bytes", maxSize),
+		Details: platerrors.InvalidConfigDetails{
+			Reason: platerrors.ReasonTooLarge,
+			Extra:  platerrors.ErrorDetails{"maxSize": maxSize, "size": size},
+		}.ToErrorDetails(),
+	}
+}
+
+// SetMaxTunnelConfigDepth sets the maximum nesting of mappings and sequences in the YAML and JSON
+// tunnel configs to parse, so that maliciously nested input can't exhaust the stack of the decoder.
+// A non-positive depth removes the limit. The default is 64.
+func SetMaxTunnelConfigDepth(depth int) {
+	maxTunnelConfigDepth.Store(int64(depth))
+}
+
+// checkTunnelConfigDepth returns an error if the YAML input nests deeper than
+// [SetMaxTunnelConfigDepth]. It's checked on the syntax tree, before decoding the input. Syntax
+// errors are left to the decoder, which reports them better.
+func checkTunnelConfigDepth(input string) *platerrors.PlatformError {
+	maxDepth := maxTunnelConfigDepth.Load()
+	if maxDepth <= 0 {
+		return nil
+	}
+	file, err := parser.ParseBytes([]byte(input), 0)
+	if err != nil {
+		return nil
+	}
+	for _, doc := range file.Docs {
+		if exceedsYAMLDepth(doc.Body, int(maxDepth)) {
+			return &platerrors.PlatformError{
+				Code:    platerrors.InvalidConfig,
+				Message: fmt.Sprintf("config exceeds the maximum nesting depth of %d", maxDepth),
+				Details: platerrors.InvalidConfigDetails{
+					Reason: platerrors.ReasonTooDeep,
+					Extra:  platerrors.ErrorDetails{"maxDepth": maxDepth},
+				}.ToErrorDetails(),
+			}
+		}
+	}
+	return nil
+}
+
+// exceedsYAMLDepth tells whether the node nests more than maxDepth mappings and sequences. It stops
+// descending past maxDepth, so it never recurses deeper than the limit.
+func exceedsYAMLDepth(node ast.Node, maxDepth int) bool {
+	switch typed := node.(type) {
+	case *ast.MappingNode:
+		if maxDepth == 0 {
+			return true
+		}
+		for _, entry := range typed.Values {
+			if exceedsYAMLDepth(entry, maxDepth) {
+				return true
+			}
+		}
+	case *ast.MappingValueNode:
+		if maxDepth == 0 {
+			return true
+		}
+		// A block mapping with a single entry is parsed as the entry alone.
+		return exceedsYAMLDepth(typed.Key, maxDepth-1) || exceedsYAMLDepth(typed.Value, maxDepth-1)
+	case *ast.SequenceNode:
+		if maxDepth == 0 {
+			return true
+		}
+		for _, value := range typed.Values {
+			if exceedsYAMLDepth(value, maxDepth-1) {
+				return true
+			}
+		}
+	case *ast.AnchorNode:
+		return exceedsYAMLDepth(typed.Value, maxDepth)
+	case *ast.TagNode:
+		return exceedsYAMLDepth(typed.Value, maxDepth)
+	}
+	return false
+}
+
+// checkEmptyConfig returns an error if the trimmed config is empty, as when pasting whitespace,
+// which the YAML parser would otherwise report with a confusing error.
+func checkEmptyConfig(input string) *platerrors.PlatformError {
+	if input != "" {
+		return nil
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "config is empty",
+		Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonMissing}.ToErrorDetails(),
+	}
+}
+
+// errConnectTimeout is the cause of the context when the connect timeout expires.
+var errConnectTimeout = errors.New("connect timeout expired")
+
+// TransportCandidate describes an entry of a transport list. It must match the
+// TransportCandidateJson definition in config.ts.
+type TransportCandidate struct {
+	TransportType string `json:"transportType"`
+	// The first hop fields are like those of [TunnelConfig].
+	FirstHop        string                    `json:"firstHop,omitempty"`
+	StreamFirstHop  string                    `json:"streamFirstHop,omitempty"`
+	PacketFirstHop  string                    `json:"packetFirstHop,omitempty"`
+	StreamFirstHops []string                  `json:"streamFirstHops,omitempty"`
+	PacketFirstHops []string                  `json:"packetFirstHops,omitempty"`
+	Error           *platerrors.PlatformError `json:"error,omitempty"`
+}
+
+// unsupportedURLSchemes are share link schemes from other clients whose protocols this client
+// doesn't implement. They're recognized to give a clear error instead of a YAML parse failure.
+var unsupportedURLSchemes = map[string]string{
+	"vmess://":  "VMess",
+	"trojan://": "Trojan",
+}
+
+// checkUnsupportedURLScheme returns an error if the input is a link of unsupportedURLSchemes.
+func checkUnsupportedURLScheme(input string) *platerrors.PlatformError {
+	for scheme, protocol := range unsupportedURLSchemes {
+		if strings.HasPrefix(input, scheme) {
+			return &platerrors.PlatformError{
+				Code:    platerrors.InvalidConfig,
+				Message: fmt.Sprintf("%s links are not supported: the %s protocol is not available in Outline", scheme, protocol),
+				Details: platerrors.InvalidConfigDetails{
+					Reason: platerrors.ReasonUnsupported,
+					Extra:  platerrors.ErrorDetails{"scheme": strings.TrimSuffix(scheme, "://")},
+				}.ToErrorDetails(),
+			}
+		}
+	}
+	return nil
 }
 
 func hasKey[K comparable, V any](m map[K]V, key K) bool {
@@ -43,94 +351,1297 @@ func hasKey[K comparable, V any](m map[K]V, key K) bool {
 	return ok
 }
 
+// newYAMLParseError creates an [platerrors.InvalidConfig] error for a YAML parse failure.
+// If the position of the failure is known, it's reported in the "line" and "column" Details.
+func newYAMLParseError(err error) *platerrors.PlatformError {
+	message := fmt.Sprintf("failed to parse: %s", yaml.FormatError(err, false, false))
+	details := platerrors.InvalidConfigDetails{Reason: platerrors.ReasonSyntax, Extra: platerrors.ErrorDetails{}}
+	if tk := yamlErrorToken(err); tk != nil && tk.Position != nil {
+		details.Extra["line"] = tk.Position.Line
+		details.Extra["column"] = tk.Position.Column
+	}
+	// Pasted configs often repeat a key, like transport. Name it, rather than the parser position.
+	if key, ok := duplicateYAMLKey(err); ok {
+		message = fmt.Sprintf("duplicate key %q", key)
+		details.Field = key
+		details.Reason = platerrors.ReasonDuplicateKey
+		details.Extra["key"] = key
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: message,
+		Details: details.ToErrorDetails(),
+	}
+}
+
+// duplicateYAMLKey returns the repeated key if err is a goccy/go-yaml duplicate key error. The
+// parser reports them as syntax errors on the repeated key, and the decoder as [yaml.DuplicateKeyError].
+func duplicateYAMLKey(err error) (string, bool) {
+	var syntaxErr *yaml.SyntaxError
+	var duplicateKeyErr *yaml.DuplicateKeyError
+	switch {
+	case errors.As(err, &syntaxErr):
+		if syntaxErr.Token != nil && strings.HasPrefix(syntaxErr.Message, "mapping key ") && strings.Contains(syntaxErr.Message, " already defined at ") {
+			return syntaxErr.Token.Value, true
+		}
+	case errors.As(err, &duplicateKeyErr):
+		if duplicateKeyErr.Token != nil {
+			return duplicateKeyErr.Token.Value, true
+		}
+	}
+	return "", false
+}
+
+// checkTopLevelShape returns an error if the input is valid YAML, but not a mapping, like a list or a
+// bare scalar. That's usually a truncated copy or the wrong file, which deserves a clearer message
+// than the YAML type error.
+func checkTopLevelShape(input string) *platerrors.PlatformError {
+	var value any
+	if err := yaml.Unmarshal([]byte(input), &value); err != nil {
+		return nil
+	}
+	var found string
+	switch value.(type) {
+	case nil, map[string]any:
+		return nil
+	case []any:
+		found = "list"
+	default:
+		found = "scalar"
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("the config must be a mapping with a transport key, found a %s", found),
+		Details: platerrors.InvalidConfigDetails{
+			Reason: platerrors.ReasonWrongShape,
+			Extra:  platerrors.ErrorDetails{"found": found},
+		}.ToErrorDetails(),
+	}
+}
+
+// yamlErrorToken returns the token where a goccy/go-yaml error occurred, or nil if unknown.
+func yamlErrorToken(err error) *token.Token {
+	var syntaxErr *yaml.SyntaxError
+	var typeErr *yaml.TypeError
+	var overflowErr *yaml.OverflowError
+	var duplicateKeyErr *yaml.DuplicateKeyError
+	var unknownFieldErr *yaml.UnknownFieldError
+	var unexpectedNodeErr *yaml.UnexpectedNodeTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Token
+	case errors.As(err, &typeErr):
+		return typeErr.Token
+	case errors.As(err, &overflowErr):
+		return overflowErr.Token
+	case errors.As(err, &duplicateKeyErr):
+		return duplicateKeyErr.Token
+	case errors.As(err, &unknownFieldErr):
+		return unknownFieldErr.Token
+	case errors.As(err, &unexpectedNodeErr):
+		return unexpectedNodeErr.Token
+	default:
+		return nil
+	}
+}
+
+// decodeBase64Config decodes input if it's a standard or URL-safe base64 payload of a YAML mapping.
+// It returns false if input is not base64 or the decoded text doesn't parse as a mapping, so
+// plain configs that happen to look like base64 are left untouched.
+func decodeBase64Config(input string) (string, bool) {
+	encodings := []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding}
+	for _, encoding := range encodings {
+		decoded, err := encoding.DecodeString(input)
+		if err != nil || !utf8.Valid(decoded) {
+			continue
+		}
+		var yamlValue map[string]any
+		if err := yaml.Unmarshal(decoded, &yamlValue); err != nil || yamlValue == nil {
+			continue
+		}
+		return strings.TrimSpace(string(decoded)), true
+	}
+	return "", false
+}
+
+// ParseOptions configures optional behaviors of the tunnel config parser.
+// The zero value gives the default behavior of [MethodParseTunnelConfig].
+type ParseOptions struct {
+	// ExpandEnv replaces ${VAR} tokens in the input with values from the process environment.
+	// Use $${VAR} to keep a literal ${VAR}.
+	ExpandEnv bool
+	// ErrorOnUndefinedEnv fails the parse if ExpandEnv finds an undefined variable, instead of
+	// replacing it with an empty string.
+	ErrorOnUndefinedEnv bool
+	// RawTransport returns the transport of the advanced format as written in the input, comments
+	// included. By default, the transport is re-serialized, which keeps anchors and aliases but
+	// drops comments. Anchors used by the transport must be defined inside it.
+	RawTransport bool
+	// ListCandidates reports every entry of a transport list in the Candidates of the result,
+	// with its first hops or the error creating it. Note that it creates a client for each entry.
+	ListCandidates bool
+	// ResolveFirstHopAddresses reports the IP addresses of the first hops in the FirstHopAddresses of the
+	// result. Note that it resolves the first hop hosts again.
+	ResolveFirstHopAddresses bool
+	// Strict fails the parse of the advanced format if it has top-level keys that are not part of
+	// the format, to catch typos like "transprot". By default, unknown keys are ignored.
+	Strict bool
+	// WarningsAsErrors fails the parse if the config has warnings, like deprecated ciphers, for
+	// pipelines that validate the configs they ship. By default, warnings are reported in the
+	// result and the parse succeeds.
+	WarningsAsErrors bool
+
+	// NoResolve parses without any network access, for offline editing and validation. The first hop
+	// hosts are not resolved and are reported as written, https:// configs are rejected, and
+	// ResolveFirstHopAddresses is ignored.
+	NoResolve bool
+	// Logger receives debug logs of the format detection, the first hops and their resolution, with
+	// the secrets left out. A nil Logger uses the logger of the context, if set with
+	// [ContextWithLogger], or discards the logs.
+	Logger *slog.Logger
+
+	// streamOnly skips the creation of the packet listener. The packet fields of the result are empty.
+	streamOnly bool
+	// borrowedInput means that the input is a view of bytes owned by the caller, as in
+	// [ParseTunnelConfigBytes], so the parse copies the parts of it that it keeps.
+	borrowedInput bool
+}
+
 func doParseTunnelConfig(input string) *InvokeMethodResult {
-	var transportConfigText string
+	return ParseTunnelConfigWithOptions(input, nil)
+}
+
+// ParseTunnelConfig is like [MethodParseTunnelConfig], but returns the parsed [TunnelConfig] rather
+// than its JSON, for Go callers.
+func ParseTunnelConfig(input string) (*TunnelConfig, *platerrors.PlatformError) {
+	return observeParseTunnelConfig(context.Background(), input, ParseOptions{})
+}
+
+// ParseTunnelConfigBytes is like [ParseTunnelConfig], for callers that hold the config as bytes,
+// like the content of a subscription file. The bytes are parsed in place, without copying them to
+// a string, so they must not be modified until it returns. The result doesn't refer to them.
+func ParseTunnelConfigBytes(input []byte) (*TunnelConfig, *platerrors.PlatformError) {
+	if perr := checkTunnelConfigLength(len(input)); perr != nil {
+		return nil, perr
+	}
+	view := unsafe.String(unsafe.SliceData(input), len(input))
+	return observeParseTunnelConfig(context.Background(), view, ParseOptions{borrowedInput: true})
+}
+
+// ownInput returns the input of a parse, copied if it's borrowed from the caller, for the parts of
+// the parse that keep it, like the transport of an ss:// link and the client cache.
+func ownInput(input string, opts ParseOptions) string {
+	if opts.borrowedInput {
+		return strings.Clone(input)
+	}
+	return input
+}
+
+// ParseTunnelConfigWithOptions is like [MethodParseTunnelConfig], with the given options.
+// A nil options is the same as the zero [ParseOptions].
+func ParseTunnelConfigWithOptions(input string, options *ParseOptions) *InvokeMethodResult {
+	return ParseTunnelConfigContext(context.Background(), input, options)
+}
+
+// ParseTunnelConfigContext is like [ParseTunnelConfigWithOptions], but aborts when ctx is done.
+// A canceled ctx results in a [platerrors.OperationCanceled] error, and an expired deadline in a
+// [platerrors.OperationTimedOut] error.
+func ParseTunnelConfigContext(ctx context.Context, input string, options *ParseOptions) *InvokeMethodResult {
+	if options == nil {
+		options = &ParseOptions{}
+	}
+	tunnelConfig, perr := observeParseTunnelConfig(ctx, input, *options)
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	return marshalInvokeMethodResult(tunnelConfig)
+}
+
+// observeParseTunnelConfig is [parseTunnelConfig], reporting the parse to the observer set with
+// [SetParseObserver], if any.
+func observeParseTunnelConfig(ctx context.Context, input string, opts ParseOptions) (*TunnelConfig, *platerrors.PlatformError) {
+	observer := getParseObserver()
+	start := time.Now()
+	tunnelConfig, perr := parseTunnelConfig(ctx, input, opts)
+	if observer != nil {
+		event := ParseEvent{Format: detectConfigFormat(input), Duration: time.Since(start)}
+		if perr != nil {
+			event.ErrorCode = perr.Code
+		} else {
+			event.TransportType = tunnelConfig.TransportType
+		}
+		observer(event)
+	}
+	return tunnelConfig, perr
+}
+
+// ResolveFirstHop returns the first hop of the stream dialer of the input tunnel config, as a
+// host:port string. It's cheaper than [MethodParseTunnelConfig] since it skips the creation of the
+// packet listener, and it fails with the same errors.
+func ResolveFirstHop(input string) *InvokeMethodResult {
+	tunnelConfig, perr := parseTunnelConfig(context.Background(), input, ParseOptions{streamOnly: true})
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	return &InvokeMethodResult{Value: tunnelConfig.StreamFirstHop}
+}
 
+// unknownTunnelConfigKeys returns the sorted top-level keys of the advanced format config that
+// don't match a field of [parseTunnelConfigRequest].
+func unknownTunnelConfigKeys(configMap map[string]any) []string {
+	requestType := reflect.TypeFor[parseTunnelConfigRequest]()
+	knownKeys := make(map[string]bool, requestType.NumField())
+	for i := 0; i < requestType.NumField(); i++ {
+		knownKeys[yamlFieldName(requestType.Field(i))] = true
+	}
+	var unknownKeys []string
+	for key := range configMap {
+		if !knownKeys[key] {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
+	slices.Sort(unknownKeys)
+	return unknownKeys
+}
+
+// ParseTunnelConfigs parses each of the inputs independently, like [MethodParseTunnelConfig], and
+// returns the results in the same order. A failure only affects the result of its input.
+// The inputs share the client cache, so repeated transports are only created once.
+func ParseTunnelConfigs(inputs []string) []*InvokeMethodResult {
+	results := make([]*InvokeMethodResult, len(inputs))
+	for i, input := range inputs {
+		results[i] = doParseTunnelConfig(input)
+	}
+	return results
+}
+
+// ParseTunnelConfigDocuments parses each document of a multi-document YAML input, separated by
+// "---" lines, as an independent tunnel config, like [ParseTunnelConfigs]. The results are in the
+// order of the documents, skipping the empty ones. An input with a single document has a single
+// result, the same as [MethodParseTunnelConfig].
+func ParseTunnelConfigDocuments(input string) []*InvokeMethodResult {
+	documents := splitYAMLDocuments(input)
+	if len(documents) == 0 {
+		// Report the error of the empty input.
+		documents = []string{input}
+	}
+	return ParseTunnelConfigs(documents)
+}
+
+// splitYAMLDocuments splits the input at the "---" document markers, which start a line, and
+// removes the "..." end markers. Documents with only blank lines and comments are dropped.
+func splitYAMLDocuments(input string) []string {
+	var documents []string
+	var lines []string
+	flush := func() {
+		document := strings.Join(lines, "\n")
+		lines = nil
+		if !isEmptyYAMLDocument(document) {
+			documents = append(documents, document)
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(input, "\r\n", "\n"), "\n") {
+		switch {
+		case isYAMLMarker(line, "---"):
+			flush()
+			// Content may follow the marker on the same line.
+			if rest := strings.TrimSpace(line[3:]); rest != "" {
+				lines = append(lines, rest)
+			}
+		case isYAMLMarker(line, "..."):
+			flush()
+		default:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return documents
+}
+
+// isYAMLMarker returns whether line is the document marker, alone or followed by a space.
+func isYAMLMarker(line string, marker string) bool {
+	rest, found := strings.CutPrefix(line, marker)
+	return found && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// isEmptyYAMLDocument returns whether the document has only blank lines and comments.
+func isEmptyYAMLDocument(document string) bool {
+	for _, line := range strings.Split(document, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateTunnelConfig checks whether input is a valid tunnel config, without connecting to it.
+// It runs the same parsing and client construction as [MethodParseTunnelConfig]. On success,
+// the result Value is a JSON object with the resolved first hops.
+func ValidateTunnelConfig(input string) *InvokeMethodResult {
+	tunnelConfig, perr := parseTunnelConfig(context.Background(), input, ParseOptions{})
+	if perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	return marshalInvokeMethodResult(firstHopsJson{
+		FirstHop:       tunnelConfig.FirstHop,
+		StreamFirstHop: tunnelConfig.StreamFirstHop,
+		PacketFirstHop: tunnelConfig.PacketFirstHop,
+	})
+}
+
+// marshalInvokeMethodResult returns an [InvokeMethodResult] with value serialized as JSON.
+func marshalInvokeMethodResult(value any) *InvokeMethodResult {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return &InvokeMethodResult{
+			Error: &platerrors.PlatformError{
+				Code:    platerrors.InternalError,
+				Message: fmt.Sprintf("failed to serialize JSON response: %v", err),
+			},
+		}
+	}
+	return &InvokeMethodResult{
+		Value: string(valueBytes),
+	}
+}
+
+// parseTunnelConfig parses the tunnel config text and creates a [Client] to resolve its first hops.
+func parseTunnelConfig(ctx context.Context, input string, opts ParseOptions) (*TunnelConfig, *platerrors.PlatformError) {
+	// A config may list several transports to try in order. It's a single entry for all but the advanced format.
+	var transportConfigTexts []string
+	// displayFirstHops has the displayFirstHop of each transport config, or an empty string.
+	var displayFirstHops []string
+	// The display labels and tags, only available in the advanced format.
+	var name, region, provider string
+	var tags []string
+	// The format of the config, after fetching remote configs.
+	var format ConfigFormat
+	// The first hop to try if the first hops fail to resolve, only available in the advanced format.
+	var fallbackFirstHop string
+	connectTimeout := defaultConnectTimeout
+	clientOpts := clientOptions{streamOnly: opts.streamOnly, noResolve: opts.NoResolve}
+	if opts.Logger != nil {
+		ctx = ContextWithLogger(ctx, opts.Logger)
+	}
+	logger := loggerFromContext(ctx)
+
+	// Large inputs use a lot of memory in the YAML parser, so they're rejected before parsing.
+	if perr := checkTunnelConfigSize(input); perr != nil {
+		return nil, perr
+	}
+	// Text copied from Windows editors may have a byte order mark and CRLF line endings.
+	input = strings.TrimPrefix(input, "\ufeff")
+	input = strings.ReplaceAll(input, "\r\n", "\n")
 	input = strings.TrimSpace(input)
+	if perr := checkEmptyConfig(input); perr != nil {
+		return nil, perr
+	}
+	if opts.ExpandEnv {
+		var perr *platerrors.PlatformError
+		if input, perr = expandEnv(input, opts.ErrorOnUndefinedEnv); perr != nil {
+			return nil, perr
+		}
+	}
+	if !strings.HasPrefix(input, "ss://") {
+		// Some distribution channels can only carry opaque base64 strings.
+		if decoded, ok := decodeBase64Config(input); ok {
+			input = decoded
+		}
+	}
 	// Input may be one of:
 	// - ss:// link
 	// - Legacy Shadowsocks JSON (parsed as YAML)
 	// - New advanced YAML format
+	// Dynamic configs may be served by a provisioning endpoint, and desktop configs may be in a
+	// local file. The content goes through the regular parsing below, which handles the provider
+	// error envelope.
+	if strings.HasPrefix(input, "http://") {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "config URL must use https://",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonNotAllowed}.ToErrorDetails(),
+		}
+	}
+	if strings.HasPrefix(input, "https://") && opts.NoResolve {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "config URLs can't be fetched without network access",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonNotAllowed}.ToErrorDetails(),
+		}
+	}
+	if strings.HasPrefix(input, "https://") {
+		logger.DebugContext(ctx, "fetching tunnel config", "format", ConfigFormatHTTPS)
+		body, err := fetchTunnelConfig(ctx, tunnelConfigHTTPClient, ownInput(input, opts))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, newContextError(ctx.Err())
+			}
+			return nil, platerrors.ToPlatformError(err)
+		}
+		input = strings.TrimSpace(body)
+		opts.borrowedInput = false
+	} else if strings.HasPrefix(input, "file://") {
+		logger.DebugContext(ctx, "reading tunnel config file", "format", ConfigFormatFile)
+		content, err := readTunnelConfigFile(ownInput(input, opts))
+		if err != nil {
+			return nil, platerrors.ToPlatformError(err)
+		}
+		input = strings.TrimSpace(content)
+		opts.borrowedInput = false
+	}
+	// Environment variables and remote configs may have made the input larger.
+	if perr := checkTunnelConfigSize(input); perr != nil {
+		return nil, perr
+	}
+	// Or empty, like a remote config with no content.
+	if perr := checkEmptyConfig(input); perr != nil {
+		return nil, perr
+	}
+
+	if perr := checkUnsupportedURLScheme(input); perr != nil {
+		return nil, perr
+	}
 	if strings.HasPrefix(input, "ss://") {
-		// Legacy URL format. Input is the transport config.
-		transportConfigText = input
+		// Legacy URL format. Input is the transport config, unless it needs a plugin.
+		input = ownInput(input, opts)
+		format = ConfigFormatShadowsocksURL
+		logger.DebugContext(ctx, "detected tunnel config format", "format", format)
+		transportConfigText, perr := translateShadowsocksPlugin(input)
+		if perr != nil {
+			return nil, perr
+		}
+		transportConfigTexts = []string{transportConfigText}
+		name = shadowsocksLinkName(input)
 	} else {
+		if isJSONObject(input) {
+			// Legacy JSON may have comments, which aren't valid YAML.
+			input = stripJSONComments(input)
+		}
+		if perr := checkTunnelConfigDepth(input); perr != nil {
+			return nil, perr
+		}
 		var yamlValue map[string]any
 		if err := yaml.Unmarshal([]byte(input), &yamlValue); err != nil {
-			return &InvokeMethodResult{
-				Error: &platerrors.PlatformError{
-					Code:    platerrors.InvalidConfig,
-					Message: fmt.Sprintf("failed to parse: %s", err),
-				},
+			if perr := checkTopLevelShape(input); perr != nil {
+				return nil, perr
 			}
+			return nil, newYAMLParseError(err)
 		}
 
 		if hasKey(yamlValue, "transport") || hasKey(yamlValue, "error") {
 			// New format. Parse as tunnel config
-			tunnelConfig := parseTunnelConfigRequest{}
-			if err := yaml.Unmarshal([]byte(input), &tunnelConfig); err != nil {
-				return &InvokeMethodResult{
-					Error: &platerrors.PlatformError{
+			format = ConfigFormatAdvancedYAML
+			logger.DebugContext(ctx, "detected tunnel config format", "format", format)
+			if opts.Strict {
+				if unknownKeys := unknownTunnelConfigKeys(yamlValue); len(unknownKeys) > 0 {
+					return nil, &platerrors.PlatformError{
 						Code:    platerrors.InvalidConfig,
-						Message: fmt.Sprintf("failed to parse: %s", err),
-					},
+						Message: fmt.Sprintf("unknown top-level keys: %s", strings.Join(unknownKeys, ", ")),
+						Details: platerrors.InvalidConfigDetails{
+							Reason: platerrors.ReasonUnknownKeys,
+							Extra:  platerrors.ErrorDetails{"unknownKeys": unknownKeys},
+						}.ToErrorDetails(),
+					}
 				}
 			}
+			tunnelConfig := parseTunnelConfigRequest{}
+			if err := yaml.Unmarshal([]byte(input), &tunnelConfig); err != nil {
+				return nil, newYAMLParseError(err)
+			}
+			if perr := migrateTunnelConfig(&tunnelConfig); perr != nil {
+				return nil, perr
+			}
 
 			// Process provider error, if present.
 			if tunnelConfig.Error != nil {
 				platErr := &platerrors.PlatformError{
-					Code:    platerrors.ProviderError,
+					Code:    providerErrorCode(tunnelConfig.Error.Code),
 					Message: tunnelConfig.Error.Message,
 				}
-				if tunnelConfig.Error.Details != "" {
-					platErr.Details = map[string]any{
-						"details": tunnelConfig.Error.Details,
+				details := platerrors.ProviderErrorDetails{ProviderCode: tunnelConfig.Error.Code}
+				if retryAfter := tunnelConfig.Error.RetryAfter; retryAfter != nil && *retryAfter >= 0 {
+					details.RetryAfterSeconds = retryAfter
+				}
+				platErr.Details = providerErrorDetails(tunnelConfig.Error.Details, details)
+				return nil, platErr
+			}
+
+			if tunnelConfig.Enabled != nil && !*tunnelConfig.Enabled {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.ConfigDisabled,
+					Message: "the config is disabled",
+				}
+			}
+
+			if isEmptyNode(tunnelConfig.Transport) {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: "transport is required and must not be empty",
+					Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonMissing}.ToErrorDetails(),
+				}
+			}
+			name, tags = tunnelConfig.Name, tunnelConfig.Tags
+			region, provider = tunnelConfig.Region, tunnelConfig.Provider
+			if tunnelConfig.ConnectTimeoutMs < 0 {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: "connectTimeoutMs must not be negative",
+					Details: platerrors.InvalidConfigDetails{Field: "connectTimeoutMs", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+				}
+			}
+			if tunnelConfig.KeepaliveSeconds < 0 {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: "keepaliveSeconds must not be negative",
+					Details: platerrors.InvalidConfigDetails{Field: "keepaliveSeconds", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+				}
+			}
+			clientOpts.keepalive = time.Duration(tunnelConfig.KeepaliveSeconds) * time.Second
+			switch tunnelConfig.AddressFamily {
+			case "", config.AddressFamilyAuto, config.AddressFamilyIPv4, config.AddressFamilyIPv6:
+				clientOpts.addressFamily = tunnelConfig.AddressFamily
+			default:
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: fmt.Sprintf("addressFamily must be ipv4, ipv6 or auto, found %q", tunnelConfig.AddressFamily),
+					Details: platerrors.InvalidConfigDetails{Field: "addressFamily", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+				}
+			}
+			if tunnelConfig.Resolver != nil {
+				if err := tunnelConfig.Resolver.Validate(); err != nil {
+					return nil, &platerrors.PlatformError{
+						Code:    platerrors.InvalidConfig,
+						Message: "invalid resolver",
+						Details: platerrors.InvalidConfigDetails{Field: "resolver", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+						Cause:   platerrors.ToPlatformError(err),
 					}
 				}
-				return &InvokeMethodResult{Error: platErr}
+				clientOpts.resolver = *tunnelConfig.Resolver
+			}
+			switch tunnelConfig.ProbeOrder {
+			case "", probeOrderTCP, probeOrderUDP:
+				clientOpts.probeOrder = tunnelConfig.ProbeOrder
+			default:
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: fmt.Sprintf("probeOrder must be tcp or udp, found %q", tunnelConfig.ProbeOrder),
+					Details: platerrors.InvalidConfigDetails{Field: "probeOrder", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+				}
+			}
+			if tunnelConfig.TunnelDNS != "" {
+				tunnelDNS, perr := parseTunnelDNS(tunnelConfig.TunnelDNS)
+				if perr != nil {
+					return nil, perr
+				}
+				clientOpts.tunnelDNS = tunnelDNS
+			}
+			if tunnelConfig.HealthCheckURL != "" {
+				if perr := validateHealthCheckURL(tunnelConfig.HealthCheckURL); perr != nil {
+					return nil, perr
+				}
+				clientOpts.healthCheckURL = tunnelConfig.HealthCheckURL
+			}
+			if tunnelConfig.BindAddress != "" {
+				bindAddress, perr := parseBindAddress(tunnelConfig.BindAddress)
+				if perr != nil {
+					return nil, perr
+				}
+				clientOpts.bindAddress = bindAddress
+			}
+			if tunnelConfig.FallbackFirstHop != "" {
+				if perr := validateFallbackFirstHop(tunnelConfig.FallbackFirstHop); perr != nil {
+					return nil, perr
+				}
+				fallbackFirstHop = tunnelConfig.FallbackFirstHop
+				clientOpts.probeFallbackFirstHop = fallbackFirstHop
+			}
+			if len(tunnelConfig.HostIPOverride) > 0 {
+				hostIPOverride, perr := parseHostIPOverride(tunnelConfig.HostIPOverride)
+				if perr != nil {
+					return nil, perr
+				}
+				clientOpts.hostIPOverride = hostIPOverride
+			}
+			if tunnelConfig.ConnectTimeoutMs > 0 {
+				connectTimeout = time.Duration(tunnelConfig.ConnectTimeoutMs) * time.Millisecond
+			}
+
+			transportNode := tunnelConfig.Transport
+			normalize := normalizeTransportNode
+			if opts.RawTransport {
+				// The regular decoding drops comments, so we need to parse the input again.
+				var err error
+				if transportNode, err = parseTransportNodeWithComments(input); err != nil {
+					return nil, newYAMLParseError(err)
+				}
+				normalize = rawTransportNodeText
 			}
 
-			// Extract transport config as an opaque string.
-			transportConfigBytes, err := yaml.Marshal(tunnelConfig.Transport)
-			if err != nil {
-				return &InvokeMethodResult{
-					Error: &platerrors.PlatformError{
+			transportNode, perr := resolveTransportRefs(transportNode, yamlValue["transport"], tunnelConfig.Transports)
+			if perr != nil {
+				return nil, perr
+			}
+
+			// A sequence lists fallback transports in order of preference.
+			transportNodes := []ast.Node{transportNode}
+			if seq, ok := transportNode.(*ast.SequenceNode); ok {
+				transportNodes = seq.Values
+			}
+
+			// Extract transport configs as opaque strings.
+			for _, transportNode := range transportNodes {
+				displayFirstHop, perr := extractDisplayFirstHop(transportNode)
+				if perr != nil {
+					return nil, perr
+				}
+				displayFirstHops = append(displayFirstHops, displayFirstHop)
+				transportConfigText, err := normalize(transportNode)
+				if err != nil {
+					return nil, &platerrors.PlatformError{
 						Code:    platerrors.InvalidConfig,
 						Message: fmt.Sprintf("failed to normalize config: %s", err),
-					},
+						Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonSyntax}.ToErrorDetails(),
+					}
 				}
+				if perr := validateTransportText(transportConfigText); perr != nil {
+					return nil, perr
+				}
+				if perr := checkTransportConflicts(transportConfigText); perr != nil {
+					return nil, perr
+				}
+				transportConfigTexts = append(transportConfigTexts, transportConfigText)
 			}
-			transportConfigText = string(transportConfigBytes)
 		} else {
 			// Legacy JSON format. Input is the transport config.
-			transportConfigText = input
+			format = ConfigFormatLegacyJSON
+			logger.DebugContext(ctx, "detected tunnel config format", "format", format)
+			transportConfigTexts = []string{ownInput(input, opts)}
 		}
 	}
 
-	result := NewClient(transportConfigText)
-	if result.Error != nil {
-		return &InvokeMethodResult{
-			Error: result.Error,
+	// Non-fatal issues to nudge users to update their config. WarningsAsErrors fails on the
+	// warnings of any transport, but the response only reports those of the selected one.
+	var warnings []string
+	cipherWarnings := make([]*CipherWarning, len(transportConfigTexts))
+	for i, transportConfigText := range transportConfigTexts {
+		if cipherWarnings[i] = cipherWarning(transportConfigText); cipherWarnings[i] != nil {
+			warnings = append(warnings, cipherWarnings[i].Message)
 		}
 	}
-	streamFirstHop := result.Client.sd.ConnectionProviderInfo.FirstHop
-	packetFirstHop := result.Client.pl.ConnectionProviderInfo.FirstHop
-	response := tunnelConfigJson{Transport: transportConfigText}
-	if streamFirstHop == packetFirstHop {
-		response.FirstHop = streamFirstHop
+	if opts.WarningsAsErrors && len(warnings) > 0 {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "config has warnings: " + strings.Join(warnings, "; "),
+			Details: platerrors.InvalidConfigDetails{
+				Reason: platerrors.ReasonWarnings,
+				Extra:  platerrors.ErrorDetails{"warnings": warnings},
+			}.ToErrorDetails(),
+		}
+	}
+
+	client, selected, perr := newClientWithTimeout(ctx, transportConfigTexts, clientOpts, connectTimeout)
+	usedFallbackFirstHop := false
+	if perr != nil && fallbackFirstHop != "" && isResolveFailure(perr) {
+		logger.DebugContext(ctx, "first hop failed to resolve, trying the fallback", "fallbackFirstHop", fallbackFirstHop)
+		fallbackOpts := clientOpts
+		fallbackOpts.fallbackFirstHop = fallbackFirstHop
+		if client, selected, perr = newClientWithTimeout(ctx, transportConfigTexts, fallbackOpts, connectTimeout); perr == nil {
+			clientOpts, usedFallbackFirstHop = fallbackOpts, true
+		}
+	}
+	if perr != nil {
+		return nil, perr
+	}
+	if perr := checkClientConflicts(client, clientOpts); perr != nil {
+		return nil, perr
+	}
+	response := TunnelConfig{
+		Transport:      transportConfigTexts[selected],
+		ConfigID:       configID(transportConfigTexts[selected]),
+		TransportType:  detectTransportType(transportConfigTexts[selected]),
+		Name:           name,
+		Tags:           tags,
+		Region:         region,
+		Provider:       provider,
+		CipherWarning:  cipherWarnings[selected],
+		Format:         format,
+		ProbeOrder:     clientOpts.probeOrder,
+		HealthCheckURL: clientOpts.healthCheckURL,
+	}
+	if response.CipherWarning != nil {
+		response.Warnings = []string{response.CipherWarning.Message}
+	}
+	setFirstHops(&response, client, hasPacketsOverStream(transportConfigTexts[selected]))
+	response.FallbackFirstHopUsed = usedFallbackFirstHop
+	if selected < len(displayFirstHops) && displayFirstHops[selected] != "" {
+		response.FirstHop = displayFirstHops[selected]
+	}
+	allFirstHops := append(slices.Clone(response.StreamFirstHops), response.PacketFirstHops...)
+	response.FirstHopIsHostname = slices.ContainsFunc(allFirstHops, isHostnameAddress)
+	response.Cipher, response.KeyBytes, response.Prefixed = shadowsocksCipherInfo(transportConfigTexts[selected])
+	response.Fragmented = hasFragmentation(transportConfigTexts[selected])
+	response.Summary = summarizeTransport(transportConfigTexts[selected], strings.Join(response.StreamFirstHops, ", "))
+	response.Layers = listTransportLayers(transportConfigTexts[selected], response.StreamFirstHop)
+	response.Capabilities = transportCapabilities(&response)
+	logger.DebugContext(ctx, "parsed tunnel config", "transportType", response.TransportType, "selectedTransport", selected,
+		"streamFirstHops", response.StreamFirstHops, "packetFirstHops", response.PacketFirstHops)
+	if opts.ResolveFirstHopAddresses && !opts.NoResolve {
+		if response.FirstHopAddresses, perr = lookupFirstHopAddresses(ctx, clientOpts, allFirstHops...); perr != nil {
+			return nil, perr
+		}
+	} else if clientOpts.hostIPOverride != "" {
+		// The overridden first hops are reported as written, so their IP is only visible here.
+		for _, firstHop := range allFirstHops {
+			if ip, ok := overriddenFirstHopIP(clientOpts.hostIPOverride, firstHop); ok && !slices.Contains(response.FirstHopAddresses, ip.String()) {
+				response.FirstHopAddresses = append(response.FirstHopAddresses, ip.String())
+			}
+		}
+	}
+	if len(transportConfigTexts) > 1 {
+		response.SelectedTransport = &selected
+		if opts.ListCandidates {
+			response.Candidates = listTransportCandidates(ctx, transportConfigTexts, displayFirstHops, clientOpts, connectTimeout)
+		}
+	}
+	return &response, nil
+}
+
+// setFirstHops sets the first hop fields of the response from the client. The scalar fields are
+// only set for a single first hop, so callers that don't support multiple first hops can use them.
+func setFirstHops(response *TunnelConfig, client *Client, packetsOverStream bool) {
+	hops := newClientFirstHops(client, packetsOverStream)
+	response.FirstHop = hops.firstHop
+	response.StreamFirstHops = hops.streamFirstHops
+	response.StreamFirstHop = singleFirstHop(hops.streamFirstHops)
+	response.PacketFirstHops = hops.packetFirstHops
+	response.PacketFirstHop = singleFirstHop(hops.packetFirstHops)
+	response.UDPSupported = hops.udpSupported
+	response.UDPOverTCP = hops.udpOverTCP
+	response.Hopless = len(hops.streamFirstHops) == 0 && len(hops.packetFirstHops) == 0
+	if client.pl != nil {
+		response.MaxPacketSize = client.pl.MaxPacketSize
+	}
+}
+
+// clientFirstHops are the first hops of a client, as reported by [TunnelConfig] and
+// [TransportCandidate].
+type clientFirstHops struct {
+	// firstHop is the single first hop of the device traffic, or empty if there are several.
+	firstHop        string
+	streamFirstHops []string
+	packetFirstHops []string
+	udpSupported    bool
+	udpOverTCP      bool
+}
+
+// newClientFirstHops returns the first hops of the client, with packetsOverStream if the packets
+// are tunneled over a stream. The device then only sends TCP traffic, as when UDP is disabled, so
+// the stream hop is the first hop even if the packet hop differs.
+func newClientFirstHops(client *Client, packetsOverStream bool) clientFirstHops {
+	hops := clientFirstHops{streamFirstHops: client.sd.AllFirstHops()}
+	if client.pl != nil {
+		hops.packetFirstHops = client.pl.AllFirstHops()
+		hops.udpSupported = client.pl.ConnType != config.ConnTypeDisabled
+	}
+	hops.udpOverTCP = hops.udpSupported && packetsOverStream
+	streamFirstHop := singleFirstHop(hops.streamFirstHops)
+	if streamFirstHop == singleFirstHop(hops.packetFirstHops) || !hops.udpSupported || hops.udpOverTCP {
+		hops.firstHop = streamFirstHop
+	}
+	return hops
+}
+
+// singleFirstHop returns the first hop if there's exactly one, or an empty string.
+func singleFirstHop(firstHops []string) string {
+	if len(firstHops) != 1 {
+		return ""
 	}
-	responseBytes, err := json.Marshal(response)
+	return firstHops[0]
+}
+
+// deprecatedCipherNames maps the deprecated cipher aliases to their current names.
+var deprecatedCipherNames = map[string]string{
+	"AEAD_CHACHA20_POLY1305": "chacha20-ietf-poly1305",
+	"AEAD_AES_256_GCM":       "aes-256-gcm",
+	"AEAD_AES_192_GCM":       "aes-192-gcm",
+	"AEAD_AES_128_GCM":       "aes-128-gcm",
+}
+
+// isEmptyNode reports whether node is missing, null, or an empty mapping, sequence or string.
+func isEmptyNode(node ast.Node) bool {
+	switch n := node.(type) {
+	case nil, *ast.NullNode:
+		return true
+	case *ast.MappingNode:
+		return len(n.Values) == 0
+	case *ast.SequenceNode:
+		return len(n.Values) == 0
+	case *ast.StringNode:
+		return strings.TrimSpace(n.Value) == ""
+	default:
+		return false
+	}
+}
+
+// providerErrorCodes maps the codes that providers can set in their error envelope to the error
+// code returned to the app, so it can show the specific remediation.
+var providerErrorCodes = map[string]platerrors.ErrorCode{
+	"unauthorized": platerrors.Unauthenticated,
+	"expired":      platerrors.ConfigExpired,
+	"disabled":     platerrors.ConfigDisabled,
+}
+
+// providerErrorCode returns the error code for the code of a provider error envelope, which is
+// [platerrors.ProviderError] if it's absent or not recognized.
+func providerErrorCode(code string) platerrors.ErrorCode {
+	if errorCode, ok := providerErrorCodes[strings.ToLower(code)]; ok {
+		return errorCode
+	}
+	return platerrors.ProviderError
+}
+
+// providerErrorDetails converts the details text of a provider error, along with its other
+// details, to [platerrors.ErrorDetails]. If the text is a JSON object, its keys are merged as
+// structured data, under those of known. Otherwise, the text is reported as is under the "details"
+// key. It returns nil if there are no details.
+func providerErrorDetails(text string, known platerrors.ProviderErrorDetails) platerrors.ErrorDetails {
+	var structured map[string]any
+	if text != "" {
+		if err := json.Unmarshal([]byte(text), &structured); err != nil || structured == nil {
+			known.Details = text
+		}
+	}
+	details := known.ToErrorDetails()
+	for key, value := range structured {
+		if _, isKnown := details[key]; !isKnown {
+			details[key] = value
+		}
+	}
+	if len(details) == 0 {
+		return nil
+	}
+	return details
+}
+
+// normalizeTransportNode serializes the transport node to its normalized text.
+//
+// The node keeps the key order and anchors of the source, but its indentation depends on where it
+// was nested. We remove the common indentation and the trailing newline, so the normalized text
+// is stable when fed back to the parser, either on its own or nested in a new tunnel config.
+func normalizeTransportNode(node ast.Node) (text string, err error) {
+	// Incomplete nodes, like an anchor without a value, make the encoder panic.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("incomplete YAML node: %v", r)
+		}
+	}()
+	transportConfigBytes, err := yaml.Marshal(node)
 	if err != nil {
-		return &InvokeMethodResult{
-			Error: &platerrors.PlatformError{
-				Code:    platerrors.InternalError,
-				Message: fmt.Sprintf("failed to serialize JSON response: %v", err),
-			},
+		return "", err
+	}
+	return removeCommonIndent(string(transportConfigBytes)), nil
+}
+
+// validateTransportText checks that the normalized text of a transport parses as a mapping or a
+// string, like a ss:// link, which are the forms the transport parser accepts. Malformed inputs may
+// leave partial nodes that normalize to something else, which would otherwise fail with an opaque
+// error when creating the client.
+func validateTransportText(transportConfigText string) *platerrors.PlatformError {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "transport is not valid YAML",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonSyntax}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
 		}
 	}
+	switch typed := node.(type) {
+	case map[string]any:
+		return nil
+	case string:
+		if strings.TrimSpace(typed) != "" {
+			return nil
+		}
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("transport must be a mapping or a link, found %s", yamlKindName(node)),
+		Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonWrongShape}.ToErrorDetails(),
+	}
+}
 
-	return &InvokeMethodResult{
-		Value: string(responseBytes),
+// yamlKindName describes the kind of a parsed YAML value for error messages.
+func yamlKindName(node config.ConfigNode) string {
+	switch node.(type) {
+	case nil:
+		return "nothing"
+	case string:
+		return "an empty string"
+	case []any:
+		return "a list"
+	case bool:
+		return "a boolean"
+	case []byte:
+		return "binary data"
+	case int, int64, uint64, float64:
+		return "a number"
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
+
+// displayFirstHopKey is the transport key with the first hop to show to users, like a friendly
+// hostname for a load balancer. It's not part of the transport, so it's removed before parsing.
+const displayFirstHopKey = "displayFirstHop"
+
+// extractDisplayFirstHop removes the displayFirstHop entry of the transport node, and returns its
+// value, or an empty string if none.
+func extractDisplayFirstHop(node ast.Node) (string, *platerrors.PlatformError) {
+	mapping, ok := node.(*ast.MappingNode)
+	if !ok {
+		return "", nil
+	}
+	for i, entry := range mapping.Values {
+		if entry.Key.GetToken().Value != displayFirstHopKey {
+			continue
+		}
+		displayFirstHop, ok := entry.Value.(*ast.StringNode)
+		if !ok || strings.TrimSpace(displayFirstHop.Value) == "" {
+			return "", &platerrors.PlatformError{
+				Code:    platerrors.InvalidConfig,
+				Message: displayFirstHopKey + " must be a non-empty string",
+				Details: platerrors.InvalidConfigDetails{Field: displayFirstHopKey, Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			}
+		}
+		mapping.Values = slices.Delete(mapping.Values, i, i+1)
+		return displayFirstHop.Value, nil
+	}
+	return "", nil
+}
+
+// rawTransportNodeText is like [normalizeTransportNode], but returns the node as written in the
+// source, including comments if it was parsed with [parser.ParseComments].
+func rawTransportNodeText(node ast.Node) (string, error) {
+	return removeCommonIndent(node.String()), nil
+}
+
+// parseTransportNodeWithComments returns the transport node of a tunnel config, with its comments.
+func parseTransportNodeWithComments(input string) (ast.Node, error) {
+	file, err := parser.ParseBytes([]byte(input), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range file.Docs {
+		mapping, ok := doc.Body.(*ast.MappingNode)
+		if !ok {
+			continue
+		}
+		for _, entry := range mapping.Values {
+			if entry.Key.GetToken().Value == "transport" {
+				return entry.Value, nil
+			}
+		}
+	}
+	return nil, errors.New("transport not found")
+}
+
+// removeCommonIndent removes the indentation shared by all the lines of text, and the trailing newlines.
+func removeCommonIndent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if indent == -1 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= indent {
+			lines[i] = line[max(indent, 0):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// configID returns the fingerprint of a normalized transport config text.
+func configID(transportConfigText string) string {
+	digest := sha256.Sum256([]byte(transportConfigText))
+	return hex.EncodeToString(digest[:])
+}
+
+// detectTransportType returns the kind of the outermost transport in the transport config.
+// It only looks at URL schemes and $type directives, so it never includes credentials.
+func detectTransportType(transportConfigText string) string {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return ""
+	}
+	switch typed := node.(type) {
+	case string:
+		scheme, _, found := strings.Cut(typed, "://")
+		if !found {
+			// host:port shorthand.
+			return "endpoint"
+		}
+		if strings.EqualFold(scheme, "ss") {
+			return "shadowsocks"
+		}
+		return strings.ToLower(scheme)
+	case map[string]any:
+		if typeName, ok := typed[config.ConfigTypeKey].(string); ok {
+			return typeName
+		}
+		// Configs without a $type are parsed as Shadowsocks for backwards-compatibility.
+		return "shadowsocks"
+	default:
+		return ""
+	}
+}
+
+// isHostnameAddress returns whether the host of the host:port address is a hostname, rather than
+// an IPv4 or IPv6 literal. Empty addresses have no host.
+func isHostnameAddress(address string) bool {
+	if address == "" {
+		return false
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	// Zoned IPv6 literals, like fe80::1%eth0, are IP literals too.
+	_, err = netip.ParseAddr(host)
+	return host != "" && err != nil
+}
+
+// lookupFirstHopAddresses returns the IP addresses of the hosts of the given first hops, without
+// duplicates. It uses the same resolver as the client.
+func lookupFirstHopAddresses(ctx context.Context, opts clientOptions, firstHops ...string) ([]string, *platerrors.PlatformError) {
+	tcpDialer := transport.TCPDialer{Dialer: net.Dialer{KeepAlive: -1}}
+	udpDialer := transport.UDPDialer{}
+	var addresses []string
+	logger := loggerFromContext(ctx)
+	for _, firstHop := range firstHops {
+		if firstHop == "" {
+			continue
+		}
+		host, _, err := net.SplitHostPort(firstHop)
+		if err != nil {
+			host = firstHop
+		}
+		if ip, ok := overriddenFirstHopIP(opts.hostIPOverride, firstHop); ok {
+			if !slices.Contains(addresses, ip.String()) {
+				addresses = append(addresses, ip.String())
+			}
+			continue
+		}
+		ips, err := config.LookupIP(ctx, host, opts.resolver, &tcpDialer, &udpDialer)
+		if err != nil {
+			logger.DebugContext(ctx, "failed to resolve first hop", "host", host, "err", err)
+			if ctx.Err() != nil {
+				return nil, newContextError(ctx.Err())
+			}
+			// The I/O deadlines derived from ctx may expire just before ctx reports it.
+			if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+				return nil, newContextError(context.DeadlineExceeded)
+			}
+			return nil, newTransportError(err)
+		}
+		logger.DebugContext(ctx, "resolved first hop", "host", host, "addresses", ips)
+		for _, ip := range ips {
+			if address := ip.Unmap().String(); !slices.Contains(addresses, address) {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// fragmentationTypes are the $type values of the layers that fragment the stream.
+var fragmentationTypes = map[string]bool{"split": true, "tlsfrag": true}
+
+// hasFragmentation returns whether the transport config has a fragmentation layer at any depth.
+func hasFragmentation(transportConfigText string) bool {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return false
+	}
+	return hasTypeNode(node, fragmentationTypes)
+}
+
+// streamedPacketTypes are the layers that carry the packets over a stream.
+var streamedPacketTypes = map[string]bool{"websocket": true}
+
+// hasPacketsOverStream returns whether the packet path of the transport config tunnels the packets
+// over a stream layer, like a WebSocket.
+func hasPacketsOverStream(transportConfigText string) bool {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return false
+	}
+	if typed, ok := node.(map[string]any); ok && typed[config.ConfigTypeKey] == "tcpudp" {
+		node = typed["udp"]
+	}
+	return hasTypeNode(node, streamedPacketTypes)
+}
+
+// hasTypeNode returns whether the node has a layer of one of the given types at any depth.
+func hasTypeNode(node config.ConfigNode, types map[string]bool) bool {
+	switch typed := node.(type) {
+	case map[string]any:
+		if typeName, ok := typed[config.ConfigTypeKey].(string); ok && types[typeName] {
+			return true
+		}
+		for _, value := range typed {
+			if hasTypeNode(value, types) {
+				return true
+			}
+		}
+	case []any:
+		for _, value := range typed {
+			if hasTypeNode(value, types) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// listTransportCandidates creates a client for each transport config to report its first hops.
+func listTransportCandidates(ctx context.Context, transportConfigTexts []string, displayFirstHops []string, opts clientOptions, timeout time.Duration) []TransportCandidate {
+	candidates := make([]TransportCandidate, 0, len(transportConfigTexts))
+	for i, transportConfigText := range transportConfigTexts {
+		candidate := TransportCandidate{TransportType: detectTransportType(transportConfigText)}
+		client, _, perr := newClientWithTimeout(ctx, []string{transportConfigText}, opts, timeout)
+		if perr != nil {
+			candidate.Error = perr
+		} else {
+			hops := newClientFirstHops(client, hasPacketsOverStream(transportConfigText))
+			candidate.FirstHop = hops.firstHop
+			candidate.StreamFirstHops = hops.streamFirstHops
+			candidate.StreamFirstHop = singleFirstHop(hops.streamFirstHops)
+			candidate.PacketFirstHops = hops.packetFirstHops
+			candidate.PacketFirstHop = singleFirstHop(hops.packetFirstHops)
+			if i < len(displayFirstHops) && displayFirstHops[i] != "" {
+				candidate.FirstHop = displayFirstHops[i]
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// shadowsocksCipherInfo returns the cipher name and key size of a Shadowsocks transport config,
+// or of the TCP transport of a tcpudp config, and whether it has a connection prefix. It returns
+// zero values for other transports.
+func shadowsocksCipherInfo(transportConfigText string) (string, int, bool) {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return "", 0, false
+	}
+	return shadowsocksNodeCipherInfo(node)
+}
+
+func shadowsocksNodeCipherInfo(node config.ConfigNode) (string, int, bool) {
+	if typed, ok := node.(map[string]any); ok {
+		switch typed[config.ConfigTypeKey] {
+		case nil, "shadowsocks":
+		case "tcpudp":
+			return shadowsocksNodeCipherInfo(typed["tcp"])
+		default:
+			return "", 0, false
+		}
+	}
+	ssConfig, err := config.ParseShadowsocksConfig(node)
+	if err != nil {
+		return "", 0, false
+	}
+	key, err := shadowsocks.NewEncryptionKey(ssConfig.Cipher, ssConfig.Secret)
+	if err != nil {
+		return "", 0, false
+	}
+	cipherName := strings.ToLower(ssConfig.Cipher)
+	if name, deprecated := deprecatedCipherNames[strings.ToUpper(ssConfig.Cipher)]; deprecated {
+		cipherName = name
+	}
+	// The salt of the AEAD ciphers has the same size as the key.
+	return cipherName, key.SaltSize(), ssConfig.Prefix != "" || ssConfig.PrefixPreset != ""
+}
+
+// newValidatedClient is like [newCachedClient], but first validates ss:// links and ciphers to give
+// precise errors.
+func newValidatedClient(ctx context.Context, transportConfigText string, opts clientOptions) *NewClientResult {
+	if perr := checkInsecureCipher(transportConfigText); perr != nil {
+		return &NewClientResult{Error: perr}
+	}
+	if perr := checkSIP022Cipher(transportConfigText); perr != nil {
+		return &NewClientResult{Error: perr}
+	}
+	if strings.HasPrefix(transportConfigText, "ss://") {
+		if perr := validateShadowsocksURL(transportConfigText); perr != nil {
+			return &NewClientResult{Error: perr}
+		}
+	}
+	return newCachedClient(ctx, transportConfigText, opts)
+}
+
+// newClientWithTimeout is like [newClientFromFallbacks], but gives up after timeout.
+func newClientWithTimeout(ctx context.Context, transportConfigTexts []string, opts clientOptions, timeout time.Duration) (*Client, int, *platerrors.PlatformError) {
+	clientCtx, cancel := context.WithTimeoutCause(ctx, timeout, errConnectTimeout)
+	defer cancel()
+	client, selected, perr := newClientFromFallbacks(clientCtx, transportConfigTexts, opts)
+	if perr != nil && ctx.Err() == nil && errors.Is(context.Cause(clientCtx), errConnectTimeout) {
+		return nil, 0, &platerrors.PlatformError{
+			Code:    platerrors.OperationTimedOut,
+			Message: fmt.Sprintf("connecting to the first hop timed out after %dms", timeout.Milliseconds()),
+			Details: platerrors.OperationTimedOutDetails{ConnectTimeoutMs: timeout.Milliseconds()}.ToErrorDetails(),
+			Cause:   perr,
+		}
+	}
+	return client, selected, perr
+}
+
+// newClientFromFallbacks creates a [Client] from the first transport config that succeeds, and
+// returns its index. If all of them fail, the returned error lists each failure in its Details.
+func newClientFromFallbacks(ctx context.Context, transportConfigTexts []string, opts clientOptions) (*Client, int, *platerrors.PlatformError) {
+	if len(transportConfigTexts) == 0 {
+		return nil, 0, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "transport list must not be empty",
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonMissing}.ToErrorDetails(),
+		}
+	}
+	if len(transportConfigTexts) == 1 {
+		result := newValidatedClient(ctx, transportConfigTexts[0], opts)
+		return result.Client, 0, result.Error
+	}
+
+	failures := make([]any, 0, len(transportConfigTexts))
+	for i, transportConfigText := range transportConfigTexts {
+		if ctx.Err() != nil {
+			return nil, 0, newContextError(ctx.Err())
+		}
+		result := newValidatedClient(ctx, transportConfigText, opts)
+		if result.Error == nil {
+			return result.Client, i, nil
+		}
+		failures = append(failures, platerrors.ErrorDetails{
+			"index":   i,
+			"code":    result.Error.Code,
+			"message": result.Error.Error(),
+		})
+	}
+	return nil, 0, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "all transports failed",
+		Details: platerrors.InvalidConfigDetails{
+			Field:  "transport",
+			Reason: platerrors.ReasonInvalidTransport,
+			Extra:  platerrors.ErrorDetails{"failures": failures},
+		}.ToErrorDetails(),
 	}
 }
@@ -15,9 +15,25 @@
 package outline
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
 	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
 	"github.com/stretchr/testify/require"
 )
 
@@ -25,7 +41,7 @@ func Test_doParseTunnel_SSURL(t *testing.T) {
 	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
 	require.Nil(t, result.Error)
 	require.Equal(t,
-		"{\"firstHop\":\"example.com:4321\",\"transport\":\"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\"}",
+		"{\"firstHop\":\"example.com:4321\",\"streamFirstHop\":\"example.com:4321\",\"packetFirstHop\":\"example.com:4321\",\"streamFirstHops\":[\"example.com:4321\"],\"packetFirstHops\":[\"example.com:4321\"],\"udpSupported\":true,\"maxPacketSize\":1385,\"transport\":\"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\",\"configId\":\"e70289fd14bc16a0119c4da436e1c6390c0df61ca751d8569c136d2d919698fc\",\"transportType\":\"shadowsocks\",\"cipher\":\"chacha20-ietf-poly1305\",\"keyBytes\":32,\"firstHopIsHostname\":true,\"summary\":\"Shadowsocks (example.com:4321)\",\"layers\":[{\"type\":\"shadowsocks\",\"endpoint\":\"example.com:4321\"}],\"capabilities\":{\"udp\":true,\"fragmentation\":false,\"obfuscation\":false,\"multihop\":false},\"format\":\"ss-url\"}",
 		result.Value)
 }
 
@@ -38,7 +54,7 @@ func Test_doParseTunnel_LegacyJSON(t *testing.T) {
 }`)
 	require.Nil(t, result.Error)
 	require.Equal(t,
-		"{\"firstHop\":\"example.com:4321\",\"transport\":\"{\\n    \\\"server\\\": \\\"example.com\\\",\\n    \\\"server_port\\\": 4321,\\n    \\\"method\\\": \\\"chacha20-ietf-poly1305\\\",\\n    \\\"password\\\": \\\"SECRET\\\"\\n}\"}",
+		"{\"firstHop\":\"example.com:4321\",\"streamFirstHop\":\"example.com:4321\",\"packetFirstHop\":\"example.com:4321\",\"streamFirstHops\":[\"example.com:4321\"],\"packetFirstHops\":[\"example.com:4321\"],\"udpSupported\":true,\"maxPacketSize\":1385,\"transport\":\"{\\n    \\\"server\\\": \\\"example.com\\\",\\n    \\\"server_port\\\": 4321,\\n    \\\"method\\\": \\\"chacha20-ietf-poly1305\\\",\\n    \\\"password\\\": \\\"SECRET\\\"\\n}\",\"configId\":\"f0868cffc7a361efe22c970402186a47c922b806dd988591ad59109916461a74\",\"transportType\":\"shadowsocks\",\"cipher\":\"chacha20-ietf-poly1305\",\"keyBytes\":32,\"firstHopIsHostname\":true,\"summary\":\"Shadowsocks (example.com:4321)\",\"layers\":[{\"type\":\"shadowsocks\",\"endpoint\":\"example.com:4321\"}],\"capabilities\":{\"udp\":true,\"fragmentation\":false,\"obfuscation\":false,\"multihop\":false},\"format\":\"legacy-json\"}",
 		result.Value)
 }
 
@@ -55,10 +71,1428 @@ transport:
 
 	require.Nil(t, result.Error)
 	require.Equal(t,
-		"{\"firstHop\":\"example.com:80\",\"transport\":\"  $type: tcpudp\\n  tcp: \\u0026shared\\n    $type: shadowsocks\\n    endpoint: example.com:80\\n    cipher: chacha20-ietf-poly1305\\n    secret: SECRET\\n  udp: *shared\\n\"}",
+		"{\"firstHop\":\"example.com:80\",\"streamFirstHop\":\"example.com:80\",\"packetFirstHop\":\"example.com:80\",\"streamFirstHops\":[\"example.com:80\"],\"packetFirstHops\":[\"example.com:80\"],\"udpSupported\":true,\"maxPacketSize\":1385,\"transport\":\"$type: tcpudp\\ntcp: \\u0026shared\\n  $type: shadowsocks\\n  endpoint: example.com:80\\n  cipher: chacha20-ietf-poly1305\\n  secret: SECRET\\nudp: *shared\",\"configId\":\"54f0b56ce42175dcab353c4928b3efbfd505528afa504591e9dc07955f322c7c\",\"transportType\":\"tcpudp\",\"cipher\":\"chacha20-ietf-poly1305\",\"keyBytes\":32,\"firstHopIsHostname\":true,\"summary\":\"Shadowsocks (example.com:80)\",\"layers\":[{\"type\":\"shadowsocks\",\"endpoint\":\"example.com:80\"}],\"capabilities\":{\"udp\":true,\"fragmentation\":false,\"obfuscation\":false,\"multihop\":false},\"format\":\"advanced-yaml\"}",
 		result.Value)
 }
 
+func Test_doParseTunnel_LegacyJSONDeprecatedCipher(t *testing.T) {
+	result := doParseTunnelConfig(`{
+    "server": "example.com",
+    "server_port": 4321,
+    "method": "AEAD_CHACHA20_POLY1305",
+    "password": "SECRET"
+}`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Equal(t, []string{`cipher "AEAD_CHACHA20_POLY1305" is deprecated, use "chacha20-ietf-poly1305" instead`}, response.Warnings)
+}
+
+func Test_ParseTunnelConfigWithOptions_WarningsAsErrors(t *testing.T) {
+	const input = `{
+    "server": "example.com",
+    "server_port": 4321,
+    "method": "AEAD_CHACHA20_POLY1305",
+    "password": "SECRET"
+}`
+	const warning = `cipher "AEAD_CHACHA20_POLY1305" is deprecated, use "chacha20-ietf-poly1305" instead`
+
+	lenient := ParseTunnelConfigWithOptions(input, &ParseOptions{})
+	require.Nil(t, lenient.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(lenient.Value), &response))
+	require.Equal(t, []string{warning}, response.Warnings)
+
+	strict := ParseTunnelConfigWithOptions(input, &ParseOptions{WarningsAsErrors: true})
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "config has warnings: " + warning,
+		Details: platerrors.ErrorDetails{"reason": platerrors.ReasonWarnings, "warnings": []string{warning}},
+	}, strict.Error)
+
+	clean := ParseTunnelConfigWithOptions(strings.Replace(input, "AEAD_CHACHA20_POLY1305", "chacha20-ietf-poly1305", 1),
+		&ParseOptions{WarningsAsErrors: true})
+	require.Nil(t, clean.Error)
+}
+
+func Test_doParseTunnel_Base64LegacyJSON(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{
+    "server": "example.com",
+    "server_port": 4321,
+    "method": "chacha20-ietf-poly1305",
+    "password": "SECRET"
+}`))
+	result := doParseTunnelConfig(encoded)
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+}
+
+func Test_doParseTunnel_Base64AdvancedYAML(t *testing.T) {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(`
+transport:
+  endpoint: example.com:80
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`))
+	result := doParseTunnelConfig(encoded)
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:80", response.FirstHop)
+}
+
+func Test_decodeBase64Config_NotAMapping(t *testing.T) {
+	_, ok := decodeBase64Config("abcd")
+	require.False(t, ok)
+	_, ok = decodeBase64Config(base64.StdEncoding.EncodeToString([]byte("just a scalar")))
+	require.False(t, ok)
+}
+
+func Test_doParseTunnel_UnsupportedURLSchemes(t *testing.T) {
+	for _, tc := range []struct {
+		input  string
+		scheme string
+	}{
+		{"vmess://eyJhZGQiOiJleGFtcGxlLmNvbSJ9", "vmess"},
+		{"trojan://password@example.com:443", "trojan"},
+	} {
+		result := doParseTunnelConfig(tc.input)
+		require.NotNil(t, result.Error)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Contains(t, result.Error.Message, "not supported")
+		require.Equal(t, tc.scheme, result.Error.Details["scheme"])
+	}
+}
+
+func Test_doParseTunnel_HTTPSURL(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `
+transport:
+  endpoint: example.com:4321
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`)
+	}))
+	defer server.Close()
+	defaultClient := tunnelConfigHTTPClient
+	tunnelConfigHTTPClient = server.Client()
+	defer func() { tunnelConfigHTTPClient = defaultClient }()
+
+	result := doParseTunnelConfig(server.URL)
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+}
+
+func Test_doParseTunnel_HTTPURLRejected(t *testing.T) {
+	result := doParseTunnelConfig("http://example.com/config")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
+func Test_doParseTunnelConfig_Empty(t *testing.T) {
+	for _, input := range []string{"", " ", "\n\t \r\n", "\ufeff  "} {
+		result := doParseTunnelConfig(input)
+		require.NotNil(t, result.Error, "%q", input)
+		require.Equal(t, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "config is empty",
+			Details: platerrors.ErrorDetails{"reason": platerrors.ReasonMissing},
+		}, result.Error)
+	}
+}
+
+func Test_doParseTunnelConfig_Format(t *testing.T) {
+	for _, tc := range []struct {
+		input  string
+		format ConfigFormat
+	}{
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", ConfigFormatShadowsocksURL},
+		{`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "SECRET"}`, ConfigFormatLegacyJSON},
+		{"transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", ConfigFormatAdvancedYAML},
+		// base64("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/").
+		{"dHJhbnNwb3J0OiBzczovL1kyaGhZMmhoTWpBdGFXVjBaaTF3YjJ4NU1UTXdOVHBUUlVOU1JWUUBleGFtcGxlLmNvbTo0MzIxLw==", ConfigFormatAdvancedYAML},
+	} {
+		result := doParseTunnelConfig(tc.input)
+		require.Nil(t, result.Error, tc.input)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.Equal(t, tc.format, response.Format, tc.input)
+	}
+}
+
+func Test_doParseTunnelConfig_NormalizationIsStable(t *testing.T) {
+	parseTransport := func(input string) string {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		return response.Transport
+	}
+
+	normalized := parseTransport(`
+transport:
+    $type: tcpudp
+    tcp: &shared
+        $type: shadowsocks
+        endpoint: example.com:80
+        cipher: chacha20-ietf-poly1305
+        secret: SECRET
+    udp: *shared`)
+	require.Equal(t, "$type: tcpudp\ntcp: &shared\n    $type: shadowsocks\n    endpoint: example.com:80\n    cipher: chacha20-ietf-poly1305\n    secret: SECRET\nudp: *shared", normalized)
+
+	// Re-feeding the normalized transport on its own.
+	require.Equal(t, normalized, parseTransport(normalized))
+	// Re-feeding the normalized transport nested in a new tunnel config.
+	require.Equal(t, normalized, parseTransport("transport:\n  "+strings.ReplaceAll(normalized, "\n", "\n  ")))
+}
+
+func Test_ParseTunnelConfig_Anchors(t *testing.T) {
+	input := `
+transport:
+  # Same server for TCP and UDP.
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80 # CDN edge
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`
+
+	t.Run("Default", func(t *testing.T) {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.Equal(t, `$type: tcpudp
+tcp: &shared
+  $type: shadowsocks
+  endpoint: example.com:80
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp: *shared`, response.Transport)
+	})
+
+	t.Run("RawTransport", func(t *testing.T) {
+		result := ParseTunnelConfigWithOptions(input, &ParseOptions{RawTransport: true})
+		require.Nil(t, result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.Equal(t, `# Same server for TCP and UDP.
+$type: tcpudp
+tcp: &shared
+  $type: shadowsocks
+  endpoint: example.com:80 # CDN edge
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp: *shared`, response.Transport)
+		require.Equal(t, "example.com:80", response.FirstHop)
+	})
+}
+
+func Test_doParseTunnelConfig_SplitFirstHops(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:53
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "", response.FirstHop)
+	require.Equal(t, "example.com:80", response.StreamFirstHop)
+	require.Equal(t, "example.com:53", response.PacketFirstHop)
+	require.False(t, response.UDPOverTCP)
+}
+
+func Test_doParseTunnelConfig_UDPOverTCP(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: wss://udp.example.com/packets
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`)
+
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.True(t, response.UDPOverTCP)
+	require.Equal(t, "example.com:80", response.FirstHop)
+	require.Equal(t, "example.com:80", response.StreamFirstHop)
+	require.Equal(t, "udp.example.com:443", response.PacketFirstHop)
+}
+
+func Test_doParseTunnelConfig_MaxPacketSize(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		// 1500 bytes minus the IPv6 and UDP headers, the 16-byte salt, the IPv6 address and the tag.
+		{"aes-128-gcm", "ss://aes-128-gcm:SECRET@example.com:4321", 1452 - 16 - 19 - 16},
+		{"chacha20-ietf-poly1305", "ss://chacha20-ietf-poly1305:SECRET@example.com:4321", 1452 - 32 - 19 - 16},
+		{"udp over websocket", `
+$type: tcpudp
+tcp: ss://chacha20-ietf-poly1305:SECRET@example.com:4321
+udp:
+  $type: shadowsocks
+  endpoint: {$type: websocket, url: "wss://udp.example.com/packets"}
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`, 0},
+		{"udp disabled", "{$type: tcpudp, tcp: 'ss://chacha20-ietf-poly1305:SECRET@example.com:4321', udp: {$type: disabled}}", 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := doParseTunnelConfig("transport:" + strings.ReplaceAll("\n"+tc.input, "\n", "\n  "))
+			require.Nil(t, result.Error, "Got %v", result.Error)
+			var response TunnelConfig
+			require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+			require.Equal(t, tc.expected, response.MaxPacketSize)
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_DisplayFirstHop(t *testing.T) {
+	input := `
+transport:
+  $type: tcpudp
+  displayFirstHop: vpn.example.com
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`
+	result := doParseTunnelConfig(input)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "vpn.example.com", response.FirstHop)
+	require.Equal(t, "example.com:80", response.StreamFirstHop)
+	require.Equal(t, "example.com:80", response.PacketFirstHop)
+	require.NotContains(t, response.Transport, "displayFirstHop")
+
+	// The config without displayFirstHop has the same transport.
+	expected := doParseTunnelConfig(strings.Replace(input, "  displayFirstHop: vpn.example.com\n", "", 1))
+	require.Nil(t, expected.Error, "Got %v", expected.Error)
+	var expectedResponse TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(expected.Value), &expectedResponse))
+	expectedResponse.FirstHop = "vpn.example.com"
+	require.Equal(t, expectedResponse, response)
+}
+
+func Test_doParseTunnelConfig_InvalidDisplayFirstHop(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  displayFirstHop: [vpn.example.com]
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`)
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "displayFirstHop must be a non-empty string",
+		Details: platerrors.ErrorDetails{"field": "displayFirstHop", "reason": platerrors.ReasonInvalidValue},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_EndpointShorthand(t *testing.T) {
+	result := doParseTunnelConfig("transport: example.com:1080")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:1080", response.FirstHop)
+	require.Equal(t, "example.com:1080", response.StreamFirstHop)
+	require.Equal(t, "example.com:1080", response.PacketFirstHop)
+	require.True(t, response.UDPSupported)
+	require.Equal(t, "endpoint", response.TransportType)
+}
+
+func Test_doParseTunnelConfig_InvalidEndpointShorthand(t *testing.T) {
+	for _, input := range []string{"transport: example.com", "transport: example.com:99999"} {
+		result := doParseTunnelConfig(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code, input)
+	}
+}
+
+func Test_doParseTunnelConfig_FirstHopIsHostname(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint   string
+		isHostname bool
+	}{
+		{"example.com:4321", true},
+		{"192.0.2.1:4321", false},
+		{"[2001:db8::1]:4321", false},
+		{"[fe80::1%eth0]:4321", false},
+	} {
+		t.Run(tc.endpoint, func(t *testing.T) {
+			result := doParseTunnelConfig(`
+transport:
+  endpoint: "` + tc.endpoint + `"
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET`)
+			require.Nil(t, result.Error, "Got %v", result.Error)
+			var response TunnelConfig
+			require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+			require.Equal(t, tc.endpoint, response.FirstHop)
+			require.Equal(t, tc.isHostname, response.FirstHopIsHostname)
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_ZonedIPv6FirstHop(t *testing.T) {
+	// Pinning the address family resolves the first hops, which must keep the zone.
+	result := ParseTunnelConfigWithOptions(`
+addressFamily: ipv6
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: "[fe80::1%eth0]:4321"
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: "[fe80::1%eth0]:4321"
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`, &ParseOptions{ResolveFirstHopAddresses: true})
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "[fe80::1%eth0]:4321", response.StreamFirstHop)
+	require.Equal(t, "[fe80::1%eth0]:4321", response.PacketFirstHop)
+	require.Equal(t, "[fe80::1%eth0]:4321", response.FirstHop)
+	require.Equal(t, []string{"fe80::1%eth0"}, response.FirstHopAddresses)
+	require.False(t, response.FirstHopIsHostname)
+}
+
+func Test_setFirstHops(t *testing.T) {
+	newClient := func(streamInfo, packetInfo config.ConnectionProviderInfo) *Client {
+		return &Client{
+			sd: &config.Dialer[transport.StreamConn]{ConnectionProviderInfo: streamInfo},
+			pl: &config.PacketListener{ConnectionProviderInfo: packetInfo},
+		}
+	}
+
+	t.Run("single hop", func(t *testing.T) {
+		info := config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHop: "example.com:443"}
+		var response TunnelConfig
+		setFirstHops(&response, newClient(info, info), false)
+		require.Equal(t, TunnelConfig{
+			FirstHop:        "example.com:443",
+			StreamFirstHop:  "example.com:443",
+			PacketFirstHop:  "example.com:443",
+			StreamFirstHops: []string{"example.com:443"},
+			PacketFirstHops: []string{"example.com:443"},
+			UDPSupported:    true,
+		}, response)
+	})
+
+	t.Run("multiple hops", func(t *testing.T) {
+		streamInfo := config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHops: []string{"a.example.com:443", "b.example.com:443"}}
+		packetInfo := config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHop: "a.example.com:443"}
+		var response TunnelConfig
+		setFirstHops(&response, newClient(streamInfo, packetInfo), false)
+		require.Equal(t, TunnelConfig{
+			PacketFirstHop:  "a.example.com:443",
+			StreamFirstHops: []string{"a.example.com:443", "b.example.com:443"},
+			PacketFirstHops: []string{"a.example.com:443"},
+			UDPSupported:    true,
+		}, response)
+	})
+
+	t.Run("multiple hops without UDP", func(t *testing.T) {
+		streamInfo := config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled, FirstHops: []string{"a.example.com:443", "b.example.com:443"}}
+		var response TunnelConfig
+		setFirstHops(&response, newClient(streamInfo, config.ConnectionProviderInfo{ConnType: config.ConnTypeDisabled}), false)
+		require.Equal(t, "", response.FirstHop)
+		require.Equal(t, []string{"a.example.com:443", "b.example.com:443"}, response.StreamFirstHops)
+		require.Nil(t, response.PacketFirstHops)
+		require.False(t, response.Hopless)
+	})
+
+	t.Run("no hops", func(t *testing.T) {
+		// Like a test transport that only connects to loopback.
+		info := config.ConnectionProviderInfo{ConnType: config.ConnTypeTunneled}
+		var response TunnelConfig
+		setFirstHops(&response, newClient(info, info), false)
+		require.Equal(t, TunnelConfig{Hopless: true, UDPSupported: true}, response)
+
+		valueBytes, err := json.Marshal(response)
+		require.NoError(t, err)
+		require.Contains(t, string(valueBytes), `"hopless":true`)
+	})
+}
+
+func Test_doParseTunnelConfig_Prefix(t *testing.T) {
+	for _, input := range []string{
+		`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+    prefixPreset: http-get
+  udp: *shared`,
+		"transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?prefixPreset=http-get",
+		"transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?prefix=%16%03%01",
+	} {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error, "Got %v", result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.True(t, response.Prefixed, input)
+	}
+
+	result := doParseTunnelConfig("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error)
+	require.NotContains(t, result.Value, "prefixed")
+}
+
+func Test_doParseTunnelConfig_EmptyTransport(t *testing.T) {
+	for _, input := range []string{
+		"transport:\n",
+		"transport: null",
+		"transport: {}",
+		"transport: []",
+		"transport: ''",
+	} {
+		t.Run(input, func(t *testing.T) {
+			result := doParseTunnelConfig(input)
+			require.NotNil(t, result.Error)
+			require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+			require.Equal(t, "transport is required and must not be empty", result.Error.Message)
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_NameAndTags(t *testing.T) {
+	result := doParseTunnelConfig(`
+name: Home server
+tags: [home, fast]
+extra: ignored
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "Home server", response.Name)
+	require.Equal(t, []string{"home", "fast"}, response.Tags)
+	require.Equal(t, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", response.Transport)
+}
+
+func Test_doParseTunnelConfig_RegionAndProvider(t *testing.T) {
+	parse := func(input string) TunnelConfig {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error, "Got %v", result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		return response
+	}
+	response := parse(`
+region: " eu-west / Frankfurt "
+provider: Example VPN
+datacenter: ignored
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+	require.Equal(t, " eu-west / Frankfurt ", response.Region)
+	require.Equal(t, "Example VPN", response.Provider)
+
+	// The labels don't change the transport.
+	unlabeled := parse("transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Empty(t, unlabeled.Region)
+	require.Empty(t, unlabeled.Provider)
+	unlabeled.Region, unlabeled.Provider = response.Region, response.Provider
+	require.Equal(t, unlabeled, response)
+}
+
+func Test_doParseTunnelConfig_WebsocketOverTLS(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: ws://relay.example.com/path
+      host: relay.example.com
+      endpoint:
+        $type: tls
+        sni: front.example.com
+        endpoint: cdn-edge.example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "cdn-edge.example.com:443", response.FirstHop)
+}
+
+func Test_doParseTunnelConfig_UDPDisabled(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+  udp:
+    $type: disabled`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.False(t, response.UDPSupported)
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Equal(t, "example.com:4321", response.StreamFirstHop)
+	require.Equal(t, "", response.PacketFirstHop)
+}
+
+func Test_doParseTunnelConfig_UnknownTransport(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: bogus`)
+
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "bogus", result.Error.Details["unknownTransport"])
+	require.Equal(t, []string{"tcpudp"}, result.Error.Details["supportedTransports"])
+}
+
+func Test_doParseTunnelConfig_AddressFamily(t *testing.T) {
+	result := doParseTunnelConfig(`
+addressFamily: ipv4
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@127.0.0.1:4321/`)
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "127.0.0.1:4321", response.FirstHop)
+
+	result = doParseTunnelConfig(`
+addressFamily: ipv6
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@127.0.0.1:4321/`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "the first hop has no address in the requested address family", result.Error.Message)
+
+	result = doParseTunnelConfig(`
+addressFamily: ipv5
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@127.0.0.1:4321/`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
+func Test_doParseTunnelConfig_Resolver(t *testing.T) {
+	// Answer A queries with 192.0.2.10, and other queries with no records.
+	resolverAddress := startStubDNSServer(t, func() netip.Addr { return netip.MustParseAddr("192.0.2.10") })
+
+	result := doParseTunnelConfig(`
+resolver:
+  address: ` + resolverAddress + `
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@proxy.invalid:4321/`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "192.0.2.10:4321", response.FirstHop)
+}
+
+func Test_doParseTunnelConfig_InvalidResolver(t *testing.T) {
+	for _, resolver := range []string{"{url: 'http://dns.google/dns-query'}", "{url: 'https://'}", "{}"} {
+		result := doParseTunnelConfig(`
+resolver: ` + resolver + `
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+		require.NotNil(t, result.Error, resolver)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Equal(t, "invalid resolver", result.Error.Message)
+	}
+}
+
+func Test_ParseTunnelConfig_ResolveFirstHopAddresses(t *testing.T) {
+	options := &ParseOptions{ResolveFirstHopAddresses: true}
+	parse := func(input string, options *ParseOptions) TunnelConfig {
+		result := ParseTunnelConfigWithOptions(input, options)
+		require.Nil(t, result.Error, "Got %v", result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		return response
+	}
+
+	response := parse("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@127.0.0.1:4321/", options)
+	require.Equal(t, []string{"127.0.0.1"}, response.FirstHopAddresses)
+
+	response = parse("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@localhost:4321/", options)
+	require.Contains(t, response.FirstHopAddresses, "127.0.0.1")
+
+	response = parse(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: 127.0.0.1:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: "[::1]:53"
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`, options)
+	require.Equal(t, []string{"127.0.0.1", "::1"}, response.FirstHopAddresses)
+
+	response = parse("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@localhost:4321/", nil)
+	require.Empty(t, response.FirstHopAddresses)
+}
+
+func Test_doParseTunnelConfig_TransportList(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  - $type: unsupported
+  - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Equal(t, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", response.Transport)
+	require.NotNil(t, response.SelectedTransport)
+	require.Equal(t, 1, *response.SelectedTransport)
+}
+
+func Test_ParseTunnelConfig_ListCandidates(t *testing.T) {
+	result := ParseTunnelConfigWithOptions(`
+transport:
+  - $type: unsupported
+  - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@first.example.com:4321/
+  - $type: tcpudp
+    tcp:
+      $type: shadowsocks
+      endpoint: second.example.com:4321
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET
+    udp:
+      $type: shadowsocks
+      endpoint: second.example.com:53
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET`, &ParseOptions{ListCandidates: true})
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, 1, *response.SelectedTransport)
+	require.Len(t, response.Candidates, 3)
+
+	require.Equal(t, "unsupported", response.Candidates[0].TransportType)
+	require.NotNil(t, response.Candidates[0].Error)
+	require.Equal(t, platerrors.InvalidConfig, response.Candidates[0].Error.Code)
+
+	require.Equal(t, "shadowsocks", response.Candidates[1].TransportType)
+	require.Equal(t, "first.example.com:4321", response.Candidates[1].FirstHop)
+	require.Nil(t, response.Candidates[1].Error)
+
+	require.Equal(t, "tcpudp", response.Candidates[2].TransportType)
+	require.Equal(t, "", response.Candidates[2].FirstHop)
+	require.Equal(t, "second.example.com:4321", response.Candidates[2].StreamFirstHop)
+	require.Equal(t, "second.example.com:53", response.Candidates[2].PacketFirstHop)
+	require.Equal(t, []string{"second.example.com:4321"}, response.Candidates[2].StreamFirstHops)
+	require.Equal(t, []string{"second.example.com:53"}, response.Candidates[2].PacketFirstHops)
+}
+
+func Test_ParseTunnelConfig_ListCandidatesUDPOverTCP(t *testing.T) {
+	result := ParseTunnelConfigWithOptions(`
+transport:
+  - $type: unsupported
+  - $type: tcpudp
+    tcp:
+      $type: shadowsocks
+      endpoint: example.com:80
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET
+    udp:
+      $type: shadowsocks
+      endpoint:
+        $type: websocket
+        url: wss://udp.example.com/packets
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET`, &ParseOptions{ListCandidates: true})
+
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Len(t, response.Candidates, 2)
+	// The packets go over a stream, so the device only sends TCP to the stream hop, as in the response.
+	require.Equal(t, "example.com:80", response.Candidates[1].FirstHop)
+	require.Equal(t, response.FirstHop, response.Candidates[1].FirstHop)
+	require.Equal(t, "udp.example.com:443", response.Candidates[1].PacketFirstHop)
+}
+
+func Test_doParseTunnelConfig_TransportListAllFail(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  - $type: unsupported
+  - ss://invalid`)
+
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "all transports failed", result.Error.Message)
+	failures, ok := result.Error.Details["failures"].([]any)
+	require.True(t, ok)
+	require.Len(t, failures, 2)
+	require.Equal(t, 0, failures[0].(platerrors.ErrorDetails)["index"])
+	require.Equal(t, 1, failures[1].(platerrors.ErrorDetails)["index"])
+}
+
+func Test_ValidateTunnelConfig(t *testing.T) {
+	result := ValidateTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error)
+	require.Equal(t,
+		"{\"firstHop\":\"example.com:4321\",\"streamFirstHop\":\"example.com:4321\",\"packetFirstHop\":\"example.com:4321\"}",
+		result.Value)
+}
+
+func Test_ValidateTunnelConfig_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"ss://invalid",
+		`{"server": "example.com", "server_port": 4321, "method": "bad-cipher", "password": "SECRET"}`,
+		"transport: {$type: unsupported}",
+	} {
+		result := ValidateTunnelConfig(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code, input)
+	}
+}
+
+func Test_doParseTunnelConfig_SyntaxErrorPosition(t *testing.T) {
+	result := doParseTunnelConfig(`transport:
+  $type: tcpudp
+  tcp: [ss://example.com:80
+`)
+
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Contains(t, result.Error.Message, "failed to parse")
+	require.NotContains(t, result.Error.Message, "\n")
+	require.Equal(t, 3, result.Error.Details["line"])
+	require.Equal(t, 8, result.Error.Details["column"])
+}
+
+func Test_ParseTunnelConfigContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	result := ParseTunnelConfigContext(ctx, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", nil)
+	require.Less(t, time.Since(start), time.Second)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.OperationCanceled, result.Error.Code)
+}
+
+func Test_ParseTunnelConfigContext_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	result := ParseTunnelConfigContext(ctx, `
+transport:
+  - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+  - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4322/`, nil)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.OperationTimedOut, result.Error.Code)
+}
+
+func Test_doParseTunnelConfig_ConnectTimeout(t *testing.T) {
+	result := doParseTunnelConfig(`
+connectTimeoutMs: 5000
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+	require.Nil(t, result.Error)
+
+	result = doParseTunnelConfig(`
+connectTimeoutMs: -1
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+}
+
+func Test_newClientWithTimeout_Expired(t *testing.T) {
+	defer ClearTunnelConfigCache()
+	// A zero timeout expires before the client is created.
+	_, _, perr := newClientWithTimeout(context.Background(), []string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+	}, clientOptions{}, 0)
+	require.NotNil(t, perr)
+	require.Equal(t, platerrors.OperationTimedOut, perr.Code)
+	require.Equal(t, "connecting to the first hop timed out after 0ms", perr.Message)
+}
+
+func Test_doParseTunnelConfig_Split(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: split
+    bytes: 3
+    dialer:
+      $type: shadowsocks
+      endpoint: example.com:4321
+      cipher: chacha20-ietf-poly1305
+      secret: SECRET
+  udp:
+    $type: disabled`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:4321", response.FirstHop)
+	require.Equal(t, "tcpudp", response.TransportType)
+	require.True(t, response.Fragmented)
+
+	result = doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error)
+	require.NotContains(t, result.Value, "fragmented")
+}
+
+func Test_doParseTunnelConfig_CipherInfo(t *testing.T) {
+	for _, input := range []string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+		`{"server": "example.com", "server_port": 4321, "method": "AEAD_CHACHA20_POLY1305", "password": "SECRET"}`,
+		`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`,
+	} {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.Equal(t, "chacha20-ietf-poly1305", response.Cipher)
+		require.Equal(t, 32, response.KeyBytes)
+		require.NotContains(t, result.Value[strings.Index(result.Value, `"transportType"`):], "SECRET")
+	}
+
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: aes-128-gcm
+    secret: SECRET
+  udp:
+    $type: disabled`)
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "aes-128-gcm", response.Cipher)
+	require.Equal(t, 16, response.KeyBytes)
+}
+
+func Test_doParseTunnelConfig_ConfigID(t *testing.T) {
+	link := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"
+	var fromLink, fromYAML, other TunnelConfig
+	for input, response := range map[string]*TunnelConfig{
+		link:                                     &fromLink,
+		"transport: " + link:                     &fromYAML,
+		strings.Replace(link, "4321", "4322", 1): &other,
+	} {
+		result := doParseTunnelConfig(input)
+		require.Nil(t, result.Error)
+		require.NoError(t, json.Unmarshal([]byte(result.Value), response))
+	}
+
+	require.Len(t, fromLink.ConfigID, 64)
+	require.Equal(t, fromLink.ConfigID, fromYAML.ConfigID)
+	require.NotEqual(t, fromLink.ConfigID, other.ConfigID)
+}
+
+func Test_detectTransportType(t *testing.T) {
+	for _, tc := range []struct {
+		transport    string
+		expectedType string
+	}{
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/", "shadowsocks"},
+		{`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "SECRET"}`, "shadowsocks"},
+		{"$type: tcpudp\ntcp: null\nudp: null", "tcpudp"},
+		{"[1, 2]", ""},
+	} {
+		transportType := detectTransportType(tc.transport)
+		require.Equal(t, tc.expectedType, transportType, tc.transport)
+		require.NotContains(t, transportType, "SECRET")
+	}
+}
+
+func Test_ResolveFirstHop(t *testing.T) {
+	result := ResolveFirstHop("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, "example.com:4321", result.Value)
+
+	result = ResolveFirstHop(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:53
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, "example.com:80", result.Value)
+}
+
+func Test_ResolveFirstHop_Errors(t *testing.T) {
+	for _, input := range []string{
+		`{"server": "example.com", "server_port": 4321, "method": "bad-cipher", "password": "SECRET"}`,
+		"transport: {$type: unsupported}",
+		"error: {message: Unauthorized}",
+		"vmess://example",
+	} {
+		result := ResolveFirstHop(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, doParseTunnelConfig(input).Error, result.Error, input)
+	}
+}
+
+func Benchmark_ResolveFirstHop(b *testing.B) {
+	benchmarkParse(b, ResolveFirstHop)
+}
+
+func Benchmark_doParseTunnelConfig(b *testing.B) {
+	benchmarkParse(b, doParseTunnelConfig)
+}
+
+// Benchmark_ParseTunnelConfig and Benchmark_ParseTunnelConfigBytes compare the allocations of the
+// string and bytes entry points. The bytes aren't copied, so both should allocate the same.
+func Benchmark_ParseTunnelConfig(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkParse(b, func(input string) *InvokeMethodResult {
+		_, perr := ParseTunnelConfig(input)
+		return &InvokeMethodResult{Error: perr}
+	})
+}
+
+func Benchmark_ParseTunnelConfigBytes(b *testing.B) {
+	b.ReportAllocs()
+	// The input is converted once, so that the conversion isn't measured.
+	input := []byte(benchmarkParseInput)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ClearTunnelConfigCache()
+		if _, perr := ParseTunnelConfigBytes(input); perr != nil {
+			b.Fatal(perr)
+		}
+	}
+}
+
+const benchmarkParseInput = `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: shadowsocks
+    endpoint: example.com:53
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET`
+
+func benchmarkParse(b *testing.B, parse func(string) *InvokeMethodResult) {
+	for i := 0; i < b.N; i++ {
+		// The cache would make every iteration but the first trivial.
+		ClearTunnelConfigCache()
+		if result := parse(benchmarkParseInput); result.Error != nil {
+			b.Fatal(result.Error)
+		}
+	}
+}
+
+func Test_ParseTunnelConfigBytes(t *testing.T) {
+	input := "\ufeff  transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\r\n"
+	expected, perr := ParseTunnelConfig(input)
+	require.Nil(t, perr)
+	inputBytes := []byte(input)
+	tunnelConfig, perr := ParseTunnelConfigBytes(inputBytes)
+	require.Nil(t, perr)
+	require.Equal(t, expected, tunnelConfig)
+
+	// The result doesn't depend on the input bytes after the call.
+	clear(inputBytes)
+	require.Equal(t, expected, tunnelConfig)
+
+	// Nor do the results of the formats whose transport is the input, or the clients cached for them.
+	for _, input := range []string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/#Home",
+		`{"server": "example.com", "server_port": 4321, "method": "chacha20-ietf-poly1305", "password": "SECRET"}`,
+	} {
+		ClearTunnelConfigCache()
+		inputBytes := []byte(input)
+		tunnelConfig, perr := ParseTunnelConfigBytes(inputBytes)
+		require.Nil(t, perr, input)
+		clear(inputBytes)
+		expected, perr := ParseTunnelConfig(input)
+		require.Nil(t, perr, input)
+		require.Equal(t, expected, tunnelConfig, input)
+		require.Len(t, parsedClients.index, 1, input)
+	}
+
+	oversized := bytes.Repeat([]byte(" "), defaultMaxTunnelConfigSize+1)
+	_, perr = ParseTunnelConfigBytes(oversized)
+	require.NotNil(t, perr)
+	require.Equal(t, platerrors.ReasonTooLarge, perr.Details["reason"])
+}
+
+func Test_ParseTunnelConfig_Typed(t *testing.T) {
+	input := `
+name: Home server
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`
+	tunnelConfig, perr := ParseTunnelConfig(input)
+	require.Nil(t, perr, "Got %v", perr)
+	require.Equal(t, "example.com:80", tunnelConfig.FirstHop)
+	require.Equal(t, "Home server", tunnelConfig.Name)
+
+	// The JSON entry point returns the same result.
+	result := doParseTunnelConfig(input)
+	require.Nil(t, result.Error)
+	expected, err := json.Marshal(tunnelConfig)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), result.Value)
+
+	_, perr = ParseTunnelConfig("transport: ss://invalid")
+	require.NotNil(t, perr)
+	require.Equal(t, doParseTunnelConfig("transport: ss://invalid").Error, perr)
+}
+
+func Test_ParseTunnelConfig_NoResolve(t *testing.T) {
+	// Simulate an environment without network, where any DNS lookup fails.
+	systemResolver := net.DefaultResolver
+	t.Cleanup(func() { net.DefaultResolver = systemResolver })
+	net.DefaultResolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			t.Errorf("unexpected DNS connection to %v", address)
+			return nil, errors.New("no network")
+		},
+	}
+	ClearTunnelConfigCache()
+	t.Cleanup(ClearTunnelConfigCache)
+
+	// The address family and resolver would require DNS lookups.
+	input := `
+addressFamily: ipv4
+resolver:
+  address: 127.0.0.1:1
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`
+	result := ParseTunnelConfigWithOptions(input, &ParseOptions{NoResolve: true, ResolveFirstHopAddresses: true})
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:443", response.FirstHop)
+	require.Empty(t, response.FirstHopAddresses)
+
+	result = ParseTunnelConfigWithOptions("https://example.com/config.yaml", &ParseOptions{NoResolve: true})
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "config URLs can't be fetched without network access",
+		Details: platerrors.ErrorDetails{"reason": platerrors.ReasonNotAllowed},
+	}, result.Error)
+}
+
+func Test_ParseTunnelConfigs(t *testing.T) {
+	results := ParseTunnelConfigs([]string{
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+		`
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:80
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`,
+		"transport: [unbalanced",
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+	})
+	require.Len(t, results, 4)
+
+	firstHops := make([]string, 0, len(results))
+	for i, result := range results {
+		if i == 2 {
+			require.NotNil(t, result.Error)
+			require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+			continue
+		}
+		require.Nil(t, result.Error, "Got %v", result.Error)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		firstHops = append(firstHops, response.FirstHop)
+	}
+	require.Equal(t, []string{"example.com:4321", "example.com:80", "example.com:4321"}, firstHops)
+	require.Equal(t, results[0], results[3])
+}
+
+func Test_ParseTunnelConfigDocuments(t *testing.T) {
+	results := ParseTunnelConfigDocuments(`# Servers of the team.
+name: First
+transport: ss://chacha20-ietf-poly1305:SECRET@first.example.com:4321
+---
+name: Invalid
+transport:
+---
+name: Third
+transport: ss://chacha20-ietf-poly1305:SECRET@third.example.com:4321
+...
+`)
+	require.Len(t, results, 3)
+
+	require.Nil(t, results[0].Error)
+	var first TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(results[0].Value), &first))
+	require.Equal(t, "First", first.Name)
+	require.Equal(t, "first.example.com:4321", first.FirstHop)
+
+	require.NotNil(t, results[1].Error)
+	require.Equal(t, platerrors.InvalidConfig, results[1].Error.Code)
+	require.Equal(t, "transport is required and must not be empty", results[1].Error.Message)
+
+	require.Nil(t, results[2].Error)
+	var third TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(results[2].Value), &third))
+	require.Equal(t, "Third", third.Name)
+	require.Equal(t, "third.example.com:4321", third.FirstHop)
+}
+
+func Test_ParseTunnelConfigDocuments_SingleDocument(t *testing.T) {
+	for _, input := range []string{
+		"transport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321",
+		"---\ntransport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321",
+		"ss://chacha20-ietf-poly1305:SECRET@example.com:4321",
+		"",
+	} {
+		results := ParseTunnelConfigDocuments(input)
+		require.Equal(t, []*InvokeMethodResult{doParseTunnelConfig(input)}, results, input)
+	}
+}
+
+func Test_splitYAMLDocuments(t *testing.T) {
+	require.Equal(t, []string{"a: 1", "b: 2", "c: 3"}, splitYAMLDocuments("---\na: 1\n--- # second\n---\nb: 2\n--- c: 3\n..."))
+	require.Equal(t, []string{"key: |\n  ---\n  text"}, splitYAMLDocuments("key: |\n  ---\n  text"))
+	require.Empty(t, splitYAMLDocuments("# nothing\n---\n"))
+}
+
+func Test_ParseTunnelConfigs_Empty(t *testing.T) {
+	require.Empty(t, ParseTunnelConfigs(nil))
+}
+
+func Test_doParseTunnelConfig_BOM(t *testing.T) {
+	result := doParseTunnelConfig("\ufeffss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\r\n")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"), result)
+}
+
+func Test_doParseTunnelConfig_CRLF(t *testing.T) {
+	input := `
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared
+`
+	result := doParseTunnelConfig("\ufeff" + strings.ReplaceAll(input, "\n", "\r\n"))
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.NotContains(t, result.Value, `\r`)
+	require.Equal(t, doParseTunnelConfig(input), result)
+}
+
+func Test_doParseTunnelConfig_DuplicateKey(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:1234/`)
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: `duplicate key "transport"`,
+		Details: platerrors.ErrorDetails{
+			"field": "transport", "reason": platerrors.ReasonDuplicateKey, "key": "transport", "line": 2, "column": 1,
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_MaxSize(t *testing.T) {
+	input := "transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/\n"
+	oversized := input + "# " + strings.Repeat("x", defaultMaxTunnelConfigSize)
+	result := doParseTunnelConfig(oversized)
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("config exceeds the maximum size of %d bytes", defaultMaxTunnelConfigSize),
+		Details: platerrors.ErrorDetails{
+			"reason": platerrors.ReasonTooLarge, "maxSize": int64(defaultMaxTunnelConfigSize), "size": len(oversized),
+		},
+	}, result.Error)
+
+	t.Cleanup(func() { SetMaxTunnelConfigSize(defaultMaxTunnelConfigSize) })
+	SetMaxTunnelConfigSize(0)
+	require.Nil(t, doParseTunnelConfig(oversized).Error)
+	SetMaxTunnelConfigSize(len(input) - 1)
+	require.NotNil(t, doParseTunnelConfig(input).Error)
+}
+
+func Test_doParseTunnelConfig_MaxDepth(t *testing.T) {
+	for _, input := range []string{
+		// Flow sequences, as in a JSON array of arrays.
+		"transport: " + strings.Repeat("[", 10000) + strings.Repeat("]", 10000),
+		// Block mappings.
+		"transport:\n" + nestedBlockMapping(defaultMaxTunnelConfigDepth+1),
+	} {
+		result := doParseTunnelConfig(input)
+		require.Equal(t, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("config exceeds the maximum nesting depth of %d", defaultMaxTunnelConfigDepth),
+			Details: platerrors.ErrorDetails{"reason": platerrors.ReasonTooDeep, "maxDepth": int64(defaultMaxTunnelConfigDepth)},
+		}, result.Error)
+	}
+
+	// The limit counts the mappings and sequences, not the keys of a mapping.
+	t.Cleanup(func() { SetMaxTunnelConfigDepth(defaultMaxTunnelConfigDepth) })
+	SetMaxTunnelConfigDepth(3)
+	require.Nil(t, doParseTunnelConfig(versionTestTransport).Error)
+	SetMaxTunnelConfigDepth(2)
+	require.NotNil(t, doParseTunnelConfig(versionTestTransport).Error)
+	// Without a limit, the nested transport is decoded, and rejected for other reasons.
+	SetMaxTunnelConfigDepth(0)
+	result := doParseTunnelConfig("transport:\n" + nestedBlockMapping(100))
+	require.NotNil(t, result.Error)
+	require.NotEqual(t, platerrors.ReasonTooDeep, result.Error.Details["reason"])
+}
+
+// nestedBlockMapping returns a block mapping nested depth times.
+func nestedBlockMapping(depth int) string {
+	var builder strings.Builder
+	for i := 0; i < depth; i++ {
+		builder.WriteString(strings.Repeat(" ", i+1) + "a:\n")
+	}
+	return builder.String()
+}
+
+func Test_doParseTunnelConfig_MaxSizeAfterExpansion(t *testing.T) {
+	t.Setenv("OUTLINE_TEST_PADDING", strings.Repeat("x", defaultMaxTunnelConfigSize))
+	result := ParseTunnelConfigWithOptions(`
+name: ${OUTLINE_TEST_PADDING}
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`, &ParseOptions{ExpandEnv: true})
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, fmt.Sprintf("config exceeds the maximum size of %d bytes", defaultMaxTunnelConfigSize), result.Error.Message)
+}
+
+func Test_doParseTunnelConfig_WrongShape(t *testing.T) {
+	result := doParseTunnelConfig(`
+- transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "the config must be a mapping with a transport key, found a list",
+		Details: platerrors.ErrorDetails{"reason": platerrors.ReasonWrongShape, "found": "list"},
+	}, result.Error)
+
+	result = doParseTunnelConfig("just some text")
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "the config must be a mapping with a transport key, found a scalar",
+		Details: platerrors.ErrorDetails{"reason": platerrors.ReasonWrongShape, "found": "scalar"},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_Disabled(t *testing.T) {
+	result := doParseTunnelConfig(`
+enabled: false
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`)
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ConfigDisabled,
+		Message: "the config is disabled",
+	}, result.Error)
+
+	// The transport is not created, so it doesn't need to be valid.
+	result = doParseTunnelConfig(`
+enabled: false
+transport: {$type: unsupported}`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.ConfigDisabled, result.Error.Code)
+
+	for _, enabled := range []string{"enabled: true\n", ""} {
+		result = doParseTunnelConfig(enabled + "transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+		require.Nil(t, result.Error, "Got %v", result.Error)
+	}
+}
+
+func Test_ParseTunnelConfig_Strict(t *testing.T) {
+	input := `
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+nmae: My server
+transprot: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.org:4321/`
+
+	// Unknown keys are ignored by default.
+	result := doParseTunnelConfig(input)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+
+	result = ParseTunnelConfigWithOptions(input, &ParseOptions{Strict: true})
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "unknown top-level keys: nmae, transprot",
+		Details: platerrors.ErrorDetails{"reason": platerrors.ReasonUnknownKeys, "unknownKeys": []string{"nmae", "transprot"}},
+	}, result.Error)
+
+	result = ParseTunnelConfigWithOptions(`
+name: My server
+tags: [work]
+connectTimeoutMs: 5000
+transport: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/`, &ParseOptions{Strict: true})
+	require.Nil(t, result.Error, "Got %v", result.Error)
+}
+
 func Test_doParseTunnelConfig_ProviderError(t *testing.T) {
 	result := doParseTunnelConfig(`
 error:
@@ -93,6 +1527,137 @@ func Test_doParseTunnelConfig_ProviderErrorJSON(t *testing.T) {
 	}, result.Error)
 }
 
+func Test_doParseTunnelConfig_ProviderErrorStructuredDetails(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Too many requests
+  details: '{"retryAfter": 30, "supportUrl": "https://example.com/support"}'
+`)
+
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Too many requests",
+		Details: map[string]any{
+			"retryAfter": float64(30),
+			"supportUrl": "https://example.com/support",
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_ProviderErrorNonObjectJSONDetails(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Unauthorized
+  details: '[1, 2]'
+`)
+
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Unauthorized",
+		Details: map[string]any{
+			"details": "[1, 2]",
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_ProviderErrorRetryAfter(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Too many requests
+  retryAfter: 30
+`)
+
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Too many requests",
+		Details: map[string]any{
+			"retryAfterSeconds": float64(30),
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_ProviderErrorRetryAfterWithDetails(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Too many requests
+  details: Slow down
+  retryAfter: 1.5
+`)
+
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Too many requests",
+		Details: map[string]any{
+			"details":           "Slow down",
+			"retryAfterSeconds": 1.5,
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_ProviderErrorCode(t *testing.T) {
+	for _, tc := range []struct {
+		providerCode string
+		code         platerrors.ErrorCode
+	}{
+		{"unauthorized", platerrors.Unauthenticated},
+		{"expired", platerrors.ConfigExpired},
+		{"Expired", platerrors.ConfigExpired},
+		{"disabled", platerrors.ConfigDisabled},
+	} {
+		result := doParseTunnelConfig(`
+error:
+  message: Your key has expired
+  details: Renew it
+  code: ` + tc.providerCode)
+
+		require.Equal(t, &platerrors.PlatformError{
+			Code:    tc.code,
+			Message: "Your key has expired",
+			Details: map[string]any{
+				"details":      "Renew it",
+				"providerCode": tc.providerCode,
+			},
+		}, result.Error)
+	}
+}
+
+func Test_doParseTunnelConfig_ProviderErrorUnknownCode(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Over quota
+  code: quota-exceeded
+`)
+
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Over quota",
+		Details: map[string]any{
+			"providerCode": "quota-exceeded",
+		},
+	}, result.Error)
+}
+
+func Test_doParseTunnelConfig_ProviderErrorStructuredDetailsWithCode(t *testing.T) {
+	result := doParseTunnelConfig(`
+error:
+  message: Over quota
+  details: '{"quotaBytes": 1000, "providerCode": "ignored"}'
+  code: quota-exceeded
+  retryAfter: 60
+`)
+
+	// The keys set by the app take precedence over those of the provider details.
+	require.Equal(t, &platerrors.PlatformError{
+		Code:    platerrors.ProviderError,
+		Message: "Over quota",
+		Details: map[string]any{
+			"quotaBytes":        float64(1000),
+			"providerCode":      "quota-exceeded",
+			"retryAfterSeconds": float64(60),
+		},
+	}, result.Error)
+}
+
 func Test_doParseTunnelConfig_ProviderErrorUTF8(t *testing.T) {
 	result := doParseTunnelConfig(`
 error:
@@ -108,3 +1673,81 @@ error:
 		},
 	}, result.Error)
 }
+
+func Test_normalizeTransportNode_PartialNode(t *testing.T) {
+	// An anchor without a value, as left by some malformed inputs.
+	node := &ast.AnchorNode{
+		BaseNode: &ast.BaseNode{},
+		Start:    token.New("&", "&", &token.Position{}),
+		Name:     ast.String(token.New("shared", "shared", &token.Position{})),
+	}
+	_, err := normalizeTransportNode(node)
+	require.ErrorContains(t, err, "incomplete YAML node")
+}
+
+func Test_validateTransportText(t *testing.T) {
+	require.Nil(t, validateTransportText("ss://chacha20-ietf-poly1305:SECRET@example.com:4321"))
+	require.Nil(t, validateTransportText("$type: tcpudp\ntcp: ss://chacha20-ietf-poly1305:SECRET@example.com:4321"))
+
+	for text, kind := range map[string]string{
+		"12":                "a number",
+		"true":              "a boolean",
+		"[[]]":              "a list",
+		"!!binary aGVsbG8=": "binary data",
+		"''":                "an empty string",
+	} {
+		perr := validateTransportText(text)
+		require.NotNil(t, perr, text)
+		require.Equal(t, platerrors.InvalidConfig, perr.Code)
+		require.Equal(t, "transport must be a mapping or a link, found "+kind, perr.Message)
+		require.Equal(t, platerrors.ErrorDetails{"field": "transport", "reason": platerrors.ReasonWrongShape}, perr.Details)
+	}
+}
+
+func Test_doParseTunnelConfig_TransportWrongShape(t *testing.T) {
+	for _, input := range []string{"transport: 12", "transport: [[]]", "transport:\n  - ss://chacha20-ietf-poly1305:SECRET@example.com:4321\n  - true"} {
+		result := doParseTunnelConfig(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Contains(t, result.Error.Message, "transport must be a mapping or a link", input)
+	}
+}
+
+func Test_doParseTunnelConfig_ProbeOrder(t *testing.T) {
+	for _, probeOrder := range []string{"tcp", "udp"} {
+		t.Run(probeOrder, func(t *testing.T) {
+			tunnelConfig, perr := parseTunnelConfig(context.Background(),
+				"probeOrder: "+probeOrder+"\ntransport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321", ParseOptions{})
+			require.Nil(t, perr)
+			require.Equal(t, probeOrder, tunnelConfig.ProbeOrder)
+
+			client, _, perr := newClientFromFallbacks(context.Background(), []string{tunnelConfig.Transport}, clientOptions{probeOrder: probeOrder})
+			require.Nil(t, perr)
+			require.Equal(t, probeOrder, client.opts.probeOrder)
+		})
+	}
+
+	result := doParseTunnelConfig("transport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321")
+	require.Nil(t, result.Error)
+	require.NotContains(t, result.Value, "probeOrder")
+
+	result = doParseTunnelConfig("probeOrder: sctp\ntransport: ss://chacha20-ietf-poly1305:SECRET@example.com:4321")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, `probeOrder must be tcp or udp, found "sctp"`, result.Error.Message)
+}
+
+func Test_doParseTunnelConfig_Keepalive(t *testing.T) {
+	transportConfig := "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/"
+	result := doParseTunnelConfig("keepaliveSeconds: 30\ntransport: " + transportConfig)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	// The client is created with the keepalive, as the key of the cache tells.
+	client, ok := parsedClients.get(clientCacheKey{transportConfig, clientOptions{keepalive: 30 * time.Second}})
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, client.opts.keepalive)
+
+	result = doParseTunnelConfig("keepaliveSeconds: -1\ntransport: " + transportConfig)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, platerrors.ErrorDetails{"field": "keepaliveSeconds", "reason": platerrors.ReasonInvalidValue}, result.Error.Details)
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platerrors
+
+// The types below define the [ErrorDetails] of each error code. Errors set their Details with the
+// ToErrorDetails method of the type of their code, so the keys documented for a code are the same
+// on every error path. Keys are omitted when unknown.
+
+// InvalidConfigReason identifies why a config is invalid, in the Details of [InvalidConfig] errors.
+type InvalidConfigReason = string
+
+const (
+	// ReasonSyntax means that the config is not valid YAML or JSON.
+	ReasonSyntax InvalidConfigReason = "syntax"
+	// ReasonDuplicateKey means that a mapping repeats a key.
+	ReasonDuplicateKey InvalidConfigReason = "duplicate-key"
+	// ReasonWrongShape means that the config is not a mapping, like a list or a scalar.
+	ReasonWrongShape InvalidConfigReason = "wrong-shape"
+	// ReasonUnknownKeys means that the config has top-level keys that are not part of the format.
+	ReasonUnknownKeys InvalidConfigReason = "unknown-keys"
+	// ReasonMissing means that a required field is absent or empty.
+	ReasonMissing InvalidConfigReason = "missing"
+	// ReasonInvalidValue means that a field has a value that is not accepted.
+	ReasonInvalidValue InvalidConfigReason = "invalid-value"
+	// ReasonTooLarge means that the config exceeds the size limit.
+	ReasonTooLarge InvalidConfigReason = "too-large"
+	// ReasonTooDeep means that the config nests mappings and sequences deeper than the limit, given
+	// in the "maxDepth" detail.
+	ReasonTooDeep InvalidConfigReason = "too-deep"
+	// ReasonUnsupported means that the config uses a feature the app doesn't support, like a URL
+	// scheme, a plugin or a newer version of the format.
+	ReasonUnsupported InvalidConfigReason = "unsupported"
+	// ReasonNotAllowed means that the config is valid but not allowed in this context, like a file
+	// outside the allowed directory.
+	ReasonNotAllowed InvalidConfigReason = "not-allowed"
+	// ReasonInvalidTransport means that the transport couldn't be created from the config.
+	ReasonInvalidTransport InvalidConfigReason = "invalid-transport"
+	// ReasonConflict means that the config combines options that exclude each other, which are
+	// listed in the "conflictingKeys" detail.
+	ReasonConflict InvalidConfigReason = "conflict"
+	// ReasonWarnings means that the config has warnings, listed in the "warnings" detail, and the
+	// parse was set to treat them as errors.
+	ReasonWarnings InvalidConfigReason = "warnings"
+	// ReasonInsecureCipher means that the config uses a Shadowsocks cipher that is known to be
+	// broken, given in the "cipher" detail along with its "severity".
+	ReasonInsecureCipher InvalidConfigReason = "insecure-cipher"
+)
+
+// InvalidConfigDetails are the Details of [InvalidConfig] errors.
+//
+//   - "field": the config key at fault, like "addressFamily", if the error is about one.
+//   - "reason": one of the InvalidConfigReason constants.
+//
+// Reasons may add more keys, like "unknownKeys" for [ReasonUnknownKeys], in Extra. Errors creating a
+// layered transport add "failedLayer", the $type of the layer at fault, and "layerIndex", its
+// position from the first hop inward, if known.
+type InvalidConfigDetails struct {
+	Field  string
+	Reason InvalidConfigReason
+	Extra  ErrorDetails
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d InvalidConfigDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(d.Extra)
+	setDetail(details, "field", d.Field)
+	setDetail(details, "reason", d.Reason)
+	return details
+}
+
+// ProviderErrorDetails are the Details of [ProviderError] errors.
+//
+//   - "details": the details text of the error returned by the provider.
+//   - "retryAfterSeconds": how long to wait before fetching the config again, as requested by the
+//     provider.
+//   - "url", "status" and "statusCode": the URL and HTTP status, if the provisioning endpoint
+//     rejected the request.
+//   - "providerCode": the code of the error envelope, if set. Recognized codes return a more specific
+//     error code than ProviderError, with the same Details.
+type ProviderErrorDetails struct {
+	Details           string
+	RetryAfterSeconds *float64
+	URL               string
+	Status            string
+	StatusCode        int
+	ProviderCode      string
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d ProviderErrorDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(nil)
+	setDetail(details, "details", d.Details)
+	if d.RetryAfterSeconds != nil {
+		details["retryAfterSeconds"] = *d.RetryAfterSeconds
+	}
+	setDetail(details, "url", d.URL)
+	setDetail(details, "status", d.Status)
+	setDetail(details, "statusCode", d.StatusCode)
+	setDetail(details, "providerCode", d.ProviderCode)
+	return details
+}
+
+// FetchConfigFailedDetails are the Details of [FetchConfigFailed] errors.
+//
+//   - "url": the URL of the config, if it's fetched from the network.
+//   - "path": the path of the config, if it's read from a file.
+//   - "status" and "body": the HTTP status and response, if the server rejected the request.
+type FetchConfigFailedDetails struct {
+	URL    string
+	Path   string
+	Status string
+	Body   string
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d FetchConfigFailedDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(nil)
+	setDetail(details, "url", d.URL)
+	setDetail(details, "path", d.Path)
+	setDetail(details, "status", d.Status)
+	setDetail(details, "body", d.Body)
+	return details
+}
+
+// ResolveIPFailedDetails are the Details of [ResolveIPFailed] errors.
+//
+//   - "host": the host name that failed to resolve.
+type ResolveIPFailedDetails struct {
+	Host string
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d ResolveIPFailedDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(nil)
+	setDetail(details, "host", d.Host)
+	return details
+}
+
+// OperationTimedOutDetails are the Details of [OperationTimedOut] errors.
+//
+//   - "connectTimeoutMs": the timeout that expired, in milliseconds.
+type OperationTimedOutDetails struct {
+	ConnectTimeoutMs int64
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d OperationTimedOutDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(nil)
+	setDetail(details, "connectTimeoutMs", d.ConnectTimeoutMs)
+	return details
+}
+
+// HealthCheckFailedDetails are the Details of [HealthCheckFailed] errors.
+//
+//   - "url": the health check URL.
+//   - "status" and "statusCode": the HTTP status returned by the URL.
+type HealthCheckFailedDetails struct {
+	URL        string
+	Status     string
+	StatusCode int
+}
+
+// ToErrorDetails returns d as the Details of a [PlatformError].
+func (d HealthCheckFailedDetails) ToErrorDetails() ErrorDetails {
+	details := newDetails(nil)
+	setDetail(details, "url", d.URL)
+	setDetail(details, "status", d.Status)
+	setDetail(details, "statusCode", d.StatusCode)
+	return details
+}
+
+// newDetails returns a copy of extra, to add the typed keys to.
+func newDetails(extra ErrorDetails) ErrorDetails {
+	details := make(ErrorDetails, len(extra)+2)
+	for key, value := range extra {
+		details[key] = value
+	}
+	return details
+}
+
+// setDetail sets the key of details to value, unless it's the zero value.
+func setDetail[T comparable](details ErrorDetails, key string, value T) {
+	var zero T
+	if value != zero {
+		details[key] = value
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package platerrors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvalidConfigDetails(t *testing.T) {
+	require.Equal(t, ErrorDetails{"field": "addressFamily", "reason": ReasonInvalidValue},
+		InvalidConfigDetails{Field: "addressFamily", Reason: ReasonInvalidValue}.ToErrorDetails())
+	require.Equal(t, ErrorDetails{"reason": ReasonUnknownKeys, "unknownKeys": []string{"a"}},
+		InvalidConfigDetails{Reason: ReasonUnknownKeys, Extra: ErrorDetails{"unknownKeys": []string{"a"}}}.ToErrorDetails())
+	require.Empty(t, InvalidConfigDetails{}.ToErrorDetails())
+}
+
+func TestInvalidConfigDetails_DoesNotChangeExtra(t *testing.T) {
+	extra := ErrorDetails{"found": "list"}
+	InvalidConfigDetails{Reason: ReasonWrongShape, Extra: extra}.ToErrorDetails()
+	require.Equal(t, ErrorDetails{"found": "list"}, extra)
+}
+
+func TestProviderErrorDetails(t *testing.T) {
+	retryAfter := 0.0
+	require.Equal(t, ErrorDetails{"details": "Slow down", "retryAfterSeconds": 0.0},
+		ProviderErrorDetails{Details: "Slow down", RetryAfterSeconds: &retryAfter}.ToErrorDetails())
+	require.Equal(t, ErrorDetails{"url": "https://example.com", "status": "403 Forbidden", "statusCode": 403},
+		ProviderErrorDetails{URL: "https://example.com", Status: "403 Forbidden", StatusCode: 403}.ToErrorDetails())
+	retryAfter = 60
+	require.Equal(t, ErrorDetails{"providerCode": "quota-exceeded", "retryAfterSeconds": 60.0},
+		ProviderErrorDetails{ProviderCode: "quota-exceeded", RetryAfterSeconds: &retryAfter}.ToErrorDetails())
+}
+
+func TestFetchConfigFailedDetails(t *testing.T) {
+	require.Equal(t, ErrorDetails{"path": "/config.yaml"}, FetchConfigFailedDetails{Path: "/config.yaml"}.ToErrorDetails())
+	require.Equal(t, ErrorDetails{"url": "https://example.com", "status": "500", "body": "oops"},
+		FetchConfigFailedDetails{URL: "https://example.com", Status: "500", Body: "oops"}.ToErrorDetails())
+}
+
+func TestResolveIPFailedDetails(t *testing.T) {
+	require.Equal(t, ErrorDetails{"host": "example.com"}, ResolveIPFailedDetails{Host: "example.com"}.ToErrorDetails())
+}
+
+func TestOperationTimedOutDetails(t *testing.T) {
+	require.Equal(t, ErrorDetails{"connectTimeoutMs": int64(100)}, OperationTimedOutDetails{ConnectTimeoutMs: 100}.ToErrorDetails())
+}
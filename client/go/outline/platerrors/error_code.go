@@ -34,6 +34,9 @@ const (
 
 	// OperationCanceled means that user canceled the long running operation.
 	OperationCanceled ErrorCode = "ERR_OPERATION_CANCELED_BY_USER"
+
+	// OperationTimedOut means that a long running operation did not complete within its deadline.
+	OperationTimedOut ErrorCode = "ERR_OPERATION_TIMED_OUT"
 )
 
 //////////
@@ -87,6 +90,10 @@ const (
 
 	// ProxyServerUDPUnsupported means the remote proxy doesn't support relaying UDP traffic.
 	ProxyServerUDPUnsupported ErrorCode = "ERR_PROXY_SERVER_UDP_NOT_SUPPORTED"
+
+	// HealthCheckFailed means the health check URL of the config returned a non-2xx status through
+	// the tunnel.
+	HealthCheckFailed ErrorCode = "ERR_HEALTH_CHECK_FAILURE"
 )
 
 //////////
@@ -102,4 +109,11 @@ const (
 
 	// InvalidConfig indicates an invalid config to connect to a remote server.
 	InvalidConfig ErrorCode = "ERR_INVALID_CONFIG"
+
+	// ConfigDisabled indicates the config was disabled by the provider with "enabled: false".
+	ConfigDisabled ErrorCode = "ERR_CONFIG_DISABLED"
+
+	// ConfigExpired indicates the provider reported that the config, or the subscription it belongs
+	// to, has expired.
+	ConfigExpired ErrorCode = "ERR_CONFIG_EXPIRED"
 )
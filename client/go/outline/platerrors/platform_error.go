@@ -93,6 +93,10 @@ func (e PlatformError) Unwrap() error {
 // MarshalJSONString returns a JSON string containing the [PlatformError] details
 // and all its underlying causes.
 // The resulting JSON can be used to reconstruct the error in TypeScript.
+//
+// The output is deterministic: the keys of Details, and of any map nested in them, are sorted, so
+// the same error always marshals to the same string. Error paths that build lists for Details from
+// maps must sort them too.
 func MarshalJSONString(e *PlatformError) (string, error) {
 	if e == nil {
 		return "", errors.New("a non-nil PlatformError is required")
@@ -88,6 +88,28 @@ func TestPlatformErrorJSONOutput(t *testing.T) {
 	}
 }
 
+func TestPlatformErrorJSONOutput_Deterministic(t *testing.T) {
+	details := ErrorDetails{}
+	for _, key := range []string{"zeta", "alpha", "mu", "beta", "omega", "kappa", "delta", "epsilon"} {
+		details[key] = map[string]interface{}{"z": 1, "a": 2, "m": 3}
+	}
+	e := &PlatformError{
+		Code:    "ERR_ORDER",
+		Message: "ordered details",
+		Details: details,
+		Cause:   &PlatformError{Code: "ERR_CAUSE", Message: "cause", Details: details},
+	}
+
+	first, err := MarshalJSONString(e)
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		got, err := MarshalJSONString(e)
+		require.NoError(t, err)
+		require.Equal(t, []byte(first), []byte(got))
+	}
+	require.Contains(t, first, `"details":{"alpha":{"a":2,"m":3,"z":1},"beta":`)
+}
+
 func TestPlatformErrorWrapsCause(t *testing.T) {
 	err := PlatformError{Code: "ERR_WRAP", Message: "should wrap"}
 	require.Nil(t, err.Unwrap())
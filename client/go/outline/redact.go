@@ -0,0 +1,161 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/goccy/go-yaml"
+)
+
+// redactedValue replaces the secrets in a redacted config.
+const redactedValue = "***"
+
+// secretConfigKeys are the config keys whose values are credentials, in lowercase.
+var secretConfigKeys = map[string]bool{"secret": true, "password": true, "username": true}
+
+// RedactConfig returns the input tunnel config with the credentials replaced by "***", so it can
+// be logged. The structure, hosts, ports and ciphers are kept. It accepts ss:// links, legacy JSON
+// configs and the advanced YAML format. JSON input is returned as JSON, and YAML input as YAML,
+// without the comments and anchors.
+func RedactConfig(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "ss://") {
+		redacted, perr := redactShadowsocksURL(input)
+		if perr != nil {
+			return "", perr
+		}
+		return redacted, nil
+	}
+
+	node, err := config.ParseConfigYAML(input)
+	if err != nil {
+		return "", newYAMLParseError(err)
+	}
+	redactedNode, perr := redactNode(node)
+	if perr != nil {
+		return "", perr
+	}
+	var out []byte
+	if strings.HasPrefix(input, "{") {
+		out, err = json.Marshal(redactedNode)
+	} else {
+		out, err = yaml.Marshal(redactedNode)
+	}
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to serialize the redacted config",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// redactNode returns a copy of the node with the values of the secret keys, and the credentials of
+// the embedded URLs, redacted.
+func redactNode(node config.ConfigNode) (config.ConfigNode, *platerrors.PlatformError) {
+	switch typed := node.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(typed))
+		for key, value := range typed {
+			if secretConfigKeys[strings.ToLower(key)] && value != nil {
+				redacted[key] = redactedValue
+				continue
+			}
+			child, perr := redactNode(value)
+			if perr != nil {
+				return nil, perr
+			}
+			redacted[key] = child
+		}
+		return redacted, nil
+	case []any:
+		redacted := make([]any, 0, len(typed))
+		for _, value := range typed {
+			child, perr := redactNode(value)
+			if perr != nil {
+				return nil, perr
+			}
+			redacted = append(redacted, child)
+		}
+		return redacted, nil
+	case string:
+		return redactURLString(typed)
+	default:
+		return node, nil
+	}
+}
+
+// redactURLString redacts the credentials of text if it's a ss:// link or a URL with userinfo.
+// Other strings are returned as is.
+func redactURLString(text string) (string, *platerrors.PlatformError) {
+	if strings.HasPrefix(text, "ss://") {
+		return redactShadowsocksURL(text)
+	}
+	if !strings.Contains(text, "://") {
+		return text, nil
+	}
+	parsed, err := url.Parse(text)
+	if err != nil || parsed.User == nil {
+		return text, nil
+	}
+	return withUserInfo(parsed, redactedValue), nil
+}
+
+// withUserInfo returns the URL with the given userinfo, which is written as is, since [url.URL]
+// would escape the "*" of the redacted value.
+func withUserInfo(u *url.URL, userInfo string) string {
+	withoutUser := *u
+	withoutUser.User = nil
+	return strings.Replace(withoutUser.String(), "//", "//"+userInfo+"@", 1)
+}
+
+// redactShadowsocksURL replaces the password of a ss:// link, keeping the cipher. Legacy links are
+// rewritten in the SIP002 format, since their host is base64-encoded with the password.
+func redactShadowsocksURL(link string) (string, *platerrors.PlatformError) {
+	if perr := validateShadowsocksURL(link); perr != nil {
+		return "", perr
+	}
+	ssConfig, err := config.ParseShadowsocksConfig(link)
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid Shadowsocks config",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	ssURL, err := url.Parse(link)
+	if err != nil {
+		return "", newInvalidShadowsocksURLError("invalid URL")
+	}
+	endpoint, _ := ssConfig.Endpoint.(string)
+	redacted := url.URL{
+		Scheme:   "ss",
+		Host:     endpoint,
+		Path:     ssURL.Path,
+		RawQuery: ssURL.RawQuery,
+		Fragment: ssURL.Fragment,
+	}
+	if redacted.Path == "" && redacted.RawQuery == "" {
+		redacted.Path = "/"
+	}
+	return withUserInfo(&redacted, url.User(ssConfig.Cipher).String()+":"+redactedValue), nil
+}
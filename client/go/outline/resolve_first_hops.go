@@ -0,0 +1,109 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+)
+
+// resolvedFirstHopsJson is the result of [Client.ResolveFirstHops].
+type resolvedFirstHopsJson struct {
+	StreamFirstHops []resolvedFirstHopJson `json:"streamFirstHops"`
+	PacketFirstHops []resolvedFirstHopJson `json:"packetFirstHops,omitempty"`
+}
+
+// resolvedFirstHopJson is a first hop as written in the config, and the endpoints it resolves to.
+type resolvedFirstHopJson struct {
+	FirstHop  string   `json:"firstHop"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// ResolveFirstHops resolves the first hops of the client again, with the resolver and address
+// family of its config, and returns the current endpoints, as when DNS answers change over the
+// life of a long-lived client. The client itself is not modified: callers compare the endpoints
+// with the ones in use to decide whether to reconnect.
+//
+// The resolution is bounded by timeoutMs, or by the default connect timeout if non-positive. The
+// result Value is a JSON object with the "streamFirstHops" and "packetFirstHops", each listing the
+// "firstHop" and its "endpoints" as IP:port.
+func (c *Client) ResolveFirstHops(timeoutMs int) *InvokeMethodResult {
+	timeout := defaultConnectTimeout
+	if timeoutMs > 0 {
+		timeout = time.Duration(timeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The transport is created again without resolution to get the first hops as written in the
+	// config, since the ones of the client may be already resolved.
+	unresolved, err := newClientWithBaseDialers(ctx, c.transportConfig, &transport.TCPDialer{}, &transport.UDPDialer{},
+		clientOptions{streamOnly: c.pl == nil, noResolve: true})
+	if err != nil {
+		return &InvokeMethodResult{Error: platerrors.ToPlatformError(err)}
+	}
+	var result resolvedFirstHopsJson
+	var perr *platerrors.PlatformError
+	if result.StreamFirstHops, perr = c.resolveFirstHops(ctx, unresolved.sd.AllFirstHops()); perr != nil {
+		return &InvokeMethodResult{Error: perr}
+	}
+	if unresolved.pl != nil && unresolved.pl.ConnType != config.ConnTypeDisabled {
+		if result.PacketFirstHops, perr = c.resolveFirstHops(ctx, unresolved.pl.AllFirstHops()); perr != nil {
+			return &InvokeMethodResult{Error: perr}
+		}
+	}
+	return marshalInvokeMethodResult(result)
+}
+
+// resolveFirstHops resolves each of the host:port first hops to its endpoints.
+func (c *Client) resolveFirstHops(ctx context.Context, firstHops []string) ([]resolvedFirstHopJson, *platerrors.PlatformError) {
+	resolved := make([]resolvedFirstHopJson, 0, len(firstHops))
+	for _, firstHop := range firstHops {
+		_, port, err := net.SplitHostPort(firstHop)
+		if err != nil {
+			continue
+		}
+		addresses, perr := lookupFirstHopAddresses(ctx, c.opts, firstHop)
+		if perr != nil {
+			return nil, perr
+		}
+		endpoints := make([]string, 0, len(addresses))
+		for _, address := range addresses {
+			if inAddressFamily(address, c.opts.addressFamily) {
+				endpoints = append(endpoints, net.JoinHostPort(address, port))
+			}
+		}
+		resolved = append(resolved, resolvedFirstHopJson{FirstHop: firstHop, Endpoints: endpoints})
+	}
+	return resolved, nil
+}
+
+// inAddressFamily returns whether the IP address belongs to the address family, if restricted.
+func inAddressFamily(address string, family config.AddressFamily) bool {
+	ip := net.ParseIP(address)
+	switch family {
+	case config.AddressFamilyIPv4:
+		return ip.To4() != nil
+	case config.AddressFamilyIPv6:
+		return ip.To4() == nil
+	default:
+		return true
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ResolveFirstHops(t *testing.T) {
+	var answer atomic.Value
+	answer.Store(netip.MustParseAddr("192.0.2.10"))
+	resolver := config.ResolverConfig{Address: startStubDNSServer(t, func() netip.Addr { return answer.Load().(netip.Addr) })}
+
+	result := newClient(context.Background(), "ss://chacha20-ietf-poly1305:SECRET@proxy.invalid:4321", clientOptions{resolver: resolver})
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	require.Equal(t, "192.0.2.10:4321", result.Client.sd.FirstHop)
+
+	// The DNS answer changes after the client is created.
+	answer.Store(netip.MustParseAddr("192.0.2.20"))
+	resolved := result.Client.ResolveFirstHops(1000)
+	require.Nil(t, resolved.Error, "Got %v", resolved.Error)
+	var firstHops resolvedFirstHopsJson
+	require.NoError(t, json.Unmarshal([]byte(resolved.Value), &firstHops))
+	expected := []resolvedFirstHopJson{{FirstHop: "proxy.invalid:4321", Endpoints: []string{"192.0.2.20:4321"}}}
+	require.Equal(t, resolvedFirstHopsJson{StreamFirstHops: expected, PacketFirstHops: expected}, firstHops)
+	// The client keeps its endpoints.
+	require.Equal(t, "192.0.2.10:4321", result.Client.sd.FirstHop)
+}
+
+func TestClient_ResolveFirstHops_Timeout(t *testing.T) {
+	// A resolver that never answers.
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := &Client{
+		sd:              &config.Dialer[transport.StreamConn]{},
+		transportConfig: "ss://chacha20-ietf-poly1305:SECRET@proxy.invalid:4321",
+		opts:            clientOptions{resolver: config.ResolverConfig{Address: conn.LocalAddr().String()}},
+	}
+	result := client.ResolveFirstHops(100)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.OperationTimedOut, result.Error.Code)
+}
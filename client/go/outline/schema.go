@@ -0,0 +1,156 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+)
+
+// knownTransportShapes maps each $type supported by the advanced config to the struct it's decoded into.
+var knownTransportShapes = map[string]any{
+	"chain":           config.ChainConfig{},
+	"dial":            config.DialEndpointConfig{},
+	"disabled":        struct{}{},
+	"first-supported": config.FirstSupportedConfig{},
+	"http-connect":    config.HTTPConnectConfig{},
+	"shadowsocks":     config.ShadowsocksConfig{},
+	"socks5":          config.Socks5Config{},
+	"split":           config.SplitConfig{},
+	"tcpudp":          config.TCPUDPConfig{},
+	"tls":             config.TLSEndpointConfig{},
+	"tlsfrag":         config.TLSFragConfig{},
+	"websocket":       config.WebsocketEndpointConfig{},
+}
+
+// requiredTransportFields lists the keys that each $type in knownTransportShapes must set. The
+// other keys are optional.
+var requiredTransportFields = map[string][]string{
+	"chain":           {"dialers"},
+	"dial":            {"address"},
+	"first-supported": {"options"},
+	"http-connect":    {"endpoint"},
+	"shadowsocks":     {"endpoint", "cipher", "secret"},
+	"socks5":          {"endpoint"},
+	"split":           {"bytes"},
+	"tls":             {"endpoint"},
+	"tlsfrag":         {"length"},
+	"websocket":       {"url"},
+}
+
+// enumTypes lists the values accepted by string types with a fixed set of values.
+var enumTypes = map[reflect.Type][]string{
+	reflect.TypeFor[config.AddressFamily](): {
+		string(config.AddressFamilyAuto), string(config.AddressFamilyIPv4), string(config.AddressFamilyIPv6),
+	},
+}
+
+// nodeRef refers to the definition of a nested transport config.
+var nodeRef = map[string]any{"$ref": "#/$defs/node"}
+
+// TunnelConfigSchema returns a JSON Schema document describing the advanced YAML config format.
+// It's generated from the config structs, so it follows the fields the parsers accept.
+func TunnelConfigSchema() string {
+	defs := map[string]any{}
+	nodeOptions := []any{
+		// ss:// links and host:port addresses.
+		map[string]any{"type": "string"},
+		// $type defaults to shadowsocks, in the advanced or legacy format.
+		schemaForType(reflect.TypeFor[config.ShadowsocksConfig]()),
+		schemaForType(reflect.TypeFor[config.LegacyShadowsocksConfig]()),
+		// A reference to a named transport of the top-level transports mapping.
+		map[string]any{
+			"type":                 "object",
+			"properties":           map[string]any{transportRefKey: map[string]any{"type": "string"}},
+			"required":             []string{transportRefKey},
+			"additionalProperties": false,
+		},
+	}
+	names := make([]string, 0, len(knownTransportShapes))
+	for name := range knownTransportShapes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		def := schemaForType(reflect.TypeOf(knownTransportShapes[name]))
+		def["properties"].(map[string]any)[config.ConfigTypeKey] = map[string]any{"const": name}
+		def["required"] = append([]string{config.ConfigTypeKey}, requiredTransportFields[name]...)
+		defs[name] = def
+		nodeOptions = append(nodeOptions, map[string]any{"$ref": "#/$defs/" + name})
+	}
+	defs["node"] = map[string]any{"anyOf": nodeOptions}
+
+	schema := schemaForType(reflect.TypeFor[parseTunnelConfigRequest]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Outline tunnel config"
+	schema["$defs"] = defs
+	schemaBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		// The schema only has maps, slices and strings, so this can't happen.
+		panic(err)
+	}
+	return string(schemaBytes)
+}
+
+// schemaForType returns the JSON Schema of a config value of type t. Untyped values are treated as
+// nested transport configs.
+func schemaForType(t reflect.Type) map[string]any {
+	if values, ok := enumTypes[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaForType(t.Elem())
+	case reflect.Interface:
+		return nodeRef
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaForType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaForType(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			properties[yamlFieldName(field)] = schemaForType(field.Type)
+		}
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	default:
+		return map[string]any{}
+	}
+}
+
+// yamlFieldName returns the key of the struct field in YAML, following the rules of the YAML decoder.
+func yamlFieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
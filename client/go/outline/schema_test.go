@@ -0,0 +1,59 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TunnelConfigSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage
+			Required   []string
+		} `json:"$defs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(TunnelConfigSchema()), &schema))
+
+	require.ElementsMatch(t, []string{"version", "name", "tags", "region", "provider", "connectTimeoutMs", "addressFamily", "enabled", "resolver", "probeOrder", "tunnelDns", "healthCheckUrl", "bindAddress", "fallbackFirstHop", "hostIpOverride", "keepaliveSeconds", "transports", "transport", "error"}, keys(schema.Properties))
+	require.JSONEq(t, `{"$ref": "#/$defs/node"}`, string(schema.Properties["transport"]))
+	require.JSONEq(t, `{"type": "string", "enum": ["auto", "ipv4", "ipv6"]}`, string(schema.Properties["addressFamily"]))
+
+	require.Contains(t, schema.Defs, "node")
+	tcpudp := schema.Defs["tcpudp"]
+	require.ElementsMatch(t, []string{"$type", "tcp", "udp"}, keys(tcpudp.Properties))
+	require.JSONEq(t, `{"const": "tcpudp"}`, string(tcpudp.Properties["$type"]))
+	require.Equal(t, []string{"$type"}, tcpudp.Required)
+	require.ElementsMatch(t, []string{"$type", "endpoint", "cipher", "secret", "prefix", "prefixPreset"}, keys(schema.Defs["shadowsocks"].Properties))
+	require.Equal(t, []string{"$type", "endpoint", "cipher", "secret"}, schema.Defs["shadowsocks"].Required)
+}
+
+func Test_InvokeMethod_GetTunnelConfigSchema(t *testing.T) {
+	result := InvokeMethod(MethodGetTunnelConfigSchema, "")
+	require.Nil(t, result.Error)
+	require.Equal(t, TunnelConfigSchema(), result.Value)
+}
+
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
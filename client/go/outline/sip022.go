@@ -0,0 +1,86 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// sip022KeySizes are the key sizes of the Shadowsocks 2022 ciphers, as specified in SIP022.
+var sip022KeySizes = map[string]int{
+	"2022-blake3-aes-128-gcm":       16,
+	"2022-blake3-aes-256-gcm":       32,
+	"2022-blake3-chacha20-poly1305": 32,
+}
+
+// parseSIP022PSKs decodes the secret of a Shadowsocks 2022 cipher, which is a list of base64
+// pre-shared keys separated by colons: the identity PSKs of the relays, if any, followed by the
+// user PSK. Providers rotate keys by changing the user PSK only.
+func parseSIP022PSKs(cipher string, secret string) ([][]byte, error) {
+	keySize := sip022KeySizes[cipher]
+	var psks [][]byte
+	for i, encoded := range strings.Split(secret, ":") {
+		psk, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("PSK %d is not valid base64", i)
+		}
+		if len(psk) != keySize {
+			return nil, fmt.Errorf("PSK %d has %d bytes, but %s needs %d", i, len(psk), cipher, keySize)
+		}
+		psks = append(psks, psk)
+	}
+	return psks, nil
+}
+
+// checkSIP022Cipher validates the PSKs of a Shadowsocks 2022 cipher in the Shadowsocks transport of
+// the config, from a ss:// link or the advanced YAML. The Outline SDK doesn't implement the 2022
+// ciphers yet, so valid configs fail as unsupported rather than as an invalid cipher, with the
+// number of PSKs in the "pskCount" detail.
+func checkSIP022Cipher(transportConfigText string) *platerrors.PlatformError {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return nil
+	}
+	ssConfig := shadowsocksNodeConfig(node)
+	if ssConfig == nil {
+		return nil
+	}
+	cipher := strings.ToLower(ssConfig.Cipher)
+	if _, ok := sip022KeySizes[cipher]; !ok {
+		return nil
+	}
+	psks, err := parseSIP022PSKs(cipher, ssConfig.Secret)
+	if err != nil {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("invalid Shadowsocks 2022 secret: %s", err),
+			Details: platerrors.InvalidConfigDetails{Field: "secret", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("Shadowsocks 2022 cipher %q is not supported yet", cipher),
+		Details: platerrors.InvalidConfigDetails{
+			Field:  "cipher",
+			Reason: platerrors.ReasonUnsupported,
+			Extra:  platerrors.ErrorDetails{"cipher": cipher, "pskCount": int64(len(psks))},
+		}.ToErrorDetails(),
+	}
+}
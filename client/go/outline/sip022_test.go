@@ -0,0 +1,56 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_SIP022(t *testing.T) {
+	identityPSK := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef"))
+	userPSK := base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+	secret := identityPSK + ":" + userPSK
+	for _, input := range []string{
+		// SIP022 links put the PSKs in the userinfo as is, percent-encoded.
+		"ss://2022-blake3-aes-128-gcm:" + url.QueryEscape(secret) + "@example.com:4321/",
+		`
+transport:
+  $type: shadowsocks
+  endpoint: example.com:4321
+  cipher: 2022-blake3-aes-128-gcm
+  secret: "` + secret + `"`,
+	} {
+		result := doParseTunnelConfig(input)
+		require.NotNil(t, result.Error, input)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code, input)
+		require.Equal(t, platerrors.ErrorDetails{
+			"field":    "cipher",
+			"reason":   platerrors.ReasonUnsupported,
+			"cipher":   "2022-blake3-aes-128-gcm",
+			"pskCount": int64(2),
+		}, result.Error.Details, input)
+	}
+
+	// The PSKs must have the key size of the cipher.
+	result := NewClient("ss://2022-blake3-aes-256-gcm:" + url.QueryEscape(secret) + "@example.com:4321/")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.ErrorDetails{"field": "secret", "reason": platerrors.ReasonInvalidValue}, result.Error.Details)
+	require.Contains(t, result.Error.Message, "needs 32")
+}
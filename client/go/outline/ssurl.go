@@ -0,0 +1,333 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/goccy/go-yaml"
+)
+
+// validateShadowsocksURL checks the structure of a ss:// link, to report precise errors before
+// creating the client. It accepts the SIP002 format, with base64 or percent-encoded userinfo, and
+// the legacy format, where everything but the fragment and query is base64-encoded.
+func validateShadowsocksURL(link string) *platerrors.PlatformError {
+	_, _, perr := splitShadowsocksURL(link)
+	return perr
+}
+
+// shadowsocksURLParts are the decoded components of a ss:// link.
+type shadowsocksURLParts struct {
+	method   string
+	password string
+	host     string
+	port     string
+}
+
+// splitShadowsocksURL parses the ss:// link, in any of the formats of [validateShadowsocksURL],
+// into its decoded components, and returns the parsed URL for the query and fragment.
+func splitShadowsocksURL(link string) (*shadowsocksURLParts, *url.URL, *platerrors.PlatformError) {
+	ssURL, err := url.Parse(link)
+	if err != nil {
+		return nil, nil, newInvalidShadowsocksURLError("invalid URL")
+	}
+
+	var userInfo, hostPort string
+	if ssURL.User == nil {
+		// Legacy format: ss://base64(method:password@host:port).
+		decoded, ok := decodeBase64String(ssURL.Host)
+		if !ok {
+			return nil, nil, newInvalidShadowsocksURLError("invalid base64 userinfo")
+		}
+		lastAt := strings.LastIndex(decoded, "@")
+		if lastAt == -1 {
+			return nil, nil, newInvalidShadowsocksURLError("missing host:port")
+		}
+		userInfo, hostPort = decoded[:lastAt], decoded[lastAt+1:]
+	} else {
+		// SIP002 format: ss://userinfo@host:port.
+		hostPort = ssURL.Host
+		if password, hasPassword := ssURL.User.Password(); hasPassword {
+			// Percent-encoded method:password.
+			userInfo = ssURL.User.Username() + ":" + password
+		} else {
+			decoded, ok := decodeBase64String(ssURL.User.Username())
+			if !ok {
+				return nil, nil, newInvalidShadowsocksURLError("invalid base64 userinfo")
+			}
+			userInfo = decoded
+		}
+	}
+
+	method, password, found := strings.Cut(userInfo, ":")
+	if !found || method == "" {
+		return nil, nil, newInvalidShadowsocksURLError("userinfo must be method:password")
+	}
+	if password == "" {
+		return nil, nil, newInvalidShadowsocksURLError("missing password")
+	}
+
+	host, portText, err := net.SplitHostPort(hostPort)
+	if err != nil || host == "" {
+		return nil, nil, newInvalidShadowsocksURLError("missing host:port")
+	}
+	if port, err := strconv.ParseUint(portText, 10, 16); err != nil || port == 0 {
+		return nil, nil, newInvalidShadowsocksURLError("invalid port")
+	}
+	return &shadowsocksURLParts{method: method, password: password, host: host, port: portText}, ssURL, nil
+}
+
+// CanonicalizeSSURL rewrites a ss:// link in its canonical SIP002 form, so that equivalent links
+// compare equal: the userinfo is base64url-encoded without padding, the host is lowercase and the
+// query parameters are sorted. The method, password and "#tag" fragment are kept as they are.
+//
+// It accepts the same formats as [ParseTunnelConfig], including the legacy base64 links, and
+// returns an [platerrors.InvalidConfig] error for invalid links. Canonical links are returned
+// unchanged.
+func CanonicalizeSSURL(link string) (string, error) {
+	parts, ssURL, perr := splitShadowsocksURL(strings.TrimSpace(link))
+	if perr != nil {
+		return "", perr
+	}
+	query, err := parseLinkQuery(ssURL.RawQuery)
+	if err != nil {
+		return "", newInvalidShadowsocksURLError("invalid query")
+	}
+
+	var canonical strings.Builder
+	canonical.WriteString("ss://")
+	canonical.WriteString(base64.RawURLEncoding.EncodeToString([]byte(parts.method + ":" + parts.password)))
+	canonical.WriteString("@")
+	canonical.WriteString(net.JoinHostPort(strings.ToLower(parts.host), parts.port))
+	canonical.WriteString("/")
+	if len(query) > 0 {
+		// Encode sorts the parameters by key.
+		canonical.WriteString("?" + query.Encode())
+	}
+	if ssURL.Fragment != "" {
+		canonical.WriteString("#" + ssURL.EscapedFragment())
+	}
+	return canonical.String(), nil
+}
+
+// shadowsocksLinkName returns the server name in the "#tag" fragment of a ss:// link, percent-decoded,
+// or an empty string.
+func shadowsocksLinkName(link string) string {
+	ssURL, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(ssURL.Fragment)
+}
+
+func newInvalidShadowsocksURLError(message string) *platerrors.PlatformError {
+	return &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: "invalid ss:// link: " + message,
+		Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+	}
+}
+
+// decodeBase64String decodes text in any of the base64 variants used by ss:// links.
+func decodeBase64String(text string) (string, bool) {
+	for _, encoding := range []*base64.Encoding{
+		base64.URLEncoding.WithPadding(base64.NoPadding),
+		base64.StdEncoding.WithPadding(base64.NoPadding),
+		base64.URLEncoding,
+		base64.StdEncoding,
+	} {
+		if decoded, err := encoding.DecodeString(text); err == nil {
+			return string(decoded), true
+		}
+	}
+	return "", false
+}
+
+// supportedShadowsocksPlugins are the SIP003 plugins that can be translated to the advanced format.
+var supportedShadowsocksPlugins = []string{"v2ray-plugin"}
+
+type websocketEndpointYAML struct {
+	Type     string `yaml:"$type"`
+	URL      string `yaml:"url"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+type disabledYAML struct {
+	Type string `yaml:"$type"`
+}
+
+type pluginTCPUDPYAML struct {
+	Type string           `yaml:"$type"`
+	TCP  *shadowsocksYAML `yaml:"tcp"`
+	UDP  disabledYAML     `yaml:"udp"`
+}
+
+// translateShadowsocksPlugin converts a ss:// link with a SIP003 plugin to the equivalent transport
+// config in the advanced format. Links without a plugin are returned as is.
+func translateShadowsocksPlugin(link string) (string, *platerrors.PlatformError) {
+	transport, perr := shadowsocksPluginTransport(link)
+	if perr != nil {
+		return "", perr
+	}
+	if transport == nil {
+		return link, nil
+	}
+	out, err := yaml.Marshal(transport)
+	if err != nil {
+		return "", &platerrors.PlatformError{
+			Code:    platerrors.InternalError,
+			Message: "failed to serialize the plugin transport",
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// shadowsocksPluginTransport returns the transport of a ss:// link with a SIP003 plugin, to be
+// serialized as YAML, or nil if the link has no plugin.
+//
+// The plugin name and options are in the percent-encoded "plugin" query parameter, separated by
+// semicolons, as in "v2ray-plugin;tls;host=example.com". Options may also be in "plugin-opts".
+func shadowsocksPluginTransport(link string) (*pluginTCPUDPYAML, *platerrors.PlatformError) {
+	ssURL, err := url.Parse(link)
+	if err != nil {
+		return nil, newInvalidShadowsocksURLError("invalid URL")
+	}
+	query, err := parseLinkQuery(ssURL.RawQuery)
+	if err != nil {
+		return nil, newInvalidShadowsocksURLError("invalid query")
+	}
+	pluginText := query.Get("plugin")
+	if pluginText == "" {
+		return nil, nil
+	}
+	if perr := validateShadowsocksURL(link); perr != nil {
+		return nil, perr
+	}
+	pluginName, pluginOptions := parsePluginOptions(pluginText, query.Get("plugin-opts"))
+
+	switch pluginName {
+	case "v2ray-plugin":
+		return v2RayPluginTransport(link, pluginOptions)
+	default:
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("unsupported Shadowsocks plugin %q", pluginName),
+			Details: platerrors.InvalidConfigDetails{
+				Field:  "plugin",
+				Reason: platerrors.ReasonUnsupported,
+				Extra:  platerrors.ErrorDetails{"plugin": pluginName, "supportedPlugins": supportedShadowsocksPlugins},
+			}.ToErrorDetails(),
+		}
+	}
+}
+
+// parseLinkQuery is like [url.ParseQuery], but allows semicolons in the values, since unencoded
+// plugin parameters have them.
+func parseLinkQuery(rawQuery string) (url.Values, error) {
+	values := make(url.Values)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = url.QueryUnescape(value); err != nil {
+			return nil, err
+		}
+		values.Add(key, value)
+	}
+	return values, nil
+}
+
+// parsePluginOptions splits the SIP003 plugin parameter into the plugin name and its options,
+// appending the options in pluginOpts. Options without a value map to an empty string.
+func parsePluginOptions(plugin string, pluginOpts string) (string, map[string]string) {
+	name, optionsText, _ := strings.Cut(plugin, ";")
+	if pluginOpts != "" {
+		optionsText = strings.TrimPrefix(optionsText+";"+pluginOpts, ";")
+	}
+	options := make(map[string]string)
+	for _, option := range strings.Split(optionsText, ";") {
+		if option == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(option, "=")
+		options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(name), options
+}
+
+// v2RayPluginTransport converts a ss:// link with the v2ray-plugin to Shadowsocks over WebSocket.
+// The plugin doesn't relay UDP.
+func v2RayPluginTransport(link string, options map[string]string) (*pluginTCPUDPYAML, *platerrors.PlatformError) {
+	if mode, ok := options["mode"]; ok && mode != "websocket" {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("unsupported v2ray-plugin mode %q", mode),
+			Details: platerrors.InvalidConfigDetails{
+				Field:  "plugin",
+				Reason: platerrors.ReasonUnsupported,
+				Extra:  platerrors.ErrorDetails{"plugin": "v2ray-plugin", "mode": mode},
+			}.ToErrorDetails(),
+		}
+	}
+	ssConfig, err := config.ParseShadowsocksConfig(link)
+	if err != nil {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: "invalid Shadowsocks config",
+			Details: platerrors.InvalidConfigDetails{Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+			Cause:   platerrors.ToPlatformError(err),
+		}
+	}
+	endpoint, _ := ssConfig.Endpoint.(string)
+	host := options["host"]
+	if host == "" {
+		host, _, _ = net.SplitHostPort(endpoint)
+	}
+	path := options["path"]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	scheme := "ws"
+	if _, ok := options["tls"]; ok {
+		scheme = "wss"
+	}
+	websocketURL := url.URL{Scheme: scheme, Host: host, Path: path}
+
+	return &pluginTCPUDPYAML{
+		Type: "tcpudp",
+		TCP: &shadowsocksYAML{
+			Type:         "shadowsocks",
+			Endpoint:     websocketEndpointYAML{Type: "websocket", URL: websocketURL.String(), Endpoint: endpoint},
+			Cipher:       ssConfig.Cipher,
+			Secret:       yamlString(ssConfig.Secret),
+			Prefix:       yamlString(ssConfig.Prefix),
+			PrefixPreset: ssConfig.PrefixPreset,
+		},
+		UDP: disabledYAML{Type: "disabled"},
+	}, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validateShadowsocksURL(t *testing.T) {
+	for _, link := range []string{
+		// SIP002 with base64 userinfo.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ=@example.com:4321/?prefix=POST%20#name",
+		// SIP002 with percent-encoded userinfo.
+		"ss://chacha20-ietf-poly1305:SE%3ACRET@example.com:4321",
+		// Legacy base64.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVRAZXhhbXBsZS5jb206NDMyMQ#name",
+	} {
+		require.Nil(t, validateShadowsocksURL(link), link)
+	}
+}
+
+func Test_validateShadowsocksURL_Malformed(t *testing.T) {
+	for _, tc := range []struct {
+		link    string
+		message string
+	}{
+		{"ss://not%base64@example.com:4321", "invalid base64 userinfo"},
+		{"ss://!!!@example.com:4321", "invalid base64 userinfo"},
+		{"ss://not-base64!", "invalid base64 userinfo"},
+		// base64("chacha20-ietf-poly1305:SECRET"), without @host:port.
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ", "missing host:port"},
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com", "missing host:port"},
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@:4321", "missing host:port"},
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:0", "invalid port"},
+		{"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNQ@example.com:4321", "userinfo must be method:password"},
+		{"ss://chacha20-ietf-poly1305:@example.com:4321", "missing password"},
+	} {
+		t.Run(tc.link, func(t *testing.T) {
+			perr := validateShadowsocksURL(tc.link)
+			require.NotNil(t, perr)
+			require.Equal(t, platerrors.InvalidConfig, perr.Code)
+			require.Equal(t, "invalid ss:// link: "+tc.message, perr.Message)
+		})
+	}
+}
+
+func TestCanonicalizeSSURL(t *testing.T) {
+	const canonical = "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?outline=1&prefix=POST+#My%20Server"
+	for _, link := range []string{
+		// Legacy base64, with the query and fragment outside of the encoded part.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVRARXhhbXBsZS5DT006NDMyMQ?prefix=POST%20&outline=1#My%20Server",
+		// SIP002 with padded base64 userinfo.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ=@EXAMPLE.com:4321?prefix=POST%20&outline=1#My%20Server",
+		// SIP002 with percent-encoded userinfo.
+		"ss://chacha20-ietf-poly1305:SECRET@example.com:4321/?outline=1&prefix=POST%20#My%20Server",
+		// Already canonical.
+		canonical,
+	} {
+		result, err := CanonicalizeSSURL(link)
+		require.NoError(t, err, link)
+		require.Equal(t, canonical, result, link)
+	}
+}
+
+func TestCanonicalizeSSURL_PreservesCredentials(t *testing.T) {
+	result, err := CanonicalizeSSURL("ss://chacha20-ietf-poly1305:Se%3Acr%40et@[2001:DB8::1]:4321")
+	require.NoError(t, err)
+	require.Equal(t, "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTZTpjckBldA@[2001:db8::1]:4321/", result)
+
+	again, err := CanonicalizeSSURL(result)
+	require.NoError(t, err)
+	require.Equal(t, result, again)
+	parts, _, perr := splitShadowsocksURL(again)
+	require.Nil(t, perr)
+	require.Equal(t, "chacha20-ietf-poly1305", parts.method)
+	require.Equal(t, "Se:cr@et", parts.password)
+}
+
+func TestCanonicalizeSSURL_Invalid(t *testing.T) {
+	_, err := CanonicalizeSSURL("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com")
+	var perr *platerrors.PlatformError
+	require.ErrorAs(t, err, &perr)
+	require.Equal(t, platerrors.InvalidConfig, perr.Code)
+}
+
+func Test_doParseTunnel_MalformedSSURL(t *testing.T) {
+	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "invalid ss:// link: missing host:port", result.Error.Message)
+}
+
+func Test_doParseTunnel_SSURLUnsupportedPlugin(t *testing.T) {
+	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dexample.com")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, `unsupported Shadowsocks plugin "obfs-local"`, result.Error.Message)
+	require.Equal(t, "obfs-local", result.Error.Details["plugin"])
+
+	// The plugin parameter is not always percent-encoded.
+	result = doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/?plugin=obfs-local;obfs=http")
+	require.NotNil(t, result.Error)
+	require.Equal(t, `unsupported Shadowsocks plugin "obfs-local"`, result.Error.Message)
+}
+
+func Test_doParseTunnel_SSURLV2RayPlugin(t *testing.T) {
+	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:443/?plugin=v2ray-plugin%3Btls%3Bhost%3Dcdn.example.com%3Bpath%3D%2Fws#Server")
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Equal(t, "example.com:443", response.FirstHop)
+	require.Equal(t, "Server", response.Name)
+	require.False(t, response.UDPSupported)
+	require.Equal(t, `$type: tcpudp
+tcp:
+  $type: shadowsocks
+  endpoint:
+    $type: websocket
+    url: wss://cdn.example.com/ws
+    endpoint: example.com:443
+  cipher: chacha20-ietf-poly1305
+  secret: SECRET
+udp:
+  $type: disabled`, response.Transport)
+
+	result = doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:80/?plugin=v2ray-plugin&plugin-opts=mode%3Dquic")
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, `unsupported v2ray-plugin mode "quic"`, result.Error.Message)
+}
+
+func Test_parsePluginOptions(t *testing.T) {
+	name, options := parsePluginOptions("v2ray-plugin;tls;host=example.com", "path=/ws")
+	require.Equal(t, "v2ray-plugin", name)
+	require.Equal(t, map[string]string{"tls": "", "host": "example.com", "path": "/ws"}, options)
+
+	name, options = parsePluginOptions("obfs-local", "")
+	require.Equal(t, "obfs-local", name)
+	require.Empty(t, options)
+}
+
+func Test_doParseTunnel_SSURLTag(t *testing.T) {
+	for _, link := range []string{
+		// SIP002, with base64 and percent-encoded userinfo.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/#My%20Server",
+		"ss://chacha20-ietf-poly1305:SECRET@example.com:4321#My%20Server",
+		// Legacy.
+		"ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVRAZXhhbXBsZS5jb206NDMyMQ#My%20Server",
+	} {
+		result := doParseTunnelConfig(link)
+		require.Nil(t, result.Error, "Got %v for %v", result.Error, link)
+		var response TunnelConfig
+		require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+		require.Equal(t, "My Server", response.Name, link)
+		require.Equal(t, "example.com:4321", response.FirstHop, link)
+	}
+
+	result := doParseTunnelConfig("ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/")
+	require.Nil(t, result.Error)
+	var response TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+	require.Empty(t, response.Name)
+}
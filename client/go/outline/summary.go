@@ -0,0 +1,225 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+)
+
+// layerNames are the display names of the transport layers, by $type.
+var layerNames = map[string]string{
+	"first-supported": "First supported",
+	"http-connect":    "HTTP CONNECT",
+	"shadowsocks":     "Shadowsocks",
+	"socks5":          "SOCKS5",
+	"split":           "Split",
+	"tls":             "TLS",
+	"tlsfrag":         "TLS fragmentation",
+	"websocket":       "WebSocket",
+}
+
+// summarizeTransport describes the layers of the stream path of the transport config, outermost
+// first, followed by the first hop, as in "Shadowsocks over WebSocket/TLS (example.com:443)".
+// It only uses the layer types and the first hop, so it never includes secrets.
+func summarizeTransport(transportConfigText string, firstHop string) string {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return ""
+	}
+	layers := strings.Join(streamLayers(node), " over ")
+	if layers == "" || firstHop == "" {
+		return layers
+	}
+	return layers + " (" + firstHop + ")"
+}
+
+// streamLayers returns the display names of the layers of the node, outermost first.
+// Direct connections to an address have no layer.
+func streamLayers(node config.ConfigNode) []string {
+	switch typed := node.(type) {
+	case string:
+		if scheme, _, found := strings.Cut(typed, "://"); found && strings.EqualFold(scheme, "ss") {
+			return []string{layerNames["shadowsocks"]}
+		}
+		return nil
+	case map[string]any:
+		typeName, hasType := typed[config.ConfigTypeKey].(string)
+		switch {
+		case !hasType:
+			// Maps without a $type are parsed as Shadowsocks for backwards-compatibility.
+			return append([]string{layerNames["shadowsocks"]}, streamLayers(typed["endpoint"])...)
+		case typeName == "tcpudp":
+			return streamLayers(typed["tcp"])
+		case typeName == "dial":
+			return streamLayers(typed["dialer"])
+		case typeName == "websocket":
+			// WebSocket over TLS is shown as a single layer, whether it's from the URL or the endpoint.
+			name := layerNames["websocket"]
+			inner := streamLayers(typed["endpoint"])
+			if len(inner) > 0 && inner[0] == layerNames["tls"] {
+				name += "/TLS"
+				inner = inner[1:]
+			} else if websocketURL, ok := typed["url"].(string); ok {
+				if parsed, err := url.Parse(websocketURL); err == nil && (parsed.Scheme == "wss" || parsed.Scheme == "https") {
+					name += "/TLS"
+				}
+			}
+			return append([]string{name}, inner...)
+		case typeName == "first-supported":
+			return []string{layerNames["first-supported"]}
+		case typeName == "chain":
+			// Each dialer of the chain goes over the previous one.
+			var layers []string
+			dialers, _ := typed["dialers"].([]any)
+			for i := len(dialers) - 1; i >= 0; i-- {
+				layers = append(layers, streamLayers(dialers[i])...)
+			}
+			return layers
+		}
+		name, ok := layerNames[typeName]
+		if !ok {
+			name = typeName
+		}
+		inner := typed["endpoint"]
+		if inner == nil {
+			inner = typed["dialer"]
+		}
+		return append([]string{name}, streamLayers(inner)...)
+	default:
+		return nil
+	}
+}
+
+// TransportLayer describes a layer of the stream path of a transport. It must match the
+// TransportLayerJson definition in config.ts.
+type TransportLayer struct {
+	// Type is the $type of the layer, like "tls" or "shadowsocks".
+	Type string `json:"type"`
+	// Endpoint is the host:port the layer connects to, if it connects to an address rather than
+	// through the next layer.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Host is the host name the layer presents to the server, like the SNI of TLS or the Host of
+	// WebSocket, if it differs from the endpoint.
+	Host string `json:"host,omitempty"`
+}
+
+// listTransportLayers returns the layers of the stream path of the transport config, from the one
+// that connects to the first hop inward, as in tls, websocket, shadowsocks. The endpoint of the
+// outermost layer is set to firstHop, if known, so it matches the first hop of the config.
+func listTransportLayers(transportConfigText string, firstHop string) []TransportLayer {
+	node, err := config.ParseConfigYAML(transportConfigText)
+	if err != nil {
+		return nil
+	}
+	layers := transportLayers(node)
+	slices.Reverse(layers)
+	if len(layers) > 0 && firstHop != "" {
+		layers[0].Endpoint = firstHop
+	}
+	return layers
+}
+
+// transportLayers returns the layers of the node, in the order of [streamLayers].
+func transportLayers(node config.ConfigNode) []TransportLayer {
+	switch typed := node.(type) {
+	case string:
+		if scheme, _, found := strings.Cut(typed, "://"); found && strings.EqualFold(scheme, "ss") {
+			layer := TransportLayer{Type: "shadowsocks"}
+			if ssConfig, err := config.ParseShadowsocksConfig(typed); err == nil {
+				layer.Endpoint, _ = ssConfig.Endpoint.(string)
+			}
+			return []TransportLayer{layer}
+		}
+		return nil
+	case map[string]any:
+		typeName, hasType := typed[config.ConfigTypeKey].(string)
+		switch {
+		case !hasType:
+			// Maps without a $type are parsed as Shadowsocks for backwards-compatibility.
+			return layerWithEndpoint(TransportLayer{Type: "shadowsocks"}, typed["endpoint"])
+		case typeName == "tcpudp":
+			return transportLayers(typed["tcp"])
+		case typeName == "dial":
+			return transportLayers(typed["dialer"])
+		case typeName == "first-supported":
+			return []TransportLayer{{Type: typeName}}
+		case typeName == "chain":
+			var layers []TransportLayer
+			dialers, _ := typed["dialers"].([]any)
+			for i := len(dialers) - 1; i >= 0; i-- {
+				layers = append(layers, transportLayers(dialers[i])...)
+			}
+			return layers
+		case typeName == "websocket":
+			layer := TransportLayer{Type: typeName}
+			layer.Host, _ = typed["host"].(string)
+			websocketURL, _ := typed["url"].(string)
+			parsed, err := url.Parse(websocketURL)
+			if err == nil && layer.Host == "" {
+				layer.Host = parsed.Hostname()
+			}
+			if typed["endpoint"] == nil && err == nil && parsed.Hostname() != "" {
+				layer.Endpoint = websocketURLEndpoint(parsed)
+				return []TransportLayer{layer}
+			}
+			return layerWithEndpoint(layer, typed["endpoint"])
+		case typeName == "tls":
+			layer := TransportLayer{Type: typeName}
+			layer.Host, _ = typed["sni"].(string)
+			return layerWithEndpoint(layer, typed["endpoint"])
+		}
+		inner := typed["endpoint"]
+		if inner == nil {
+			inner = typed["dialer"]
+		}
+		return layerWithEndpoint(TransportLayer{Type: typeName}, inner)
+	default:
+		return nil
+	}
+}
+
+// layerWithEndpoint returns the layer followed by the layers of its inner node. An address, or an
+// address dialed with a dialer, is the endpoint of the layer rather than a layer.
+func layerWithEndpoint(layer TransportLayer, inner config.ConfigNode) []TransportLayer {
+	switch typed := inner.(type) {
+	case string:
+		if !strings.Contains(typed, "://") {
+			layer.Endpoint = typed
+			return []TransportLayer{layer}
+		}
+	case map[string]any:
+		if typed[config.ConfigTypeKey] == "dial" {
+			layer.Endpoint, _ = typed["address"].(string)
+			return append([]TransportLayer{layer}, transportLayers(typed["dialer"])...)
+		}
+	}
+	return append([]TransportLayer{layer}, transportLayers(inner)...)
+}
+
+// websocketURLEndpoint returns the host:port of the WebSocket URL, with the default port of its scheme.
+func websocketURLEndpoint(parsed *url.URL) string {
+	if port := parsed.Port(); port != "" {
+		return net.JoinHostPort(parsed.Hostname(), port)
+	}
+	if parsed.Scheme == "wss" || parsed.Scheme == "https" {
+		return net.JoinHostPort(parsed.Hostname(), "443")
+	}
+	return net.JoinHostPort(parsed.Hostname(), "80")
+}
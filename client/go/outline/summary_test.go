@@ -0,0 +1,168 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_Summary(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		summary string
+	}{
+		{
+			name:    "ss:// link",
+			input:   "ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/",
+			summary: "Shadowsocks (example.com:4321)",
+		},
+		{
+			name: "WebSocket over TLS",
+			input: `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: ws://cdn.example.com/tcp
+      endpoint:
+        $type: tls
+        sni: cdn.example.com
+        endpoint: example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled`,
+			summary: "Shadowsocks over WebSocket/TLS (example.com:443)",
+		},
+		{
+			name: "WebSocket URL",
+			input: `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: https://example.com/tcp
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled`,
+			summary: "Shadowsocks over WebSocket/TLS (example.com:443)",
+		},
+		{
+			name: "multi-hop",
+			input: `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: dial
+      address: exit.example.com:4321
+      dialer:
+        $type: split
+        bytes: 2
+        dialer: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@entry.example.com:4321/
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled`,
+			summary: "Shadowsocks over Split over Shadowsocks (entry.example.com:4321)",
+		},
+		{
+			name: "chain",
+			input: `
+transport:
+  $type: tcpudp
+  tcp:
+    $type: chain
+    dialers:
+      - $type: socks5
+        endpoint: jump.example.com:1080
+      - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@exit.example.com:4321/
+  udp:
+    $type: disabled`,
+			summary: "Shadowsocks over SOCKS5 (jump.example.com:1080)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := doParseTunnelConfig(tt.input)
+			require.Nil(t, result.Error, "Got %v", result.Error)
+			var response TunnelConfig
+			require.NoError(t, json.Unmarshal([]byte(result.Value), &response))
+			require.Equal(t, tt.summary, response.Summary)
+			require.NotContains(t, response.Summary, "SECRET")
+		})
+	}
+}
+
+func Test_doParseTunnelConfig_Layers(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: ws://cdn.example.com/tcp
+      endpoint:
+        $type: tls
+        sni: front.example.com
+        endpoint: example.com:443
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var tunnelConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &tunnelConfig))
+	require.Equal(t, []TransportLayer{
+		{Type: "tls", Endpoint: "example.com:443", Host: "front.example.com"},
+		{Type: "websocket", Host: "cdn.example.com"},
+		{Type: "shadowsocks"},
+	}, tunnelConfig.Layers)
+	require.Equal(t, tunnelConfig.FirstHop, tunnelConfig.Layers[0].Endpoint)
+}
+
+func Test_doParseTunnelConfig_LayersWebSocketURL(t *testing.T) {
+	result := doParseTunnelConfig(`
+transport:
+  $type: tcpudp
+  tcp:
+    $type: shadowsocks
+    endpoint:
+      $type: websocket
+      url: wss://example.com/tcp
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp:
+    $type: disabled`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var tunnelConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &tunnelConfig))
+	require.Equal(t, []TransportLayer{
+		{Type: "websocket", Endpoint: "example.com:443", Host: "example.com"},
+		{Type: "shadowsocks"},
+	}, tunnelConfig.Layers)
+	require.Equal(t, tunnelConfig.FirstHop, tunnelConfig.Layers[0].Endpoint)
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+)
+
+// transportRefKey is the key of a mapping that stands for a named transport of the top-level
+// "transports" mapping, as in "transport: {$ref: name}".
+const transportRefKey = "$ref"
+
+// maxResolvedTransportNodes bounds the number of values in a transport after its references are
+// resolved. Each reference copies its definition, so a few nested definitions that each reference
+// the previous one several times would expand exponentially, like the "billion laughs" attack.
+const maxResolvedTransportNodes = 10_000
+
+// resolveTransportRefs replaces the {$ref: name} mappings in the transport with the named
+// definitions, which may have references themselves. transportValue is the decoded transport, with
+// the YAML aliases resolved.
+//
+// The node is returned as is if it has no references. Otherwise, a new node is created from the
+// resolved value, since nodes from different places of the document can't be mixed in the output.
+func resolveTransportRefs(node ast.Node, transportValue any, definitions map[string]any) (ast.Node, *platerrors.PlatformError) {
+	if !hasTransportRef(transportValue) {
+		return node, nil
+	}
+	nodes := 0
+	resolved, perr := resolveTransportRefValue(transportValue, definitions, nil, &nodes)
+	if perr != nil {
+		return nil, perr
+	}
+	resolvedNode, err := yaml.ValueToNode(resolved)
+	if err != nil {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("failed to resolve transport references: %s", err),
+			Details: platerrors.InvalidConfigDetails{Field: "transport", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+		}
+	}
+	return resolvedNode, nil
+}
+
+// resolveTransportRefValue returns value with its references resolved. path lists the names being
+// resolved, to detect cycles. nodes counts the values of the result, which fails once it exceeds
+// [maxResolvedTransportNodes].
+func resolveTransportRefValue(value any, definitions map[string]any, path []string, nodes *int) (any, *platerrors.PlatformError) {
+	if *nodes++; *nodes > maxResolvedTransportNodes {
+		return nil, &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("transport has more than %d values once its references are resolved", maxResolvedTransportNodes),
+			Details: platerrors.InvalidConfigDetails{
+				Field:  transportRefKey,
+				Reason: platerrors.ReasonTooLarge,
+				Extra:  platerrors.ErrorDetails{"maxNodes": maxResolvedTransportNodes},
+			}.ToErrorDetails(),
+		}
+	}
+	switch typed := value.(type) {
+	case map[string]any:
+		if ref, isRef := typed[transportRefKey]; isRef {
+			name, ok := ref.(string)
+			if !ok || name == "" || len(typed) > 1 {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: "$ref must be a transport name and the only key of its mapping",
+					Details: platerrors.InvalidConfigDetails{Field: transportRefKey, Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+				}
+			}
+			if slices.Contains(path, name) {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: fmt.Sprintf("circular transport reference: %s -> %s", strings.Join(path, " -> "), name),
+					Details: platerrors.InvalidConfigDetails{
+						Field:  transportRefKey,
+						Reason: platerrors.ReasonInvalidValue,
+						Extra:  platerrors.ErrorDetails{"ref": name},
+					}.ToErrorDetails(),
+				}
+			}
+			definition, ok := definitions[name]
+			if !ok {
+				return nil, &platerrors.PlatformError{
+					Code:    platerrors.InvalidConfig,
+					Message: fmt.Sprintf("transport reference %q is not defined in transports", name),
+					Details: platerrors.InvalidConfigDetails{
+						Field:  transportRefKey,
+						Reason: platerrors.ReasonMissing,
+						Extra:  platerrors.ErrorDetails{"ref": name},
+					}.ToErrorDetails(),
+				}
+			}
+			return resolveTransportRefValue(definition, definitions, append(path, name), nodes)
+		}
+		resolved := make(map[string]any, len(typed))
+		for key, entry := range typed {
+			resolvedEntry, perr := resolveTransportRefValue(entry, definitions, path, nodes)
+			if perr != nil {
+				return nil, perr
+			}
+			resolved[key] = resolvedEntry
+		}
+		return resolved, nil
+	case []any:
+		resolved := make([]any, 0, len(typed))
+		for _, entry := range typed {
+			resolvedEntry, perr := resolveTransportRefValue(entry, definitions, path, nodes)
+			if perr != nil {
+				return nil, perr
+			}
+			resolved = append(resolved, resolvedEntry)
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// hasTransportRef returns whether the decoded transport has a {$ref: name} mapping.
+func hasTransportRef(value any) bool {
+	switch typed := value.(type) {
+	case map[string]any:
+		if hasKey(typed, transportRefKey) {
+			return true
+		}
+		for _, entry := range typed {
+			if hasTransportRef(entry) {
+				return true
+			}
+		}
+	case []any:
+		for _, entry := range typed {
+			if hasTransportRef(entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
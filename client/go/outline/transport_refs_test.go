@@ -0,0 +1,120 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doParseTunnelConfig_TransportRef(t *testing.T) {
+	result := doParseTunnelConfig(`
+transports:
+  ss:
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  split: {$type: tcpudp, tcp: {$ref: ss}, udp: {$ref: ss}}
+transport: {$ref: split}`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var tunnelConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &tunnelConfig))
+
+	expected := doParseTunnelConfig(versionTestTransport)
+	require.Nil(t, expected.Error)
+	var expectedConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(expected.Value), &expectedConfig))
+	require.Equal(t, expectedConfig.FirstHop, tunnelConfig.FirstHop)
+	expectedNode, err := config.ParseConfigYAML(expectedConfig.Transport)
+	require.NoError(t, err)
+	node, err := config.ParseConfigYAML(tunnelConfig.Transport)
+	require.NoError(t, err)
+	require.Equal(t, expectedNode, node)
+	require.Equal(t, "tcpudp", tunnelConfig.TransportType)
+	require.True(t, tunnelConfig.UDPSupported)
+}
+
+func Test_doParseTunnelConfig_TransportRefFallbacks(t *testing.T) {
+	result := doParseTunnelConfig(`
+transports:
+  ss: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+transport:
+  - {$ref: ss}
+  - ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@other.example.com:4321/`)
+	require.Nil(t, result.Error, "Got %v", result.Error)
+	var tunnelConfig TunnelConfig
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &tunnelConfig))
+	require.Equal(t, "example.com:4321", tunnelConfig.FirstHop)
+}
+
+func Test_doParseTunnelConfig_DanglingTransportRef(t *testing.T) {
+	result := doParseTunnelConfig(`
+transports:
+  ss: ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/
+transport: {$type: tcpudp, tcp: {$ref: ss}, udp: {$ref: missing}}`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, `transport reference "missing" is not defined in transports`, result.Error.Message)
+	require.Equal(t, platerrors.ErrorDetails{
+		"field": "$ref", "reason": platerrors.ReasonMissing, "ref": "missing",
+	}, result.Error.Details)
+}
+
+func Test_doParseTunnelConfig_CircularTransportRef(t *testing.T) {
+	result := doParseTunnelConfig(`
+transports:
+  a: {$ref: b}
+  b: {$type: tcpudp, tcp: {$ref: a}, udp: {$type: disabled}}
+transport: {$ref: a}`)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "circular transport reference: a -> b -> a", result.Error.Message)
+}
+
+func Test_doParseTunnelConfig_InvalidTransportRef(t *testing.T) {
+	for _, transport := range []string{"{$ref: 12}", "{$ref: ss, $type: tls}", "{$ref: ''}"} {
+		result := doParseTunnelConfig("transports: {ss: 'ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/'}\ntransport: " + transport)
+		require.NotNil(t, result.Error, transport)
+		require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+		require.Equal(t, platerrors.ErrorDetails{"field": "$ref", "reason": platerrors.ReasonInvalidValue}, result.Error.Details)
+	}
+}
+
+func Test_doParseTunnelConfig_TransportRefExpansion(t *testing.T) {
+	// Each level references the previous one 10 times, so the transport would expand to 10^9 links.
+	var input strings.Builder
+	input.WriteString("transports:\n  l0: 'ss://Y2hhY2hhMjAtaWV0Zi1wb2x5MTMwNTpTRUNSRVQ@example.com:4321/'\n")
+	for level := 1; level < 10; level++ {
+		ref := fmt.Sprintf("{$ref: l%d}", level-1)
+		fmt.Fprintf(&input, "  l%d: [%s]\n", level, strings.Repeat(ref+", ", 9)+ref)
+	}
+	input.WriteString("transport: {$ref: l9}")
+
+	result := doParseTunnelConfig(input.String())
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, platerrors.ErrorDetails{
+		"field":    "$ref",
+		"reason":   platerrors.ReasonTooLarge,
+		"maxNodes": maxResolvedTransportNodes,
+	}, result.Error.Details)
+}
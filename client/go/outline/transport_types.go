@@ -0,0 +1,82 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+)
+
+// TransportTypeDescriptor describes a $type of the advanced config format, for config editors.
+type TransportTypeDescriptor struct {
+	// Type is the $type value, e.g. "shadowsocks".
+	Type string `json:"type"`
+	// Kinds lists where the type can be used, e.g. "streamDialer" or "packetListener".
+	Kinds []string `json:"kinds"`
+	// Fields lists the keys of the type, in the order of the config struct.
+	Fields []TransportFieldDescriptor `json:"fields"`
+}
+
+// TransportFieldDescriptor describes a key of a transport config.
+type TransportFieldDescriptor struct {
+	Name string `json:"name"`
+	// Type is "string", "integer", "number", "boolean", "array" or "node", for nested transport configs.
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Enum lists the accepted values of strings with a fixed set of values.
+	Enum []string `json:"enum,omitempty"`
+}
+
+// TransportTypes returns the descriptors of the $type values that [NewClient] supports, sorted by
+// type. The types come from the registered parsers, so the list follows what the client accepts.
+func TransportTypes() []TransportTypeDescriptor {
+	configTypes := config.SupportedConfigTypes()
+	descriptors := make([]TransportTypeDescriptor, 0, len(configTypes))
+	for _, configType := range configTypes {
+		descriptor := TransportTypeDescriptor{Type: configType.Name, Kinds: configType.Kinds, Fields: []TransportFieldDescriptor{}}
+		if shape, ok := knownTransportShapes[configType.Name]; ok {
+			shapeType := reflect.TypeOf(shape)
+			for i := 0; i < shapeType.NumField(); i++ {
+				field := shapeType.Field(i)
+				if !field.IsExported() {
+					continue
+				}
+				name := yamlFieldName(field)
+				descriptor.Fields = append(descriptor.Fields, TransportFieldDescriptor{
+					Name:     name,
+					Type:     fieldTypeName(field.Type),
+					Required: slices.Contains(requiredTransportFields[configType.Name], name),
+					Enum:     enumTypes[field.Type],
+				})
+			}
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+	return descriptors
+}
+
+// fieldTypeName returns the type of a config field, as reported in [TransportFieldDescriptor].
+func fieldTypeName(t reflect.Type) string {
+	schema := schemaForType(t)
+	if _, ok := schema["$ref"]; ok {
+		return "node"
+	}
+	if schemaType, ok := schema["type"].(string); ok {
+		return schemaType
+	}
+	return "node"
+}
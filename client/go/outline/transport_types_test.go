@@ -0,0 +1,75 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TransportTypes(t *testing.T) {
+	descriptors := make(map[string]TransportTypeDescriptor)
+	for _, descriptor := range TransportTypes() {
+		descriptors[descriptor.Type] = descriptor
+	}
+
+	require.Equal(t, TransportTypeDescriptor{
+		Type:  "shadowsocks",
+		Kinds: []string{config.KindStreamDialer, config.KindPacketDialer, config.KindPacketListener},
+		Fields: []TransportFieldDescriptor{
+			{Name: "endpoint", Type: "node", Required: true},
+			{Name: "cipher", Type: "string", Required: true},
+			{Name: "secret", Type: "string", Required: true},
+			{Name: "prefix", Type: "string"},
+			{Name: "prefixPreset", Type: "string"},
+		},
+	}, descriptors["shadowsocks"])
+	require.Equal(t, []TransportFieldDescriptor{
+		{Name: "bytes", Type: "integer", Required: true},
+		{Name: "count", Type: "integer"},
+		{Name: "dialer", Type: "node"},
+	}, descriptors["split"].Fields)
+	require.Equal(t, []TransportFieldDescriptor{
+		{Name: "options", Type: "array", Required: true},
+	}, descriptors["first-supported"].Fields)
+	require.Empty(t, descriptors["disabled"].Fields)
+}
+
+func Test_TransportTypes_MatchRegistry(t *testing.T) {
+	// Every type the client accepts must have a known shape, and vice versa.
+	var registered []string
+	for _, descriptor := range TransportTypes() {
+		registered = append(registered, descriptor.Type)
+	}
+	require.ElementsMatch(t, keys(knownTransportShapes), registered)
+	for name, fields := range requiredTransportFields {
+		require.Contains(t, knownTransportShapes, name)
+		for _, field := range fields {
+			require.Contains(t, keys(schemaForType(reflect.TypeOf(knownTransportShapes[name]))["properties"].(map[string]any)), field, name)
+		}
+	}
+}
+
+func Test_InvokeMethod_GetTransportTypes(t *testing.T) {
+	result := InvokeMethod(MethodGetTransportTypes, "")
+	require.Nil(t, result.Error)
+	var descriptors []TransportTypeDescriptor
+	require.NoError(t, json.Unmarshal([]byte(result.Value), &descriptors))
+	require.Equal(t, TransportTypes(), descriptors)
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+)
+
+const dnsPort = 53
+
+// parseTunnelDNS parses the tunnelDns setting, an IP address with an optional port, which defaults
+// to 53. A host name is rejected, since there would be no resolver to resolve it with.
+func parseTunnelDNS(value string) (netip.AddrPort, *platerrors.PlatformError) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil && addrPort.Port() != 0 {
+		return addrPort, nil
+	}
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return netip.AddrPortFrom(addr, dnsPort), nil
+	}
+	return netip.AddrPort{}, &platerrors.PlatformError{
+		Code:    platerrors.InvalidConfig,
+		Message: fmt.Sprintf("tunnelDns must be an IP address with an optional port, found %q", value),
+		Details: platerrors.InvalidConfigDetails{Field: "tunnelDns", Reason: platerrors.ReasonInvalidValue}.ToErrorDetails(),
+	}
+}
+
+// tunnelDNSPacketListener is a [transport.PacketListener] that sends the DNS queries of the device,
+// the packets to port 53, to a resolver of choice inside the tunnel. Unlike the resolver setting,
+// which resolves the first hop, this changes the resolver of the traffic that goes over the tunnel.
+type tunnelDNSPacketListener struct {
+	transport.PacketListener
+	dnsAddr net.Addr
+}
+
+var _ transport.PacketListener = (*tunnelDNSPacketListener)(nil)
+
+func newTunnelDNSPacketListener(pl transport.PacketListener, dnsAddr netip.AddrPort) *tunnelDNSPacketListener {
+	return &tunnelDNSPacketListener{PacketListener: pl, dnsAddr: net.UDPAddrFromAddrPort(dnsAddr)}
+}
+
+func (l *tunnelDNSPacketListener) ListenPacket(ctx context.Context) (net.PacketConn, error) {
+	conn, err := l.PacketListener.ListenPacket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tunnelDNSPacketConn{PacketConn: conn, dnsAddr: l.dnsAddr}, nil
+}
+
+// tunnelDNSPacketConn redirects the writes to port 53 to dnsAddr. The responses from dnsAddr are
+// returned as coming from the original destination, which is what the device expects.
+type tunnelDNSPacketConn struct {
+	net.PacketConn
+	dnsAddr net.Addr
+
+	mu sync.Mutex
+	// queryAddr is the original destination of the last DNS query. Devices send their queries to a
+	// single DNS server, so one is enough.
+	queryAddr net.Addr
+}
+
+func (c *tunnelDNSPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if isDNSAddr(addr) && addr.String() != c.dnsAddr.String() {
+		c.mu.Lock()
+		c.queryAddr = addr
+		c.mu.Unlock()
+		addr = c.dnsAddr
+	}
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+func (c *tunnelDNSPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	n, addr, err := c.PacketConn.ReadFrom(p)
+	if addr != nil && addr.String() == c.dnsAddr.String() {
+		c.mu.Lock()
+		if c.queryAddr != nil {
+			addr = c.queryAddr
+		}
+		c.mu.Unlock()
+	}
+	return n, addr, err
+}
+
+// isDNSAddr returns whether addr is a UDP address with port 53.
+func isDNSAddr(addr net.Addr) bool {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.Port == dnsPort
+	}
+	if addr == nil {
+		return false
+	}
+	_, port, err := net.SplitHostPort(addr.String())
+	return err == nil && port == "53"
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/Jigsaw-Code/outline-sdk/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseTunnelDNS(t *testing.T) {
+	addr, perr := parseTunnelDNS("1.1.1.1")
+	require.Nil(t, perr)
+	require.Equal(t, netip.MustParseAddrPort("1.1.1.1:53"), addr)
+
+	addr, perr = parseTunnelDNS("[2606:4700:4700::1111]:5353")
+	require.Nil(t, perr)
+	require.Equal(t, netip.MustParseAddrPort("[2606:4700:4700::1111]:5353"), addr)
+
+	for _, value := range []string{"dns.example.com", "dns.example.com:53", "1.1.1.1:0", "1.1.1.1:dns"} {
+		_, perr = parseTunnelDNS(value)
+		require.NotNil(t, perr, value)
+		require.Equal(t, platerrors.InvalidConfig, perr.Code)
+	}
+}
+
+func TestTunnelDNSPacketListener(t *testing.T) {
+	pl := newTunnelDNSPacketListener(echoPacketListener{}, netip.MustParseAddrPort("1.1.1.1:53"))
+	conn, err := pl.ListenPacket(context.Background())
+	require.NoError(t, err)
+	underlying := conn.(*tunnelDNSPacketConn).PacketConn.(*echoPacketConn)
+	buf := make([]byte, 10)
+
+	// DNS queries go to the tunnel resolver, and the responses come from the original server.
+	queryAddr := &net.UDPAddr{IP: net.ParseIP("10.111.222.1"), Port: 53}
+	_, err = conn.WriteTo([]byte("query"), queryAddr)
+	require.NoError(t, err)
+	require.Equal(t, "1.1.1.1:53", (<-underlying.packets).String())
+	underlying.packets <- net.UDPAddrFromAddrPort(netip.MustParseAddrPort("1.1.1.1:53"))
+	_, addr, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, queryAddr, addr)
+
+	// Other packets are left alone.
+	otherAddr := &net.UDPAddr{IP: net.ParseIP("10.111.222.1"), Port: 443}
+	_, err = conn.WriteTo([]byte("data"), otherAddr)
+	require.NoError(t, err)
+	_, addr, err = conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, otherAddr, addr)
+}
+
+func Test_newClientWithBaseDialers_TunnelDNS(t *testing.T) {
+	tunnelDNS := netip.MustParseAddrPort("1.1.1.1:53")
+	client, err := newClientWithBaseDialers(context.Background(), "ss://chacha20-ietf-poly1305:SECRET@192.0.2.1:4321",
+		&transport.TCPDialer{}, &transport.UDPDialer{}, clientOptions{tunnelDNS: tunnelDNS})
+	require.NoError(t, err)
+	pl, ok := client.pl.PacketListener.(*tunnelDNSPacketListener)
+	require.True(t, ok, "packet listener is %T", client.pl.PacketListener)
+	require.Equal(t, net.UDPAddrFromAddrPort(tunnelDNS), pl.dnsAddr)
+
+	client, err = newClientWithBaseDialers(context.Background(), "ss://chacha20-ietf-poly1305:SECRET@192.0.2.1:4321",
+		&transport.TCPDialer{}, &transport.UDPDialer{}, clientOptions{})
+	require.NoError(t, err)
+	_, ok = client.pl.PacketListener.(*tunnelDNSPacketListener)
+	require.False(t, ok)
+}
+
+func Test_doParseTunnelConfig_TunnelDNS(t *testing.T) {
+	result := doParseTunnelConfig("tunnelDns: 1.1.1.1\ntransport: ss://chacha20-ietf-poly1305:SECRET@192.0.2.1:4321")
+	require.Nil(t, result.Error)
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"fmt"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+)
+
+// tunnelConfigMigrations upgrade configs of the advanced format to the next version: the entry at
+// index i upgrades a config of version i+1 to version i+2. Changes in the format that break older
+// configs must come with a migration, so that providers can keep serving them.
+var tunnelConfigMigrations []func(*parseTunnelConfigRequest) error
+
+// currentTunnelConfigVersion returns the version of the advanced format that the parser implements.
+func currentTunnelConfigVersion() int {
+	return len(tunnelConfigMigrations) + 1
+}
+
+// migrateTunnelConfig upgrades the config to the current version, in place. Configs without a
+// version are version 1. Versions newer than the current one are rejected, since their fields may
+// mean something else.
+func migrateTunnelConfig(request *parseTunnelConfigRequest) *platerrors.PlatformError {
+	if request.Version == 0 {
+		request.Version = 1
+	}
+	currentVersion := currentTunnelConfigVersion()
+	if request.Version < 0 {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("version must be a positive integer, found %d", request.Version),
+			Details: platerrors.InvalidConfigDetails{
+				Field:  "version",
+				Reason: platerrors.ReasonInvalidValue,
+				Extra:  platerrors.ErrorDetails{"version": request.Version},
+			}.ToErrorDetails(),
+		}
+	}
+	if request.Version > currentVersion {
+		return &platerrors.PlatformError{
+			Code:    platerrors.InvalidConfig,
+			Message: fmt.Sprintf("config version %d is not supported, please update the app", request.Version),
+			Details: platerrors.InvalidConfigDetails{
+				Field:  "version",
+				Reason: platerrors.ReasonUnsupported,
+				Extra:  platerrors.ErrorDetails{"version": request.Version, "supportedVersion": currentVersion},
+			}.ToErrorDetails(),
+		}
+	}
+	for ; request.Version < currentVersion; request.Version++ {
+		if err := tunnelConfigMigrations[request.Version-1](request); err != nil {
+			return &platerrors.PlatformError{
+				Code:    platerrors.InvalidConfig,
+				Message: fmt.Sprintf("failed to migrate config from version %d", request.Version),
+				Details: platerrors.InvalidConfigDetails{
+					Field:  "version",
+					Reason: platerrors.ReasonInvalidValue,
+					Extra:  platerrors.ErrorDetails{"version": request.Version},
+				}.ToErrorDetails(),
+				Cause: platerrors.ToPlatformError(err),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 The Outline Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Jigsaw-Code/outline-apps/client/go/outline/platerrors"
+	"github.com/stretchr/testify/require"
+)
+
+const versionTestTransport = `
+transport:
+  $type: tcpudp
+  tcp: &shared
+    $type: shadowsocks
+    endpoint: example.com:4321
+    cipher: chacha20-ietf-poly1305
+    secret: SECRET
+  udp: *shared`
+
+func Test_doParseTunnelConfig_Version(t *testing.T) {
+	unversioned := doParseTunnelConfig(versionTestTransport)
+	require.Nil(t, unversioned.Error)
+
+	versioned := doParseTunnelConfig("version: 1" + versionTestTransport)
+	require.Nil(t, versioned.Error)
+	require.Equal(t, unversioned.Value, versioned.Value)
+}
+
+func Test_doParseTunnelConfig_FutureVersion(t *testing.T) {
+	result := doParseTunnelConfig("version: 2" + versionTestTransport)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Contains(t, result.Error.Message, "please update the app")
+	require.Equal(t, platerrors.ErrorDetails{
+		"field": "version", "reason": platerrors.ReasonUnsupported, "version": 2, "supportedVersion": 1,
+	}, result.Error.Details)
+}
+
+func Test_doParseTunnelConfig_NegativeVersion(t *testing.T) {
+	result := doParseTunnelConfig("version: -1" + versionTestTransport)
+	require.NotNil(t, result.Error)
+	require.Equal(t, platerrors.InvalidConfig, result.Error.Code)
+	require.Equal(t, "version must be a positive integer, found -1", result.Error.Message)
+}
+
+func Test_migrateTunnelConfig(t *testing.T) {
+	// Version 2 counts the connect timeout in seconds, for the sake of the test.
+	t.Cleanup(func() { tunnelConfigMigrations = nil })
+	tunnelConfigMigrations = []func(*parseTunnelConfigRequest) error{
+		func(request *parseTunnelConfigRequest) error {
+			request.ConnectTimeoutMs /= 1000
+			return nil
+		},
+	}
+
+	request := parseTunnelConfigRequest{ConnectTimeoutMs: 5000}
+	require.Nil(t, migrateTunnelConfig(&request))
+	require.Equal(t, parseTunnelConfigRequest{Version: 2, ConnectTimeoutMs: 5}, request)
+
+	request = parseTunnelConfigRequest{Version: 2, ConnectTimeoutMs: 5}
+	require.Nil(t, migrateTunnelConfig(&request))
+	require.Equal(t, parseTunnelConfigRequest{Version: 2, ConnectTimeoutMs: 5}, request)
+}
+
+func Test_migrateTunnelConfig_Failure(t *testing.T) {
+	t.Cleanup(func() { tunnelConfigMigrations = nil })
+	tunnelConfigMigrations = []func(*parseTunnelConfigRequest) error{
+		func(*parseTunnelConfigRequest) error { return errors.New("bad field") },
+	}
+
+	perr := migrateTunnelConfig(&parseTunnelConfigRequest{Version: 1})
+	require.NotNil(t, perr)
+	require.Equal(t, platerrors.InvalidConfig, perr.Code)
+	require.Equal(t, "failed to migrate config from version 1", perr.Message)
+}
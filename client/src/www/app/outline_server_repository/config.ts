@@ -38,15 +38,99 @@ export class DynamicServiceConfig {
   constructor(readonly name: string, readonly transportConfigLocation: URL) {}
 }
 
+/** TransportCandidateJson describes an entry of a transport list. */
+export interface TransportCandidateJson {
+  transportType: string;
+  firstHop?: string;
+  streamFirstHop?: string;
+  packetFirstHop?: string;
+  streamFirstHops?: string[];
+  packetFirstHops?: string[];
+  error?: {code: string; message: string};
+}
+
+/** TransportLayerJson describes a layer of the stream path of a transport. */
+export interface TransportLayerJson {
+  type: string;
+  endpoint?: string;
+  host?: string;
+}
+
+/** TransportCapabilitiesJson tells which features a transport supports. */
+export interface TransportCapabilitiesJson {
+  udp: boolean;
+  fragmentation: boolean;
+  obfuscation: boolean;
+  multihop: boolean;
+}
+
 /**
  * TunnelConfigJson represents the configuration to set up a tunnel.
  * This is where VPN-layer parameters would go (e.g. interface IP, routes, dns, etc.).
  */
 export interface TunnelConfigJson {
+  /** firstHop is shared by the stream and packet paths. It's empty if they differ.
+   * It's the displayFirstHop of the transport instead, if set. */
   firstHop: string;
+  streamFirstHop?: string;
+  packetFirstHop?: string;
+  /** streamFirstHops and packetFirstHops list all the first hops, for transports with several. */
+  streamFirstHops?: string[];
+  packetFirstHops?: string[];
+  /** hopless is true if the transport reports no first hop, so there's no server to show. */
+  hopless?: boolean;
+  /** udpSupported is false if the transport doesn't relay UDP. */
+  udpSupported?: boolean;
+  /** udpOverTcp is true if UDP is tunneled over a stream, in which case firstHop is the stream hop. */
+  udpOverTcp?: boolean;
+  /** maxPacketSize is the largest UDP payload through the tunnel, if known. */
+  maxPacketSize?: number;
+  /** probeOrder is the path that the connectivity probes try first, if set in the config. */
+  probeOrder?: 'tcp' | 'udp';
+  /** healthCheckUrl is the URL that the health check fetches through the tunnel, if set in the config. */
+  healthCheckUrl?: string;
   /** transport describes how to establish connections to the destinations.
    * See https://github.com/Jigsaw-Code/outline-apps/blob/master/client/go/outline/config.go for format. */
   transport: string;
+  /** configId is a stable fingerprint of the transport that doesn't expose its credentials. */
+  configId?: string;
+  /** transportType is the kind of the outermost transport, e.g. "shadowsocks". */
+  transportType?: string;
+  /** cipher and keyBytes describe the encryption of Shadowsocks transports. */
+  cipher?: string;
+  keyBytes?: number;
+  /** prefixed is true if the Shadowsocks connections start with a prefix, raw or from a prefixPreset like "http-get". */
+  prefixed?: boolean;
+  /** firstHopIsHostname is true if a first hop is a hostname, so connecting depends on DNS. */
+  firstHopIsHostname?: boolean;
+  /** firstHopAddresses lists the IP addresses of the first hops, when requested or set in the hostIpOverride of the config. */
+  firstHopAddresses?: string[];
+  /** summary describes the transport layers and first hop, e.g. "Shadowsocks over WebSocket/TLS (example.com:443)". */
+  summary?: string;
+  /** layers lists the stream layers from the one that connects to the first hop inward, e.g. tls, websocket, shadowsocks. */
+  layers?: TransportLayerJson[];
+  /** fragmented is true if the transport fragments the stream, e.g. with split or tlsfrag. */
+  fragmented?: boolean;
+  /** capabilities tells which features the transport supports, derived from its layers. */
+  capabilities: TransportCapabilitiesJson;
+  /** selectedTransport is the index of the transport picked from a transport list, if any. */
+  selectedTransport?: number;
+  /** candidates lists every entry of a transport list, when requested. */
+  candidates?: TransportCandidateJson[];
+  /** name and tags are the optional display label and tags of the config. */
+  name?: string;
+  tags?: string[];
+  /** region and provider are the optional display labels of the server location and operator. */
+  region?: string;
+  provider?: string;
+  /** warnings lists non-fatal issues with the config, like deprecated fields. */
+  warnings?: string[];
+  /** cipherWarning is set if the Shadowsocks cipher is a deprecated name. Insecure ciphers fail the parse instead. */
+  cipherWarning?: {cipher: string; severity: 'low'; message: string};
+  /** fallbackFirstHopUsed is true if the first hop failed to resolve and the fallbackFirstHop of the config was used. */
+  fallbackFirstHopUsed?: boolean;
+  /** format is how the config was parsed, after fetching remote configs. */
+  format: 'ss-url' | 'legacy-json' | 'advanced-yaml';
 }
 
 /**
@@ -102,7 +102,7 @@ function convertRawErrorObjectToError(rawObj: object): Error {
     case GoErrorCode.PROVIDER_ERROR:
       return new errors.SessionProviderError(
         rawObj.message,
-        (detailsMap as {details?: string})?.details
+        (detailsMap as ProviderErrorDetails)?.details
       );
     default: {
       const error = new Error(detailsMessage, {cause});
@@ -166,6 +166,23 @@ function convertPlatformErrorToRawErrorObject(platErr: PlatformError): object {
  */
 export type ErrorDetails = {[key: string]: unknown};
 
+/**
+ * ProviderErrorDetails are the details of PROVIDER_ERROR errors, and of the errors of the provider
+ * codes that the app recognizes. They mirror ProviderErrorDetails in client/go/outline/platerrors.
+ */
+export interface ProviderErrorDetails extends ErrorDetails {
+  /** details is the details text of the error returned by the provider. */
+  details?: string;
+  /** retryAfterSeconds is how long to wait before fetching the config again. */
+  retryAfterSeconds?: number;
+  /** url, status and statusCode describe the rejection of the provisioning endpoint, if any. */
+  url?: string;
+  status?: string;
+  statusCode?: number;
+  /** providerCode is the code of the error envelope, if set. */
+  providerCode?: string;
+}
+
 /**
  * PlatformError is used to communicate error details from Go to TypeScript.
  */
@@ -289,11 +306,20 @@ export function deserializeError(errObj: string | Error | unknown): Error {
  */
 export enum GoErrorCode {
   INTERNAL_ERROR = 'ERR_INTERNAL_ERROR',
+  OPERATION_TIMED_OUT = 'ERR_OPERATION_TIMED_OUT',
+  /** Indicates a network failure to resolve a hostname. Retrying may succeed. */
+  RESOLVE_IP_FAILED = 'ERR_RESOLVE_IP_FAILURE',
   FETCH_CONFIG_FAILED = 'ERR_FETCH_CONFIG_FAILURE',
   INVALID_CONFIG = 'ERR_INVALID_CONFIG',
   PROVIDER_ERROR = 'ERR_PROVIDER',
+  /** Indicates that the provider disabled the config. */
+  CONFIG_DISABLED = 'ERR_CONFIG_DISABLED',
+  /** Indicates that the provider reported the config as expired. */
+  CONFIG_EXPIRED = 'ERR_CONFIG_EXPIRED',
   VPN_PERMISSION_NOT_GRANTED = 'ERR_VPN_PERMISSION_NOT_GRANTED',
   PROXY_SERVER_UNREACHABLE = 'ERR_PROXY_SERVER_UNREACHABLE',
+  /** Indicates that the health check URL of the config returned a non-2xx status. */
+  HEALTH_CHECK_FAILED = 'ERR_HEALTH_CHECK_FAILURE',
   /** Indicates that the OS routing service is not running (electron only). */
   ROUTING_SERVICE_NOT_RUNNING = 'ERR_ROUTING_SERVICE_NOT_RUNNING',
 }